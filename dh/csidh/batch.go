@@ -0,0 +1,124 @@
+package csidh
+
+import (
+	"io"
+	"runtime"
+	"sync"
+
+	"github.com/henrydcase/nobs/drbg"
+	"github.com/henrydcase/nobs/utils"
+)
+
+// Batch operations spread work on many public keys over a pool of
+// goroutines. Readers implementing io.Reader usually can't be used
+// concurrently, hence each worker uses its own CTR_DRBG, seeded with
+// randomness read from the reader provided by the caller.
+
+// fillErrors returns slice of 'n' errors, all equal to 'err'.
+func fillErrors(n int, err error) []error {
+	var errs = make([]error, n)
+	for i := range errs {
+		errs[i] = err
+	}
+	return errs
+}
+
+// runBatch calls job(i, rng) for i in [0, n) on 'workers' goroutines and
+// returns errors returned by each call. Each goroutine uses a separate
+// random stream. If 'workers' is not positive, runtime.GOMAXPROCS(0)
+// goroutines are used. If random streams can't be created, no job
+// is run and all errors match ErrRng.
+func runBatch(n, workers int, rng io.Reader, job func(i int, rng io.Reader) error) []error {
+	var errs = make([]error, n)
+	var wg sync.WaitGroup
+
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > n {
+		workers = n
+	}
+
+	// Seeds are read upfront, as 'rng' is used by one goroutine only
+	var rngs = make([]*drbg.CtrDrbg, workers)
+	var seed [drbg.SeedLen]byte
+	var err error
+	for w := range rngs {
+		if err = readRand(rng, seed[:]); err != nil {
+			break
+		}
+		rngs[w] = drbg.NewCtrDrbg()
+		if !rngs[w].Init(seed[:], nil) {
+			err = ErrRng
+			break
+		}
+	}
+	utils.Zeroize(seed[:])
+	if err != nil {
+		for _, r := range rngs {
+			if r != nil {
+				r.Destroy()
+			}
+		}
+		return fillErrors(n, err)
+	}
+
+	var idx = make(chan int, n)
+	for i := 0; i < n; i++ {
+		idx <- i
+	}
+	close(idx)
+
+	wg.Add(workers)
+	for w := range rngs {
+		go func(rng *drbg.CtrDrbg) {
+			defer wg.Done()
+			defer rng.Destroy()
+			for i := range idx {
+				errs[i] = job(i, rng)
+			}
+		}(rngs[w])
+	}
+	wg.Wait()
+	return errs
+}
+
+// ValidateBatch validates public keys 'pubs' concurrently on 'workers'
+// goroutines (runtime.GOMAXPROCS(0) if not positive). Returns slice of
+// errors of the same length as 'pubs'. Error at index i is nil if
+// pubs[i] is valid, otherwise it is the error returned by CheckPublicKey.
+func ValidateBatch(pubs []*PublicKey, workers int, rng io.Reader) []error {
+	return runBatch(len(pubs), workers, rng, func(i int, rng io.Reader) error {
+		// Validation uses buffers from the key, so copy is used. It
+		// allows the same key to be passed multiple times.
+		var pub = PublicKey{params: pubs[i].params, a: pubs[i].a}
+		return CheckPublicKey(&pub, rng)
+	})
+}
+
+// DeriveSecretBatch computes shared secrets of 'prv' with each of public
+// keys from 'pubs'. Shared secret with pubs[i] is stored in out[i], which
+// must have size equal to pubs[i].SharedSecretSize(). Work is done
+// concurrently on 'workers' goroutines (runtime.GOMAXPROCS(0) if not
+// positive). Returns slice of errors of the same length as 'pubs'. Error
+// at index i is nil if out[i] has been computed successfully, otherwise
+// it is the error returned by ComputeSharedSecret. If 'out' and 'pubs'
+// have different lengths, no shared secret is computed and all errors
+// are ErrBatchSize.
+func DeriveSecretBatch(out [][]byte, pubs []*PublicKey, prv *PrivateKey, workers int, rng io.Reader) []error {
+	if len(out) != len(pubs) {
+		return fillErrors(len(pubs), ErrBatchSize)
+	}
+	// Exponents are resolved before workers start, as exps allocates
+	// them on first use.
+	var e = prv.exps()
+	return runBatch(len(pubs), workers, rng, func(i int, rng io.Reader) error {
+		// Group action uses buffers from the keys, so copies are used.
+		var pub = PublicKey{params: pubs[i].params, a: pubs[i].a}
+		// Exponents are shared with 'prv', only the buffer is zeroized.
+		var sk = PrivateKey{params: prv.params, e: e, sampling: prv.sampling, simba: prv.simba}
+		defer utils.Zeroize(sk.wbuf[:])
+
+		return ComputeSharedSecret(out[i], &pub, &sk, rng)
+	})
+}
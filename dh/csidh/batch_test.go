@@ -0,0 +1,96 @@
+package csidh
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestValidateBatch(t *testing.T) {
+	var prv PrivateKey
+	var pubs = make([]*PublicKey, 6)
+
+	for i := range pubs {
+		pubs[i] = new(PublicKey)
+		if i%2 == 0 {
+			checkErr(t, GeneratePrivateKey(&prv, rng), "PrivateKey generation failed")
+			GeneratePublicKey(pubs[i], &prv, rng)
+		} else {
+			pubs[i].a = params512.two
+		}
+	}
+	// Same key passed twice
+	pubs[4] = pubs[0]
+
+	for _, workers := range []int{0, 1, 4, 10} {
+		errs := ValidateBatch(pubs, workers, rng)
+		Ok(t, len(errs) == len(pubs), "Wrong number of results")
+		for i := range errs {
+			if i%2 == 0 {
+				Ok(t, errs[i] == nil, "Valid key failed validation")
+			} else {
+				Ok(t, errs[i] == ErrInvalidPublicKey, "Invalid key passed validation")
+			}
+		}
+	}
+	Ok(t, len(ValidateBatch(nil, 0, rng)) == 0, "Wrong number of results")
+
+	// Seeding of the second worker fails
+	errs := ValidateBatch(pubs, 2, &failingReader{n: 1})
+	Ok(t, len(errs) == len(pubs), "Wrong number of results")
+	for _, err := range errs {
+		Ok(t, errors.Is(err, ErrRng) && errors.Is(err, errReader), "Expected RNG error")
+	}
+}
+
+func TestDeriveSecretBatch(t *testing.T) {
+	var prv, prvR PrivateKey
+	var pubs = make([]*PublicKey, 5)
+	var out = make([][]byte, len(pubs))
+
+	checkErr(t, GeneratePrivateKey(&prv, rng), "PrivateKey generation failed")
+	checkErr(t, GeneratePrivateKey(&prvR, rng), "PrivateKey generation failed")
+	pubs[0] = new(PublicKey)
+	GeneratePublicKey(pubs[0], &prvR, rng)
+	pubs[1] = pubs[0]
+	pubs[2] = &PublicKey{a: params512.twoNeg}
+	pubs[3] = NewPublicKey(Csidh1024)
+	pubs[4] = pubs[0]
+	for i := range out {
+		out[i] = make([]byte, SharedSecretSize)
+	}
+	out[4] = out[4][:1]
+
+	errs := DeriveSecretBatch(out, pubs, &prv, 0, rng)
+	Ok(t, errs[0] == nil && errs[1] == nil, "Derivation failed")
+	Ok(t, errs[2] == ErrInvalidPublicKey, "Invalid key passed validation")
+	Ok(t, errs[3] == ErrParamsMismatch, "Derivation with mixed parameters succeeded")
+	Ok(t, errs[4] == ErrSharedSecretSize, "Derivation with wrong buffer size succeeded")
+
+	var ss [SharedSecretSize]byte
+	Ok(t, DeriveSecret(&ss, pubs[0], &prv, rng), "Derivation failed")
+	Ok(t, bytes.Equal(ss[:], out[0]) && bytes.Equal(ss[:], out[1]), "Shared secrets differ")
+
+	errs = DeriveSecretBatch(out[:2], pubs, &prv, 0, rng)
+	Ok(t, len(errs) == len(pubs), "Wrong number of results")
+	for _, err := range errs {
+		Ok(t, err == ErrBatchSize, "Outputs of wrong length accepted")
+	}
+}
+
+func BenchmarkDeriveSecretBatch(b *testing.B) {
+	var pubs = make([]*PublicKey, 16)
+	var out = make([][]byte, len(pubs))
+
+	_ = GeneratePrivateKey(&prv1, rng)
+	for i := range pubs {
+		pubs[i] = new(PublicKey)
+		out[i] = make([]byte, SharedSecretSize)
+		GeneratePublicKey(pubs[i], &prv1, rng)
+	}
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		DeriveSecretBatch(out, pubs, &prv1, 0, rng)
+	}
+}
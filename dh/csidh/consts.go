@@ -19,6 +19,9 @@ const (
 	PublicKeySize = 64
 	// SharedSecretSize is a size of cSIDH/512 shared secret in bytes.
 	SharedSecretSize = 64
+	// PrivateKeyEncodingV1 is the version of canonical encoding of
+	// private keys, see PrivateKey.Encode.
+	PrivateKeyEncodingV1 = 1
 )
 
 var (
@@ -72,13 +75,6 @@ var (
 		0x229517D251910514, 0x06F26E6577649E80,
 	}
 
-	// 4 * sqrt(p)
-	fourSqrtP = fp{
-		0x17895E71E1A20B3F, 0x38D0CD95F8636A56,
-		0x142B9541E59682CD, 0x856F1399D91D6592,
-		0x0000000000000002,
-	}
-
 	// -p^-1 mod 2^64
 	pNegInv = fp{
 		0x66c1301f632e294d,
@@ -2,331 +2,670 @@ package csidh
 
 import (
 	"io"
-)
+	"unsafe"
 
-// 511-bit number representing prime field element GF(p)
-type fp [numWords]uint64
+	"github.com/henrydcase/nobs/utils"
+)
 
 // Represents projective point on elliptic curve E over GF(p)
 type point struct {
-	x fp
-	z fp
+	x fpx
+	z fpx
 }
 
 // Curve coefficients
 type coeff struct {
-	a fp
-	c fp
+	a fpx
+	c fpx
 }
 
+// PointSampling selects a method used by the group action to sample
+// random points. Result of the group action doesn't depend on it.
+type PointSampling uint8
+
+const (
+	// SampleRandom samples random x-coordinates and computes the Legendre
+	// symbol to find whether the point lies on the curve or on its twist.
+	// Points from the side which is already done are discarded. Default.
+	SampleRandom PointSampling = iota
+	// SampleElligator samples points with Elligator 2 (ia.cr/2018/1059).
+	// Each sample gives a point on the curve and a point on its twist,
+	// for a cost of single Legendre symbol. Both are used: the one
+	// processed second is mapped through the isogenies computed with
+	// the first one.
+	SampleElligator
+)
+
 type fpRngGen struct {
 	// working buffer needed to avoid memory allocation
-	wbuf [64]byte
+	wbuf [8 * maxWords]byte
 }
 
-// Defines operations on public key
+// Defines operations on public key. Zero value is a
+// CSIDH-512 key, keys for other parameter sets are created
+// with NewPublicKey.
 type PublicKey struct {
 	fpRngGen
 	// Montgomery coefficient A from GF(p) of the elliptic curve
 	// y^2 = x^3 + Ax^2 + x.
-	a fp
+	a fpx
+	// Parameter set, nil for zero value (CSIDH-512), see paramSet
+	params *params
 }
 
-// Defines operations on private key
+// Defines operations on private key. Zero value is a
+// CSIDH-512 key, keys for other parameter sets are created
+// with NewPrivateKey.
 type PrivateKey struct {
 	fpRngGen
-	// private key is a set of integers randomly
-	// each sampled from a range [-5, 5].
-	e [PrivateKeySize]int8
+	// private key is a set of integers, one per prime l_i,
+	// each sampled from a range [-expMax, expMax]. Stored in
+	// secbuf, allocated on first use, see exps.
+	e []int8
+	// Memory holding exponents
+	secbuf *utils.SecureBuffer
+	// Parameter set, nil for zero value (CSIDH-512), see paramSet
+	params *params
+	// Method of sampling points used by the group action
+	sampling PointSampling
+	// Batched strategy used by the group action, nil for default
+	// one of the parameter set
+	simba *simbaStrategy
+}
+
+// NewPrivateKey returns private key for parameter set 'id'.
+func NewPrivateKey(id uint8) *PrivateKey {
+	return &PrivateKey{params: paramsFor(id)}
+}
+
+// NewPublicKey returns public key for parameter set 'id'.
+func NewPublicKey(id uint8) *PublicKey {
+	return &PublicKey{params: paramsFor(id)}
+}
+
+// exps returns exponents of the key, one per prime of the parameter
+// set. Memory for them is allocated on first use.
+func (c *PrivateKey) exps() []int8 {
+	if c.e == nil {
+		n := len(c.paramSet().primes)
+		c.secbuf = utils.NewSecureBuffer(n)
+		b := c.secbuf.Bytes()
+		c.e = (*[maxPrimeCount]int8)(unsafe.Pointer(&b[0]))[:n:n]
+	}
+	return c.e
+}
+
+// paramSet returns parameter set of the key.
+func (c *PrivateKey) paramSet() *params {
+	if c.params == nil {
+		return params512
+	}
+	return c.params
+}
+
+// paramSet returns parameter set of the key.
+func (c *PublicKey) paramSet() *params {
+	if c.params == nil {
+		return params512
+	}
+	return c.params
 }
 
 // randFp generates random element from Fp.
-func (s *fpRngGen) randFp(v *fp, rng io.Reader) {
-	mask := uint64(1<<(pbits%limbBitSize)) - 1
+func (f *params) randFp(v *fpx, s *fpRngGen, rng io.Reader) error {
+	var mask = ^uint64(0)
+	if f.pbits%limbBitSize != 0 {
+		mask = (uint64(1) << uint(f.pbits%limbBitSize)) - 1
+	}
 	for {
-		*v = fp{}
-		_, err := io.ReadFull(rng, s.wbuf[:])
-		if err != nil {
-			panic("Can't read random number")
+		*v = fpx{}
+		buf := s.wbuf[:limbByteSize*f.words]
+		if err := readRand(rng, buf); err != nil {
+			return err
 		}
 
-		for i := 0; i < len(s.wbuf); i++ {
+		for i := range buf {
 			j := i / limbByteSize
 			k := uint(i % 8)
-			v[j] |= uint64(s.wbuf[i]) << (8 * k)
+			v[j] |= uint64(buf[i]) << (8 * k)
 		}
 
-		v[len(v)-1] &= mask
-		if isLess(v, &p) {
-			return
+		v[f.words-1] &= mask
+		if f.isLess(v, &f.p) {
+			return nil
 		}
 	}
 }
 
-// cofactorMul helper implements batch cofactor multiplication as described
-// in the ia.cr/2018/383 (algo. 3). Returns tuple of two booleans, first indicates
-// if function has finished successfully. In case first return value is true,
-// second return value indicates if curve represented by coffactor 'a' is
-// supersingular.
-// Implemenation uses divide-and-conquer strategy and recursion in order to
-// speed up calculation of Q_i = [(p+1)/l_i] * P.
-// Implementation is not constant time, but it operates on public data only.
-func cofactorMul(p *point, a *coeff, halfL, halfR int, order *fp) (bool, bool) {
-	var Q point
-	var r1, d1, r2, d2 bool
-	if (halfR - halfL) == 1 {
-		// base case
-		if !p.z.isZero() {
-			var tmp = fp{primes[halfL]}
-			xMul(p, p, a, &tmp)
-
-			if !p.z.isZero() {
-				// order does not divide p+1 -> ordinary curve
-				return true, false
-			}
+// elligator samples a pair of random points, P on the curve with
+// coefficient A and T on its twist. For random u, x-coordinates
+//   x1 = A/(u^2-1) and x2 = -u^2*x1
+// lie on opposite sides, hence single Legendre symbol is needed to find
+// which one is on the curve. Elligator doesn't work for A=0, in which
+// case x and -x are used. A.c must be equal to 1.
+func (f *params) elligator(P, T *point, A *coeff, s *fpRngGen, rng io.Reader) error {
+	var u, t, rhs fpx
+	for {
+		if err := f.randFp(&u, s, rng); err != nil {
+			return err
+		}
+		if f.isZero(&u) {
+			continue
+		}
 
-			mul512(order, order, primes[halfL])
-			if isLess(&fourSqrtP, order) {
-				// order > 4*sqrt(p) -> supersingular curve
-				return true, true
-			}
+		if f.isZero(&A.a) {
+			// f(-x) = -f(x) and -1 is non-square as p = 3 mod 4
+			P.x = u
+			P.z = f.one
+			f.sub(&T.x, &fpx{}, &u)
+			T.z = f.one
+			f.montEval(&rhs, &A.a, &u)
+			f.cswappoint(P, T, uint8(f.isNonQuadRes(&rhs)))
+			return nil
+		}
+
+		// P = (A : u^2-1), T = (-A*u^2 : u^2-1)
+		f.mul(&u, &u, &u)
+		f.sub(&P.z, &u, &f.one)
+		if f.isZero(&P.z) {
+			continue
 		}
-		return false, false
-	}
-
-	// perform another recursive step
-	mid := halfL + ((halfR - halfL + 1) / 2)
-	var mulL, mulR = fp{1}, fp{1}
-	// compute u = primes_1 * ... * primes_m
-	for i := halfL; i < mid; i++ {
-		mul512(&mulR, &mulR, primes[i])
-	}
-	// compute v = primes_m+1 * ... * primes_n
-	for i := mid; i < halfR; i++ {
-		mul512(&mulL, &mulL, primes[i])
-	}
-
-	// calculate Q_i
-	xMul(&Q, p, a, &mulR)
-	xMul(p, p, a, &mulL)
-
-	d1, r1 = cofactorMul(&Q, a, mid, halfR, order)
-	d2, r2 = cofactorMul(p, a, halfL, mid, order)
-	return d1 || d2, r1 || r2
-}
-
-// groupAction evaluates group action of prv.e on a Montgomery
-// curve represented by coefficient pub.A.
-// This is implementation of algorithm 2 from ia.cr/2018/383.
-func groupAction(pub *PublicKey, prv *PrivateKey, rng io.Reader) {
-	var k [2]fp
-	var e [2][primeCount]uint8
-	var done = [2]bool{false, false}
-	var A = coeff{a: pub.a, c: one}
-
-	k[0][0] = 4
-	k[1][0] = 4
-
-	for i, v := range primes {
-		t := (prv.e[uint(i)>>1] << ((uint(i) % 2) * 4)) >> 4
-		if t > 0 {
-			e[0][i] = uint8(t)
-			e[1][i] = 0
-			mul512(&k[1], &k[1], v)
-		} else if t < 0 {
-			e[1][i] = uint8(-t)
-			e[0][i] = 0
-			mul512(&k[0], &k[0], v)
+		P.x = A.a
+		T.z = P.z
+		f.mul(&T.x, &A.a, &u)
+		f.sub(&T.x, &fpx{}, &T.x)
+
+		// rhs = A*Z*(A^2*u^2 + Z^2), which is f(A/Z)*Z^4
+		f.mul(&t, &A.a, &A.a)
+		f.mul(&t, &t, &u)
+		f.mul(&rhs, &P.z, &P.z)
+		f.add(&rhs, &rhs, &t)
+		if f.isZero(&rhs) {
+			// point of order 2
+			continue
+		}
+		f.mul(&rhs, &rhs, &P.z)
+		f.mul(&rhs, &rhs, &A.a)
+		f.cswappoint(P, T, uint8(f.isNonQuadRes(&rhs)))
+		return nil
+	}
+}
+
+// groupAction evaluates group action of exponents 'e' on a Montgomery
+// curve represented by coefficient 'a'. Points are sampled with a method
+// given by 'sampling'.
+// This is implementation of algorithm 2 from ia.cr/2018/383, with
+// primes processed in batches given by 'simba', see simba.go.
+func (f *params) groupAction(a *fpx, e []int8, sampling PointSampling, simba *simbaStrategy, s *fpRngGen, rng io.Reader) error {
+	var ee [2][maxPrimeCount]uint8
+	var A = coeff{a: *a, c: f.one}
+
+	for i := range f.primes {
+		if e[i] > 0 {
+			ee[0][i] = uint8(e[i])
 		} else {
-			e[0][i] = 0
-			e[1][i] = 0
-			mul512(&k[0], &k[0], v)
-			mul512(&k[1], &k[1], v)
+			ee[1][i] = uint8(-e[i])
 		}
 	}
 
-	for {
-		var P point
-		var rhs fp
-		prv.randFp(&P.x, rng)
-		P.z = one
-		montEval(&rhs, &A.a, &P.x)
-		sign := rhs.isNonQuadRes()
-
-		if done[sign] {
-			continue
+	for r := 0; ; r++ {
+		var todo [2]bool
+		var b = simba.batch(r)
+		var batch = simba.batches[b]
+
+		for _, i := range batch {
+			todo[0] = todo[0] || (ee[0][i] != 0)
+			todo[1] = todo[1] || (ee[1][i] != 0)
+		}
+		if b == len(simba.batches)-1 && !todo[0] && !todo[1] {
+			break
 		}
 
-		xMul(&P, &P, &A, &k[sign])
-		done[sign] = true
+		for todo[0] || todo[1] {
+			// Points on the curve (P[0]) and on the twist (P[1]). With
+			// Elligator both are sampled at once, the one processed
+			// second is mapped by the isogenies of the first one.
+			var P [2]point
+			var have [2]bool
 
-		for i, v := range primes {
-			if e[sign][i] != 0 {
-				var cof = fp{1}
-				var K point
+			if sampling == SampleElligator {
+				if err := f.elligator(&P[0], &P[1], &A, s, rng); err != nil {
+					return err
+				}
+				have = todo
+			} else {
+				var rhs fpx
+				var x point
+				if err := f.randFp(&x.x, s, rng); err != nil {
+					return err
+				}
+				x.z = f.one
+				f.montEval(&rhs, &A.a, &x.x)
+				sign := f.isNonQuadRes(&rhs)
+				P[sign], have[sign] = x, todo[sign]
+			}
 
-				for j := i + 1; j < len(primes); j++ {
-					if e[sign][j] != 0 {
-						mul512(&cof, &cof, primes[j])
-					}
+			for sign := range P {
+				if !have[sign] {
+					continue
 				}
+				todo[sign] = false
 
-				xMul(&K, &P, &A, &cof)
-				if !K.z.isZero() {
-					xIso(&P, &A, &K, v)
-					e[sign][i] = e[sign][i] - 1
-					if e[sign][i] == 0 {
-						mul512(&k[sign], &k[sign], primes[i])
+				// Point on the other side, if it is still to be used
+				var img = []*point{&P[sign]}
+				if have[1-sign] {
+					img = append(img, &P[1-sign])
+				}
+
+				// Remove from the order of P all primes but those from
+				// the batch, for which isogenies are still to be computed.
+				var k = simba.cofactors[b]
+				for _, i := range batch {
+					if ee[sign][i] == 0 {
+						mulScalar(&k, &k, f.primes[i])
 					}
 				}
+				f.xMul(&P[sign], &P[sign], &A, &k)
+
+				for n := len(batch) - 1; n >= 0; n-- {
+					i := batch[n]
+					if ee[sign][i] != 0 {
+						var cof = fpx{1}
+						var K point
+
+						for _, j := range batch[:n] {
+							if ee[sign][j] != 0 {
+								mulScalar(&cof, &cof, f.primes[j])
+							}
+						}
+
+						f.xMul(&K, &P[sign], &A, &cof)
+						if !f.isZero(&K.z) {
+							if f.sqrtVelu != 0 && f.primes[i] >= f.sqrtVelu {
+								f.xIsoSqrt(&A, &K, f.primes[i], img...)
+							} else {
+								f.xIso(&A, &K, f.primes[i], img...)
+							}
+							ee[sign][i]--
+						}
+					}
+				}
+
+				f.exp(&A.c, &A.c, &f.pMin2)
+				f.mul(&A.a, &A.a, &A.c)
+				A.c = f.one
 			}
-			done[sign] = done[sign] && (e[sign][i] == 0)
 		}
+	}
+	*a = A.a
+	return nil
+}
 
-		modExpRdc512(&A.c, &A.c, &pMin1)
-		mulRdc(&A.a, &A.a, &A.c)
-		A.c = one
+// PrivateKey operations
 
-		if done[0] && done[1] {
-			break
+// ID returns identifier of the parameter set of the key.
+func (c *PrivateKey) ID() uint8 {
+	return c.paramSet().id
+}
+
+// Size returns size of the private key in bytes.
+func (c *PrivateKey) Size() int {
+	return c.paramSet().privateKeySize
+}
+
+// UnmarshalBinary imports private key in raw format, used by the
+// reference implementation of CSIDH. For CSIDH-512 it is an array of
+// PrivateKeySize bytes, each holding two exponents as 4-bit two's
+// complement numbers. Exponent of the prime l_i with even index i
+// (counting from 0) is stored in the high nibble of byte i/2, exponent
+// of l_(i+1) in its low nibble. For other parameter sets key is encoded
+// as one signed byte per prime. Returns ErrKeySize if size of the input
+// is wrong or ErrOutOfRange if exponents are out of range. Key is not
+// modified in case of error.
+func (c *PrivateKey) UnmarshalBinary(key []byte) error {
+	var e [maxPrimeCount]int8
+	var f = c.paramSet()
+
+	if len(key) != f.privateKeySize {
+		return ErrKeySize
+	}
+	if f.id == Csidh512 {
+		for i := range f.primes {
+			e[i] = (int8(key[i>>1]) << ((uint(i) % 2) * 4)) >> 4
+		}
+	} else {
+		for i, v := range key {
+			e[i] = int8(v)
 		}
 	}
-	pub.a = A.a
+	return c.SetExponents(e[:len(f.primes)])
 }
 
-// PrivateKey operations
+// MarshalBinary exports private key in raw format. See UnmarshalBinary
+// for details about the encoding.
+func (c *PrivateKey) MarshalBinary() ([]byte, error) {
+	out := make([]byte, c.Size())
+	c.export(out)
+	return out, nil
+}
 
-func (c *PrivateKey) Import(key []byte) bool {
-	if len(key) < len(c.e) {
-		return false
+// export writes encoded key to 'out', which must have at least c.Size()
+// bytes.
+func (c *PrivateKey) export(out []byte) {
+	var f = c.paramSet()
+	var e = c.exps()
+	if f.id == Csidh512 {
+		for i := 0; i < f.privateKeySize; i++ {
+			out[i] = byte(e[2*i]<<4) | byte(e[2*i+1]&0xF)
+		}
+		return
 	}
-	for i, v := range key {
-		c.e[i] = int8(v)
+	for i := range f.primes {
+		out[i] = byte(e[i])
 	}
-	return true
 }
 
+// Import works as UnmarshalBinary, but returns false instead of an error.
+func (c *PrivateKey) Import(key []byte) bool {
+	return c.UnmarshalBinary(key) == nil
+}
+
+// Export writes key in raw format to 'out'. Returns false if 'out' is
+// shorter than c.Size().
 func (c PrivateKey) Export(out []byte) bool {
-	if len(out) < len(c.e) {
+	if len(out) < c.Size() {
 		return false
 	}
-	for i, v := range c.e {
-		out[i] = byte(v)
-	}
+	c.export(out)
 	return true
 }
 
-func GeneratePrivateKey(key *PrivateKey, rng io.Reader) error {
-	for i := range key.e {
-		key.e[i] = 0
+// Encode returns canonical encoding of the key. It consists of a byte
+// with the version of the encoding (PrivateKeyEncodingV1), a byte with
+// identifier of the parameter set and the key in raw format (see
+// UnmarshalBinary). Each key has exactly one encoding.
+func (c *PrivateKey) Encode() []byte {
+	out := make([]byte, 2+c.Size())
+	out[0] = PrivateKeyEncodingV1
+	out[1] = c.ID()
+	c.export(out[2:])
+	return out
+}
+
+// DecodePrivateKey returns private key from its canonical encoding, see
+// Encode. Returns ErrKeyFormat if version of the encoding or parameter
+// set is not supported, ErrKeySize if size of the input is wrong or
+// ErrOutOfRange if exponents are out of range.
+func DecodePrivateKey(key []byte) (*PrivateKey, error) {
+	if len(key) < 2 || key[0] != PrivateKeyEncodingV1 {
+		return nil, ErrKeyFormat
 	}
+	switch key[1] {
+	case Csidh512, Csidh1024, Csidh1792:
+	default:
+		return nil, ErrKeyFormat
+	}
+	c := NewPrivateKey(key[1])
+	if err := c.UnmarshalBinary(key[2:]); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
 
-	for i := 0; i < len(primes); {
-		_, err := io.ReadFull(rng, key.wbuf[:])
-		if err != nil {
-			return err
+// Exponents returns the key as a vector of exponents, one per prime l_i,
+// in increasing order of primes. This is the form used by private keys
+// of many implementations of CSIDH.
+func (c *PrivateKey) Exponents() []int8 {
+	return append([]int8(nil), c.exps()...)
+}
+
+// SetExponents sets the key from a vector of exponents, see Exponents.
+// Returns ErrKeySize if number of exponents differs from number of primes
+// or ErrOutOfRange if exponents are out of range of the parameter set.
+// Key is not modified in case of error.
+func (c *PrivateKey) SetExponents(e []int8) error {
+	var f = c.paramSet()
+	if len(e) != len(f.primes) {
+		return ErrKeySize
+	}
+	for _, v := range e {
+		if v > f.expMax || v < -f.expMax {
+			return ErrOutOfRange
 		}
+	}
+	copy(c.exps(), e)
+	return nil
+}
 
-		for j := range key.wbuf {
-			if int8(key.wbuf[j]) <= expMax && int8(key.wbuf[j]) >= -expMax {
-				key.e[i>>1] |= int8((key.wbuf[j] & 0xF) << uint((i%2)*4))
-				i = i + 1
-				if i == len(primes) {
-					break
-				}
-			}
+// SetSimba sets parameters of the batched strategy (SIMBA, see
+// ia.cr/2018/1198) used by the group action with the key. Primes are
+// split into 'batches' batches, which are processed in turns for
+// 'rounds' rounds, after which the batches are merged into one. Like
+// SetPointSampling, it affects only the performance. Defaults are
+// SimbaBatches and SimbaRounds. Returns ErrOutOfRange if 'batches' is
+// not in the range [1, number of primes] or 'rounds' is negative.
+func (c *PrivateKey) SetSimba(batches, rounds int) error {
+	var f = c.paramSet()
+	if batches < 1 || batches > len(f.primes) || rounds < 0 {
+		return ErrOutOfRange
+	}
+	c.simba = newSimbaStrategy(f.primes, batches, rounds)
+	return nil
+}
+
+// strategy returns batched strategy used by the group action.
+func (c *PrivateKey) strategy() *simbaStrategy {
+	if c.simba == nil {
+		return c.paramSet().simba
+	}
+	return c.simba
+}
+
+// SetPointSampling sets the method used to sample random points during
+// evaluation of the group action with the key. It affects only the
+// performance, the result of key generation and derivation stays the same.
+func (c *PrivateKey) SetPointSampling(m PointSampling) {
+	c.sampling = m
+}
+
+// Destroy zeroizes the private key.
+func (c *PrivateKey) Destroy() {
+	if c.secbuf != nil {
+		c.secbuf.Destroy()
+	}
+	c.e, c.secbuf = nil, nil
+	utils.Zeroize(c.wbuf[:])
+}
+
+// GeneratePrivateKey generates random private key for the parameter set
+// of 'key'. Error is returned in case 'rng' fails.
+func GeneratePrivateKey(key *PrivateKey, rng io.Reader) error {
+	var f = key.paramSet()
+	var e = key.exps()
+	for i := range e {
+		v, err := key.randExp(int(f.expMax), rng)
+		if err != nil {
+			return err
 		}
+		e[i] = int8(v)
 	}
 	return nil
 }
 
 // Public key operations
 
-// Assumes key is in Montgomery domain.
-func (c *PublicKey) Import(key []byte) bool {
-	if len(key) != numWords*limbByteSize {
-		return false
+// ID returns identifier of the parameter set of the key.
+func (c *PublicKey) ID() uint8 {
+	return c.paramSet().id
+}
+
+// Size returns size of the public key in bytes.
+func (c *PublicKey) Size() int {
+	return c.paramSet().publicKeySize
+}
+
+// SharedSecretSize returns size of the shared secret in bytes.
+func (c *PublicKey) SharedSecretSize() int {
+	return c.Size()
+}
+
+// UnmarshalBinary imports public key. Key is encoded as coefficient A
+// in Montgomery domain, little-endian. Returns ErrKeySize if size of the
+// input is wrong. Key is not validated, see CheckPublicKey.
+func (c *PublicKey) UnmarshalBinary(key []byte) error {
+	if len(key) != c.Size() {
+		return ErrKeySize
 	}
+	c.a = fpx{}
 	for i := 0; i < len(key); i++ {
 		j := i / limbByteSize
 		k := uint64(i % 8)
 		c.a[j] |= uint64(key[i]) << (8 * k)
 	}
-	return true
+	return nil
 }
 
-// Assumes key is exported as encoded in Montgomery domain.
-func (c *PublicKey) Export(out []byte) bool {
-	if len(out) != numWords*limbByteSize {
-		return false
-	}
+// MarshalBinary exports public key. See UnmarshalBinary for details
+// about the encoding.
+func (c *PublicKey) MarshalBinary() ([]byte, error) {
+	out := make([]byte, c.Size())
+	c.export(out)
+	return out, nil
+}
+
+// export writes encoded key to 'out', which must have c.Size() bytes.
+func (c *PublicKey) export(out []byte) {
 	for i := 0; i < len(out); i++ {
 		j := i / limbByteSize
 		k := uint64(i % 8)
 		out[i] = byte(c.a[j] >> (8 * k))
 	}
+}
+
+// Import works as UnmarshalBinary, but returns false instead of an error.
+func (c *PublicKey) Import(key []byte) bool {
+	return c.UnmarshalBinary(key) == nil
+}
+
+// Export writes encoded key to 'out'. Returns false if size of 'out'
+// differs from c.Size().
+func (c *PublicKey) Export(out []byte) bool {
+	if len(out) != c.Size() {
+		return false
+	}
+	c.export(out)
 	return true
 }
 
+// ComputePublicKey computes public key corresponding to 'prv'. Parameter
+// set of 'pub' is set to the one of 'prv'. Error is returned in case
+// 'rng' fails.
+func ComputePublicKey(pub *PublicKey, prv *PrivateKey, rng io.Reader) error {
+	var f = prv.paramSet()
+	pub.params = prv.params
+	pub.a = fpx{}
+	return f.groupAction(&pub.a, prv.exps(), prv.sampling, prv.strategy(), &prv.fpRngGen, rng)
+}
+
+// GeneratePublicKey works as ComputePublicKey, but panics in case 'rng'
+// fails.
 func GeneratePublicKey(pub *PublicKey, prv *PrivateKey, rng io.Reader) {
-	for i := range pub.a {
-		pub.a[i] = 0
+	if err := ComputePublicKey(pub, prv, rng); err != nil {
+		panic(err)
 	}
-	groupAction(pub, prv, rng)
 }
 
-// Validate returns true if 'pub' is a valid cSIDH public key,
-// otherwise false.
+// CheckPublicKey returns nil if 'pub' is a valid cSIDH public key.
 // More precisely, the function verifies that curve
 //            y^2 = x^3 + pub.a * x^2 + x
-// is supersingular.
-func Validate(pub *PublicKey, rng io.Reader) bool {
+// is supersingular. Returns ErrOutOfRange if coefficient of the curve
+// is not smaller than p, ErrInvalidPublicKey if the curve is singular
+// or not supersingular, or an error matching ErrRng if 'rng' fails.
+func CheckPublicKey(pub *PublicKey, rng io.Reader) error {
+	var f = pub.paramSet()
+
 	// Check if in range
-	if !isLess(&pub.a, &p) {
-		return false
+	if !f.isLess(&pub.a, &f.p) {
+		return ErrOutOfRange
 	}
 
 	// Check if pub represents a smooth Montgomery curve.
-	if pub.a.equal(&two) || pub.a.equal(&twoNeg) {
-		return false
+	if f.equal(&pub.a, &f.two) || f.equal(&pub.a, &f.twoNeg) {
+		return ErrInvalidPublicKey
 	}
 
-	// Check if pub represents a supersingular curve.
-	for {
-		var P point
-		var A = point{pub.a, one}
-
-		// Randomly chosen P must have big enough order to check
-		// supersingularity. Probability of random P having big
-		// enough order is very high, as proven by W.Castryck et
-		// al. (ia.cr/2018/383, ch 5)
-		pub.randFp(&P.x, rng)
-		P.z = one
-
-		xDbl(&P, &P, &A)
-		xDbl(&P, &P, &A)
-
-		done, res := cofactorMul(&P, &coeff{A.x, A.z}, 0, len(primes), &fp{1})
-		if done {
-			return res
+	// Check if pub represents a supersingular curve. Random x must not
+	// be from GF(p), otherwise points lie in E(GF(p)), which for some
+	// ordinary curves is killed by p+1.
+	var x fp2
+	for f.isZero(&x.b) {
+		if err := f.randFp(&x.a, &pub.fpRngGen, rng); err != nil {
+			return err
+		}
+		if err := f.randFp(&x.b, &pub.fpRngGen, rng); err != nil {
+			return err
 		}
 	}
+	if !f.isSupersingular(&pub.a, &x) {
+		return ErrInvalidPublicKey
+	}
+	return nil
+}
+
+// Validate returns true if 'pub' is a valid cSIDH public key,
+// otherwise false. See CheckPublicKey.
+func Validate(pub *PublicKey, rng io.Reader) bool {
+	return CheckPublicKey(pub, rng) == nil
+}
+
+// ComputeSharedSecret computes a cSIDH shared secret and stores it in
+// 'out', which size must be equal to pub.SharedSecretSize(). Shared
+// secret is a Montgomery coefficient A of a secret curve
+// y^2 = x^3 + Ax^2 + x, computed by applying action of a prv.e on a curve
+// represented by pub.a. Returns ErrParamsMismatch if keys use different
+// parameter sets, ErrSharedSecretSize if 'out' has wrong size, errors
+// returned by CheckPublicKey if 'pub' is invalid or an error matching
+// ErrRng if 'rng' fails.
+func ComputeSharedSecret(out []byte, pub *PublicKey, prv *PrivateKey, rng io.Reader) error {
+	if pub.paramSet() != prv.paramSet() {
+		return ErrParamsMismatch
+	}
+	if len(out) != pub.SharedSecretSize() {
+		return ErrSharedSecretSize
+	}
+	if err := CheckPublicKey(pub, rng); err != nil {
+		return err
+	}
+	return deriveSecret(out, pub, prv, rng)
 }
 
 // DeriveSecret computes a cSIDH shared secret. If successful, returns true
-// and fills 'out' with shared secret. Function returns false in case 'pub' is invalid.
-// More precisely, shared secret is a Montgomery coefficient A of a secret
-// curve y^2 = x^3 + Ax^2 + x, computed by applying action of a prv.e
-// on a curve represented by pub.a.
+// and fills 'out' with shared secret. Function returns false in case 'pub'
+// is invalid or 'rng' fails. Function works only with CSIDH-512 keys, see
+// ComputeSharedSecret.
 func DeriveSecret(out *[64]byte, pub *PublicKey, prv *PrivateKey, rng io.Reader) bool {
-	var pk PublicKey
-
-	if !Validate(pub, rng) {
+	if pub.paramSet() != params512 || prv.paramSet() != params512 {
 		return false
 	}
+	return ComputeSharedSecret(out[:], pub, prv, rng) == nil
+}
+
+// DeriveSharedSecret works as ComputeSharedSecret, but returns false
+// instead of an error.
+func DeriveSharedSecret(out []byte, pub *PublicKey, prv *PrivateKey, rng io.Reader) bool {
+	return ComputeSharedSecret(out, pub, prv, rng) == nil
+}
+
+// deriveSecret computes shared secret without validation of 'pub'. Keys
+// must use the same parameter set and size of 'out' must be equal to
+// pub.SharedSecretSize(). Function doesn't modify 'pub'.
+func deriveSecret(out []byte, pub *PublicKey, prv *PrivateKey, rng io.Reader) error {
 	// Resulting shared secret is stored in the pk
-	copy(pk.a[:], pub.a[:])
-	groupAction(&pk, prv, rng)
-	pk.Export(out[:])
-	return true
+	var pk = PublicKey{params: pub.params, a: pub.a}
+	var f = prv.paramSet()
+	if err := f.groupAction(&pk.a, prv.exps(), prv.sampling, prv.strategy(), &prv.fpRngGen, rng); err != nil {
+		return err
+	}
+	pk.export(out)
+	return nil
 }
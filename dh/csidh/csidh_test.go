@@ -5,6 +5,8 @@ import (
 	crand "crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"testing"
 
@@ -123,29 +125,219 @@ func TestPrivateKeyExportImport(t *testing.T) {
 	}
 }
 
+func TestPrivateKeyEncoding(t *testing.T) {
+	for _, id := range []uint8{Csidh512, Csidh1024, Csidh1792} {
+		prv := NewPrivateKey(id)
+		checkErr(t, GeneratePrivateKey(prv, rng), "PrivateKey generation failed")
+
+		enc := prv.Encode()
+		Ok(t, len(enc) == 2+prv.Size() && enc[0] == PrivateKeyEncodingV1 && enc[1] == id,
+			"Wrong encoding")
+		dec, err := DecodePrivateKey(enc)
+		Ok(t, err == nil && bytes.Equal(dec.Encode(), enc), "Decoding failed")
+
+		e := prv.Exponents()
+		dec = NewPrivateKey(id)
+		checkErr(t, dec.SetExponents(e), "Setting exponents failed")
+		Ok(t, bytes.Equal(dec.Encode(), enc), "Exponents differ")
+
+		e[len(e)-1] = 6
+		Ok(t, dec.SetExponents(e) == ErrOutOfRange, "Expected ErrOutOfRange")
+		Ok(t, dec.SetExponents(e[1:]) == ErrKeySize, "Expected ErrKeySize")
+		Ok(t, bytes.Equal(dec.Encode(), enc), "Key modified by failed call")
+
+		_, err = DecodePrivateKey(enc[:len(enc)-1])
+		Ok(t, err == ErrKeySize, "Expected ErrKeySize")
+		enc[0] = 2
+		_, err = DecodePrivateKey(enc)
+		Ok(t, err == ErrKeyFormat, "Expected ErrKeyFormat")
+		enc[0], enc[1] = PrivateKeyEncodingV1, 3
+		_, err = DecodePrivateKey(enc)
+		Ok(t, err == ErrKeyFormat, "Expected ErrKeyFormat")
+	}
+
+	// Packing used by the reference implementation
+	var prv PrivateKey
+	var e [primeCount]int8
+	e[0], e[1], e[2], e[73] = 1, -2, -5, 5
+	checkErr(t, prv.SetExponents(e[:]), "Setting exponents failed")
+	raw, _ := prv.MarshalBinary()
+	Ok(t, raw[0] == 0x1E && raw[1] == 0xB0 && raw[36] == 0x05, "Wrong packing")
+
+	// Nibbles out of range
+	for _, v := range []byte{0x60, 0x06, 0xA0, 0x0A, 0x80} {
+		raw[5] = v
+		Ok(t, prv.UnmarshalBinary(raw) == ErrOutOfRange, "Expected ErrOutOfRange")
+		Ok(t, !prv.Import(raw), "Malformed key imported")
+	}
+}
+
+func TestPrivateKeyDestroy(t *testing.T) {
+	var prv PrivateKey
+	checkErr(t, GeneratePrivateKey(&prv, rng), "PrivateKey generation failed")
+	e := prv.exps()
+	prv.Destroy()
+	if prv.e != nil || prv.secbuf != nil || prv.fpRngGen != (fpRngGen{}) {
+		t.Error("Private key not zeroized")
+	}
+	for _, v := range e {
+		if v != 0 {
+			t.Error("Exponents not zeroized")
+		}
+	}
+}
+
+func TestPointSampling(t *testing.T) {
+	var prv PrivateKey
+	var pub1, pub2 PublicKey
+
+	for i := 0; i < numIter; i++ {
+		checkErr(t, GeneratePrivateKey(&prv, rng), "PrivateKey generation failed")
+		prv.SetPointSampling(SampleElligator)
+		GeneratePublicKey(&pub1, &prv, rng)
+		prv.SetPointSampling(SampleRandom)
+		GeneratePublicKey(&pub2, &prv, rng)
+		if pub1 != pub2 {
+			t.Error("Public key depends on point sampling")
+		}
+	}
+}
+
+func TestSimba(t *testing.T) {
+	var prv PrivateKey
+	var pub1, pub2 PublicKey
+
+	Ok(t, prv.SetSimba(0, 1) == ErrOutOfRange, "Accepted zero batches")
+	Ok(t, prv.SetSimba(len(primes)+1, 1) == ErrOutOfRange, "Accepted too many batches")
+	Ok(t, prv.SetSimba(3, -1) == ErrOutOfRange, "Accepted negative number of rounds")
+
+	checkErr(t, GeneratePrivateKey(&prv, rng), "PrivateKey generation failed")
+	GeneratePublicKey(&pub1, &prv, rng)
+	for _, v := range [][2]int{{1, 0}, {2, 3}, {5, 1}, {len(primes), 1}} {
+		checkErr(t, prv.SetSimba(v[0], v[1]), "SetSimba failed")
+		GeneratePublicKey(&pub2, &prv, rng)
+		if pub1 != pub2 {
+			t.Errorf("Public key depends on strategy %v", v)
+		}
+	}
+}
+
 func TestValidateNegative(t *testing.T) {
-	pk := PublicKey{a: p}
+	pk := PublicKey{a: params512.p}
 	pk.a[0]++
 	if Validate(&pk, rng) {
 		t.Error("Public key > p has been validated")
 	}
 
-	pk = PublicKey{a: p}
+	pk = PublicKey{a: params512.p}
 	if Validate(&pk, rng) {
 		t.Error("Public key == p has been validated")
 	}
 
-	pk = PublicKey{a: two}
+	pk = PublicKey{a: params512.two}
 	if Validate(&pk, rng) {
 		t.Error("Public key == 2 has been validated")
 	}
 
-	pk = PublicKey{a: twoNeg}
+	pk = PublicKey{a: params512.twoNeg}
 	if Validate(&pk, rng) {
 		t.Error("Public key == -2 has been validated")
 	}
 }
 
+// failingReader returns an error after 'n' successful reads.
+type failingReader struct {
+	n int
+}
+
+var errReader = errors.New("reader failed")
+
+func (r *failingReader) Read(b []byte) (int, error) {
+	if r.n == 0 {
+		return 0, errReader
+	}
+	r.n--
+	return rng.Read(b)
+}
+
+func TestRngFailure(t *testing.T) {
+	for _, id := range []uint8{Csidh512, Csidh1024} {
+		var prv = NewPrivateKey(id)
+		var pub = NewPublicKey(id)
+		var ss = make([]byte, pub.SharedSecretSize())
+
+		err := GeneratePrivateKey(prv, &failingReader{})
+		Ok(t, errors.Is(err, ErrRng) && errors.Is(err, errReader), "Expected RNG error")
+
+		checkErr(t, GeneratePrivateKey(prv, rng), "PrivateKey generation failed")
+		for _, s := range []PointSampling{SampleElligator, SampleRandom} {
+			prv.SetPointSampling(s)
+			err = ComputePublicKey(pub, prv, &failingReader{n: 1})
+			Ok(t, errors.Is(err, ErrRng), "Expected RNG error")
+		}
+		err = CheckPublicKey(pub, &failingReader{})
+		Ok(t, errors.Is(err, ErrRng), "Expected RNG error")
+		Ok(t, !Validate(pub, &failingReader{}), "Validation succeeded")
+
+		checkErr(t, ComputePublicKey(pub, prv, rng), "PublicKey generation failed")
+		// Validation succeeds, group action fails
+		err = ComputeSharedSecret(ss, pub, prv, &failingReader{n: 2})
+		Ok(t, errors.Is(err, ErrRng), "Expected RNG error")
+		Ok(t, !DeriveSharedSecret(ss, pub, prv, &failingReader{n: 2}), "Derivation succeeded")
+	}
+
+	var prv CtidhPrivateKey
+	var pub PublicKey
+	var ss [SharedSecretSize]byte
+	checkErr(t, GenerateCtidhPrivateKey(&prv, rng), "PrivateKey generation failed")
+	err := ComputeCtidhPublicKey(&pub, &prv, &failingReader{n: 1})
+	Ok(t, errors.Is(err, ErrRng), "Expected RNG error")
+	err = ComputeSharedSecretCtidh(&ss, &PublicKey{}, &prv, &failingReader{n: 2})
+	Ok(t, errors.Is(err, ErrRng), "Expected RNG error")
+
+	var prv512 PrivateKey
+	checkErr(t, GeneratePrivateKey(&prv512, rng), "PrivateKey generation failed")
+	defer func() {
+		Ok(t, recover() != nil, "GeneratePublicKey didn't panic")
+	}()
+	GeneratePublicKey(&pub, &prv512, &failingReader{})
+}
+
+func TestImportErrors(t *testing.T) {
+	var pub PublicKey
+	var prv PrivateKey
+	var ctidh CtidhPrivateKey
+	var buf [PublicKeySize + 1]byte
+
+	Ok(t, pub.UnmarshalBinary(buf[:]) == ErrKeySize, "Expected ErrKeySize")
+	Ok(t, prv.UnmarshalBinary(buf[:]) == ErrKeySize, "Expected ErrKeySize")
+	Ok(t, ctidh.UnmarshalBinary(buf[:]) == ErrKeySize, "Expected ErrKeySize")
+
+	// Coefficient equal to p
+	pub.a = params512.p
+	enc, _ := pub.MarshalBinary()
+	Ok(t, pub.UnmarshalBinary(enc) == nil, "Import failed")
+	Ok(t, CheckPublicKey(&pub, rng) == ErrOutOfRange, "Expected ErrOutOfRange")
+	pub.a = params512.two
+	Ok(t, CheckPublicKey(&pub, rng) == ErrInvalidPublicKey, "Expected ErrInvalidPublicKey")
+
+	prvx := NewPrivateKey(Csidh1024)
+	key := make([]byte, prvx.Size())
+	key[3] = byte(expMax)
+	Ok(t, prvx.UnmarshalBinary(key) == ErrOutOfRange, "Expected ErrOutOfRange")
+
+	key = make([]byte, CtidhPrivateKeySize)
+	key[0] = 100
+	Ok(t, ctidh.UnmarshalBinary(key) == ErrOutOfRange, "Expected ErrOutOfRange")
+
+	// Mixing parameter sets
+	ss := make([]byte, SharedSecretSize)
+	Ok(t, ComputeSharedSecret(ss, NewPublicKey(Csidh1024), &prv, rng) == ErrParamsMismatch,
+		"Expected ErrParamsMismatch")
+	Ok(t, ComputeSharedSecret(ss[:1], &PublicKey{}, &prv, rng) == ErrSharedSecretSize,
+		"Expected ErrSharedSecretSize")
+}
+
 func TestPublicKeyExportImport(t *testing.T) {
 	var buf [64]byte
 	eq64 := func(x, y []uint64) bool {
@@ -176,6 +368,7 @@ func TestPublicKeyExportImport(t *testing.T) {
 func TestKAT(t *testing.T) {
 	var tests TestVectors
 	var katFile string
+	var sampling PointSampling
 
 	// Helper checks if e==true and reports an error if not.
 	checkExpr := func(e bool, vec *TestVector, t *testing.T, msg string) {
@@ -200,6 +393,8 @@ func TestKAT(t *testing.T) {
 		var pub1, pub2 PublicKey
 		var ss [SharedSecretSize]byte
 
+		prv1.SetPointSampling(sampling)
+
 		prBuf, err := hex.DecodeString(vec.Pr1)
 		if err != nil {
 			t.Fatal(err)
@@ -278,29 +473,36 @@ func TestKAT(t *testing.T) {
 	if err != nil {
 		t.Fatal(err.Error())
 	}
-	// Loop over all test cases
-	for i := range tests.Vectors {
-		if !hasADXandBMI2 && i >= numIter {
-			// The algorithm is relatively slow, so on slow systems test
-			// against smaller number of test vectors (otherwise CI may break)
-			return
-		}
-		test := tests.Vectors[i]
-		switch test.Status {
-		case StatusValues[Valid]:
-			checkSharedSecret(&test, t, Valid)
-			checkPublicKey2(&test, t, Valid)
-		case StatusValues[InvalidSharedSecret]:
-			checkSharedSecret(&test, t, InvalidSharedSecret)
-		case StatusValues[InvalidPublicKey1]:
-			checkPublicKey1(&test, t)
-		case StatusValues[InvalidPublicKey2]:
-			checkPublicKey2(&test, t, InvalidPublicKey2)
-		case StatusValues[InvalidPublicKey2]:
-			checkPublicKey2(&test, t, InvalidPublicKey2)
-		case StatusValues[ValidPublicKey2]:
-			checkPublicKey2(&test, t, ValidPublicKey2)
-		}
+	// Run test vectors with both methods of sampling points. Result
+	// of the group action must not depend on it.
+	for _, sampling = range []PointSampling{SampleElligator, SampleRandom} {
+		name := map[PointSampling]string{SampleElligator: "Elligator", SampleRandom: "Random"}[sampling]
+		t.Run(name, func(t *testing.T) {
+			// Loop over all test cases
+			for i := range tests.Vectors {
+				if !hasADXandBMI2 && i >= numIter {
+					// The algorithm is relatively slow, so on slow systems test
+					// against smaller number of test vectors (otherwise CI may break)
+					return
+				}
+				test := tests.Vectors[i]
+				switch test.Status {
+				case StatusValues[Valid]:
+					checkSharedSecret(&test, t, Valid)
+					checkPublicKey2(&test, t, Valid)
+				case StatusValues[InvalidSharedSecret]:
+					checkSharedSecret(&test, t, InvalidSharedSecret)
+				case StatusValues[InvalidPublicKey1]:
+					checkPublicKey1(&test, t)
+				case StatusValues[InvalidPublicKey2]:
+					checkPublicKey2(&test, t, InvalidPublicKey2)
+				case StatusValues[InvalidPublicKey2]:
+					checkPublicKey2(&test, t, InvalidPublicKey2)
+				case StatusValues[ValidPublicKey2]:
+					checkPublicKey2(&test, t, ValidPublicKey2)
+				}
+			}
+		})
 	}
 }
 
@@ -323,6 +525,44 @@ func BenchmarkGenerateKeyPair(b *testing.B) {
 	}
 }
 
+// Compares methods of sampling points used by the group action.
+func BenchmarkPointSampling(b *testing.B) {
+	for _, s := range []PointSampling{SampleRandom, SampleElligator} {
+		name := map[PointSampling]string{SampleElligator: "Elligator", SampleRandom: "Random"}[s]
+		b.Run(name, func(b *testing.B) {
+			var prv PrivateKey
+			var pub PublicKey
+			prv.SetPointSampling(s)
+			for n := 0; n < b.N; n++ {
+				_ = GeneratePrivateKey(&prv, rng)
+				GeneratePublicKey(&pub, &prv, rng)
+			}
+		})
+	}
+}
+
+// Compares parameters of the batched strategy. Used to choose
+// SimbaBatches and SimbaRounds.
+func BenchmarkSimba(b *testing.B) {
+	for m := 1; m <= 6; m++ {
+		for k := 0; k <= 3; k++ {
+			if m == 1 && k > 0 {
+				// Single batch is the merged one
+				continue
+			}
+			b.Run(fmt.Sprintf("%d-%d", m, k), func(b *testing.B) {
+				var prv PrivateKey
+				var pub PublicKey
+				checkErr(b, prv.SetSimba(m, k), "SetSimba failed")
+				for n := 0; n < b.N; n++ {
+					_ = GeneratePrivateKey(&prv, rng)
+					GeneratePublicKey(&pub, &prv, rng)
+				}
+			})
+		}
+	}
+}
+
 // Benchmark validation on same key multiple times.
 func BenchmarkValidate(b *testing.B) {
 	prvBytes := []byte{0xaa, 0x54, 0xe4, 0xd4, 0xd0, 0xbd, 0xee, 0xcb, 0xf4, 0xd0, 0xc2, 0xbc, 0x52, 0x44, 0x11, 0xee, 0xe1, 0x14, 0xd2, 0x24, 0xe5, 0x0, 0xcc, 0xf5, 0xc0, 0xe1, 0x1e, 0xb3, 0x43, 0x52, 0x45, 0xbe, 0xfb, 0x54, 0xc0, 0x55, 0xb2}
@@ -0,0 +1,520 @@
+package csidh
+
+import (
+	"encoding/binary"
+	"io"
+	"math/bits"
+	"unsafe"
+
+	"github.com/henrydcase/nobs/utils"
+)
+
+// Constant-time variant of the group action, based on CTIDH
+// (ia.cr/2021/633). It works with the same prime and the same
+// public keys as cSIDH-512, but private keys are sampled from
+// a different key space.
+//
+// Primes are split into batches. Private key is a vector of
+// exponents, such that for each batch, sum of absolute values of
+// exponents is not bigger than the bound assigned to the batch.
+// Group action performs for each batch exactly as many isogenies
+// as the bound specifies, where each isogeny is either real or a
+// dummy one. Secret prime of the batch is hidden by computing
+// isogenies with Matryoshka structure - cost of the isogeny is always
+// the one of the largest prime in the batch. Probability of the
+// isogeny failure is equalized for all primes of the batch, so
+// it doesn't leak the prime. The algorithm branches only on public
+// data (batch bounds and values revealed by successful isogenies).
+
+const (
+	// CtidhPrivateKeySize is a size of CTIDH-512 private key in bytes.
+	CtidhPrivateKeySize = primeCount
+	// number of batches
+	ctidhBatchCount = 15
+)
+
+var (
+	// Number of consecutive primes in each batch.
+	ctidhBatchSize = [ctidhBatchCount]int{
+		2, 3, 4, 4, 5, 5, 5, 6, 6, 6, 6, 7, 7, 7, 1}
+
+	// Bound on number of isogenies computed in each batch. Bounds are
+	// chosen so that key space has ~2^256.5 elements.
+	ctidhBatchBound = [ctidhBatchCount]int{
+		3, 5, 8, 8, 10, 11, 12, 14, 16, 17, 19, 23, 27, 32, 8}
+)
+
+// ctidhBatch describes a batch of primes.
+type ctidhBatch struct {
+	// index of the first and one after last prime in the batch
+	start, end int
+	// product of all primes in the batch
+	prod fpx
+	// threshold[i] ~ 2^64 * P(success)_min / P(success)_i, used to
+	// equalize probability of successful isogeny computation.
+	threshold []uint64
+}
+
+var ctidhBatches [ctidhBatchCount]ctidhBatch
+
+func init() {
+	var start int
+	for j := range ctidhBatches {
+		b := &ctidhBatches[j]
+		b.start, b.end = start, start+ctidhBatchSize[j]
+		b.prod = fpx{1}
+		b.threshold = make([]uint64, ctidhBatchSize[j])
+
+		lmin := primes[b.start]
+		for i := b.start; i < b.end; i++ {
+			l := primes[i]
+			mulScalar(&b.prod, &b.prod, l)
+			// Probability of success for prime l is (1-1/l). Isogeny
+			// is accepted with probability (1-1/lmin)/(1-1/l), which
+			// is (lmin-1)*l / (lmin*(l-1)).
+			if l == lmin {
+				b.threshold[i-b.start] = ^uint64(0)
+			} else {
+				b.threshold[i-b.start], _ = bits.Div64((lmin-1)*l, 0, lmin*(l-1))
+			}
+		}
+		start = b.end
+	}
+}
+
+// CtidhPrivateKey is a private key of constant-time variant of the
+// group action.
+type CtidhPrivateKey struct {
+	fpRngGen
+	// exponents of the group action, one per prime, stored in
+	// secbuf, allocated on first use, see exps.
+	e []int8
+	// Memory holding exponents
+	secbuf *utils.SecureBuffer
+}
+
+// exps returns exponents of the key. Memory for them is allocated on
+// first use.
+func (c *CtidhPrivateKey) exps() []int8 {
+	if c.e == nil {
+		c.secbuf = utils.NewSecureBuffer(primeCount)
+		b := c.secbuf.Bytes()
+		c.e = (*[primeCount]int8)(unsafe.Pointer(&b[0]))[:]
+	}
+	return c.e
+}
+
+// Constant-time helpers
+
+// ctLess64 returns 1 if x < y, otherwise 0.
+func ctLess64(x, y uint64) uint64 {
+	_, b := bits.Sub64(x, y, 0)
+	return b
+}
+
+// ctSelectPoint sets r = x if mask is 0xFF..FF, leaves r unchanged if mask is 0.
+func (f *params) ctSelectPoint(r, x *point, mask uint64) {
+	ctSelectFpx(&r.x, &x.x, mask, f.words)
+	ctSelectFpx(&r.z, &x.z, mask, f.words)
+}
+
+// expCt computes r = b^e, where e has at most eBitLen bits. Implemented
+// with Montgomery ladder, time depends only on eBitLen.
+func (f *params) expCt(r, b *fpx, e uint64, eBitLen int) {
+	var r0, r1 = f.one, *b
+	for i := eBitLen - 1; i >= 0; i-- {
+		bit := uint8(e>>uint(i)) & 1
+		f.cswap(&r0, &r1, bit)
+		f.mul(&r1, &r0, &r1)
+		f.mul(&r0, &r0, &r0)
+		f.cswap(&r0, &r1, bit)
+	}
+	*r = r0
+}
+
+// xIsoMatryoshka computes an isogeny with kernel point 'kern' of order 'ell'
+// and evaluates it on both points in 'img'. Returns the new curve coefficient
+// in 'co'. It works as xIso, but time of the computation doesn't depend on
+// 'ell', only on 'ellMax' which must be odd and not smaller than 'ell'.
+// Multiples of the kernel point bigger than [(ell-1)/2] are computed, but
+// not used.
+func (f *params) xIsoMatryoshka(img *[2]point, co *coeff, kern *point, ell, ellMax uint64) {
+	var t0, t1, t2 fpx
+	var S, D [2]fpx
+	var Q [2]point
+	var prod, tmp point
+	var coEd coeff
+	var M = [3]point{*kern}
+
+	// See xIso for details
+	f.add(&coEd.c, &co.c, &co.c)
+	f.add(&coEd.a, &co.a, &coEd.c)
+	f.sub(&coEd.c, &co.a, &coEd.c)
+
+	f.sub(&prod.x, &kern.x, &kern.z)
+	f.add(&prod.z, &kern.x, &kern.z)
+
+	for j := range img {
+		f.add(&S[j], &img[j].x, &img[j].z)
+		f.sub(&D[j], &img[j].x, &img[j].z)
+		f.mul(&t1, &prod.x, &S[j])
+		f.mul(&t0, &prod.z, &D[j])
+		f.add(&Q[j].x, &t0, &t1)
+		f.sub(&Q[j].z, &t0, &t1)
+	}
+
+	f.xDbl(&M[1], kern, &point{x: co.a, z: co.c})
+
+	half := ell >> 1
+	for i := uint64(1); i < ellMax>>1; i++ {
+		// Use i-th multiple only if i < (ell-1)/2
+		mask := -ctLess64(i, half)
+		if i >= 2 {
+			f.xAdd(&M[i%3], &M[(i-1)%3], kern, &M[(i-2)%3])
+		}
+		f.sub(&t1, &M[i%3].x, &M[i%3].z)
+		f.add(&t0, &M[i%3].x, &M[i%3].z)
+		f.mul(&tmp.x, &prod.x, &t1)
+		f.mul(&tmp.z, &prod.z, &t0)
+		f.ctSelectPoint(&prod, &tmp, mask)
+
+		for j := range img {
+			var u0, u1 fpx
+			f.mul(&u1, &t1, &S[j])
+			f.mul(&u0, &t0, &D[j])
+			f.add(&t2, &u0, &u1)
+			f.mul(&tmp.x, &Q[j].x, &t2)
+			f.sub(&t2, &u0, &u1)
+			f.mul(&tmp.z, &Q[j].z, &t2)
+			f.ctSelectPoint(&Q[j], &tmp, mask)
+		}
+	}
+
+	for j := range img {
+		f.mul(&Q[j].x, &Q[j].x, &Q[j].x)
+		f.mul(&Q[j].z, &Q[j].z, &Q[j].z)
+		f.mul(&img[j].x, &img[j].x, &Q[j].x)
+		f.mul(&img[j].z, &img[j].z, &Q[j].z)
+	}
+
+	// coEd.a^ell and coEd.c^ell
+	eBitLen := bits.Len64(ellMax)
+	f.expCt(&coEd.a, &coEd.a, ell, eBitLen)
+	f.expCt(&coEd.c, &coEd.c, ell, eBitLen)
+
+	// prod^8
+	for i := 0; i < 3; i++ {
+		f.mul(&prod.x, &prod.x, &prod.x)
+		f.mul(&prod.z, &prod.z, &prod.z)
+	}
+
+	f.mul(&coEd.c, &coEd.c, &prod.x)
+	f.mul(&coEd.a, &coEd.a, &prod.z)
+
+	f.add(&co.a, &coEd.a, &coEd.c)
+	f.sub(&co.c, &coEd.a, &coEd.c)
+	f.add(&co.a, &co.a, &co.a)
+}
+
+// ctidhRandPoints samples points T[0] on the curve and T[1] on its
+// twist. Curve coefficient A must be normalized (A.c = 1).
+func (f *params) ctidhRandPoints(T *[2]point, A *coeff, s *fpRngGen, rng io.Reader) error {
+	var done [2]bool
+	for !done[0] || !done[1] {
+		var x, rhs fpx
+		if err := f.randFp(&x, s, rng); err != nil {
+			return err
+		}
+		f.montEval(&rhs, &A.a, &x)
+		// Sign of random point is public.
+		sign := f.isNonQuadRes(&rhs)
+		if !done[sign] {
+			T[sign] = point{x: x, z: f.one}
+			done[sign] = true
+		}
+	}
+	return nil
+}
+
+// ctidhGroupAction evaluates group action of prv.e on a Montgomery
+// curve represented by coefficient pub.a. Constant-time with respect
+// to prv.e.
+func ctidhGroupAction(pub *PublicKey, prv *CtidhPrivateKey, rng io.Reader) error {
+	var f = params512
+	var budget = ctidhBatchBound
+	var e [primeCount]int8
+	var A = coeff{a: pub.a, c: f.one}
+	var rnd [8]byte
+
+	defer func() {
+		for i := range e {
+			e[i] = 0
+		}
+	}()
+	copy(e[:], prv.exps())
+
+	for {
+		var T [2]point
+		var cof = fpx{4}
+		var active []int
+
+		for j := range ctidhBatches {
+			if budget[j] > 0 {
+				active = append(active, j)
+			} else {
+				for i := ctidhBatches[j].start; i < ctidhBatches[j].end; i++ {
+					mulScalar(&cof, &cof, primes[i])
+				}
+			}
+		}
+		if len(active) == 0 {
+			break
+		}
+
+		if err := f.ctidhRandPoints(&T, &A, &prv.fpRngGen, rng); err != nil {
+			return err
+		}
+		f.xMul(&T[0], &T[0], &A, &cof)
+		f.xMul(&T[1], &T[1], &A, &cof)
+
+		for n, j := range active {
+			var K, R point
+			var sel [8]uint64
+			var found, neg, ell, threshold uint64
+			b := &ctidhBatches[j]
+
+			// Find first prime of the batch with non-zero exponent. If
+			// all exponents are zero, dummy isogeny of degree of the
+			// first prime is computed.
+			for i := b.start; i < b.end; i++ {
+				ei := uint64(uint8(e[i]))
+				nz := -uint64(ctIsNonZero64(ei))
+				sel[i-b.start] = nz &^ found
+				neg |= sel[i-b.start] & (ei >> 7)
+				found |= nz
+			}
+			sel[0] |= ^found
+			for i := b.start; i < b.end; i++ {
+				ell |= sel[i-b.start] & primes[i]
+				threshold |= sel[i-b.start] & b.threshold[i-b.start]
+			}
+
+			// K = [prod of primes in remaining batches and in this
+			// batch, except ell] * T[sign]
+			K = T[0]
+			f.ctSelectPoint(&K, &T[1], -neg)
+			var later = fpx{1}
+			for _, jj := range active[n+1:] {
+				for i := ctidhBatches[jj].start; i < ctidhBatches[jj].end; i++ {
+					mulScalar(&later, &later, primes[i])
+				}
+			}
+			f.xMul(&K, &K, &A, &later)
+			for i := b.start; i < b.end; i++ {
+				f.xMul(&R, &K, &A, &fpx{primes[i]})
+				f.ctSelectPoint(&K, &R, ^sel[i-b.start])
+			}
+
+			// Equalize probability of success and reveal the result.
+			if err := readRand(rng, rnd[:]); err != nil {
+				return err
+			}
+			coin := -ctLess64(binary.LittleEndian.Uint64(rnd[:]), threshold)
+			ok := coin & -uint64(ctIsNonZero64(f.orLimbs(&K.z)))
+
+			if ok != 0 {
+				var img = T
+				var co = A
+				f.xIsoMatryoshka(&img, &co, &K, ell, primes[b.end-1])
+
+				// Use results only for real isogeny
+				ctSelectFpx(&A.a, &co.a, found, f.words)
+				ctSelectFpx(&A.c, &co.c, found, f.words)
+				f.ctSelectPoint(&T[0], &img[0], found)
+				f.ctSelectPoint(&T[1], &img[1], found)
+
+				// Move exponent of the prime towards 0
+				for i := b.start; i < b.end; i++ {
+					d := uint8(1) | uint8(-int8(neg))
+					e[i] -= int8(d & uint8(sel[i-b.start]&found))
+				}
+				budget[j]--
+			}
+
+			// Clear primes of this batch from the order of the points
+			f.xMul(&T[0], &T[0], &A, &b.prod)
+			f.xMul(&T[1], &T[1], &A, &b.prod)
+		}
+
+		// Normalize curve coefficient
+		f.exp(&A.c, &A.c, &f.pMin2)
+		f.mul(&A.a, &A.a, &A.c)
+		A.c = f.one
+	}
+
+	pub.a = A.a
+	return nil
+}
+
+// orLimbs returns OR of all limbs of 'v'.
+func (f *params) orLimbs(v *fpx) uint64 {
+	var r uint64
+	for i := 0; i < f.words; i++ {
+		r |= v[i]
+	}
+	return r
+}
+
+// PrivateKey operations
+
+// UnmarshalBinary imports CTIDH private key. Key is encoded as an array
+// of CtidhPrivateKeySize signed bytes, one exponent per prime. Returns
+// ErrKeySize in case of wrong length of the input, or ErrOutOfRange if
+// exponents are not within bounds of the key space.
+func (c *CtidhPrivateKey) UnmarshalBinary(key []byte) error {
+	if len(key) != CtidhPrivateKeySize {
+		return ErrKeySize
+	}
+	for j, b := range ctidhBatches {
+		var sum int
+		for i := b.start; i < b.end; i++ {
+			v := int(int8(key[i]))
+			if v < 0 {
+				v = -v
+			}
+			sum += v
+		}
+		if sum > ctidhBatchBound[j] {
+			return ErrOutOfRange
+		}
+	}
+	e := c.exps()
+	for i, v := range key {
+		e[i] = int8(v)
+	}
+	return nil
+}
+
+// MarshalBinary exports CTIDH private key. See UnmarshalBinary for
+// details about the encoding.
+func (c *CtidhPrivateKey) MarshalBinary() ([]byte, error) {
+	out := make([]byte, CtidhPrivateKeySize)
+	for i, v := range c.exps() {
+		out[i] = byte(v)
+	}
+	return out, nil
+}
+
+// Import works as UnmarshalBinary, but returns false instead of an error.
+func (c *CtidhPrivateKey) Import(key []byte) bool {
+	return c.UnmarshalBinary(key) == nil
+}
+
+// Export exports CTIDH private key to 'out'. Returns false if 'out' is
+// shorter than CtidhPrivateKeySize.
+func (c *CtidhPrivateKey) Export(out []byte) bool {
+	if len(out) < CtidhPrivateKeySize {
+		return false
+	}
+	for i, v := range c.exps() {
+		out[i] = byte(v)
+	}
+	return true
+}
+
+// Destroy zeroizes the private key.
+func (c *CtidhPrivateKey) Destroy() {
+	if c.secbuf != nil {
+		c.secbuf.Destroy()
+	}
+	c.e, c.secbuf = nil, nil
+	utils.Zeroize(c.wbuf[:])
+}
+
+// GenerateCtidhPrivateKey generates CTIDH private key. For each batch,
+// exponents are sampled uniformly from the set of vectors which sum
+// of absolute values is not bigger than bound of the batch.
+func GenerateCtidhPrivateKey(key *CtidhPrivateKey, rng io.Reader) error {
+	var e = key.exps()
+	for j, b := range ctidhBatches {
+		m := ctidhBatchBound[j]
+		// Rejection sampling. Time depends only on rejected candidates.
+		for {
+			var sum int
+			for i := b.start; i < b.end; i++ {
+				v, err := key.randExp(m, rng)
+				if err != nil {
+					return err
+				}
+				e[i] = int8(v)
+				if v < 0 {
+					v = -v
+				}
+				sum += v
+			}
+			if sum <= m {
+				break
+			}
+		}
+	}
+	utils.Zeroize(key.wbuf[:])
+	return nil
+}
+
+// randExp returns random integer from [-m, m].
+func (s *fpRngGen) randExp(m int, rng io.Reader) (int, error) {
+	n := 2*m + 1
+	lim := 256 - 256%n
+	for {
+		if err := readRand(rng, s.wbuf[:1]); err != nil {
+			return 0, err
+		}
+		if int(s.wbuf[0]) < lim {
+			return int(s.wbuf[0])%n - m, nil
+		}
+	}
+}
+
+// ComputeCtidhPublicKey computes public key corresponding to 'prv'.
+// Public key has the same format as cSIDH-512 public key. Error is
+// returned in case 'rng' fails.
+func ComputeCtidhPublicKey(pub *PublicKey, prv *CtidhPrivateKey, rng io.Reader) error {
+	pub.params = nil
+	pub.a = fpx{}
+	return ctidhGroupAction(pub, prv, rng)
+}
+
+// GenerateCtidhPublicKey works as ComputeCtidhPublicKey, but panics in
+// case 'rng' fails.
+func GenerateCtidhPublicKey(pub *PublicKey, prv *CtidhPrivateKey, rng io.Reader) {
+	if err := ComputeCtidhPublicKey(pub, prv, rng); err != nil {
+		panic(err)
+	}
+}
+
+// ComputeSharedSecretCtidh computes a shared secret with CTIDH private
+// key and stores it in 'out'. Returns ErrParamsMismatch if 'pub' is not
+// a CSIDH-512 key, errors returned by CheckPublicKey if 'pub' is invalid
+// or an error matching ErrRng if 'rng' fails.
+func ComputeSharedSecretCtidh(out *[64]byte, pub *PublicKey, prv *CtidhPrivateKey, rng io.Reader) error {
+	var pk PublicKey
+
+	if pub.paramSet() != params512 {
+		return ErrParamsMismatch
+	}
+	if err := CheckPublicKey(pub, rng); err != nil {
+		return err
+	}
+	pk.a = pub.a
+	if err := ctidhGroupAction(&pk, prv, rng); err != nil {
+		return err
+	}
+	pk.export(out[:])
+	return nil
+}
+
+// DeriveSecretCtidh works as ComputeSharedSecretCtidh, but returns false
+// instead of an error.
+func DeriveSecretCtidh(out *[64]byte, pub *PublicKey, prv *CtidhPrivateKey, rng io.Reader) bool {
+	return ComputeSharedSecretCtidh(out, pub, prv, rng) == nil
+}
@@ -0,0 +1,92 @@
+package csidh
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCtidhKeyExchange(t *testing.T) {
+	var ss1, ss2 [64]byte
+	var prv1, prv2 CtidhPrivateKey
+	var pub1, pub2 PublicKey
+
+	checkErr(t, GenerateCtidhPrivateKey(&prv1, rng), "PrivateKey generation failed")
+	checkErr(t, GenerateCtidhPrivateKey(&prv2, rng), "PrivateKey generation failed")
+	GenerateCtidhPublicKey(&pub1, &prv1, rng)
+	GenerateCtidhPublicKey(&pub2, &prv2, rng)
+
+	Ok(t, Validate(&pub1, rng), "Generated public key is invalid")
+	Ok(t, DeriveSecretCtidh(&ss1, &pub1, &prv2, rng), "Derivation failed")
+	Ok(t, DeriveSecretCtidh(&ss2, &pub2, &prv1, rng), "Derivation failed")
+	if !bytes.Equal(ss1[:], ss2[:]) {
+		t.Error("ss1 != ss2")
+	}
+}
+
+// Checks that for the same exponents, constant-time group action
+// gives the same result as the one used by cSIDH.
+func TestCtidhMatchesCsidh(t *testing.T) {
+	var ctPrv CtidhPrivateKey
+	var prv PrivateKey
+	var ctPub, pub PublicKey
+
+	checkErr(t, GenerateCtidhPrivateKey(&ctPrv, rng), "PrivateKey generation failed")
+	e := ctPrv.exps()
+	for i := range e {
+		// cSIDH exponents are in [-5,5]
+		if e[i] > expMax {
+			e[i] = expMax
+		} else if e[i] < -expMax {
+			e[i] = -expMax
+		}
+		prv.exps()[i] = e[i]
+	}
+
+	GenerateCtidhPublicKey(&ctPub, &ctPrv, rng)
+	GeneratePublicKey(&pub, &prv, rng)
+	if ctPub.a != pub.a {
+		t.Error("Public keys differ")
+	}
+}
+
+func TestCtidhPrivateKey(t *testing.T) {
+	var buf [CtidhPrivateKeySize]byte
+	for i := 0; i < numIter; i++ {
+		var prv1, prv2 CtidhPrivateKey
+		checkErr(t, GenerateCtidhPrivateKey(&prv1, rng), "PrivateKey generation failed")
+		Ok(t, prv1.Export(buf[:]), "Export failed")
+		// Import checks bounds
+		Ok(t, prv2.Import(buf[:]), "Import failed")
+		for j := range prv1.e {
+			if prv1.e[j] != prv2.e[j] {
+				t.Fatal("Error occurred when private key export/import")
+			}
+		}
+		e := prv1.e
+		prv1.Destroy()
+		if prv1.e != nil || prv1.secbuf != nil || prv1.fpRngGen != (fpRngGen{}) {
+			t.Error("Private key not released")
+		}
+		for _, v := range e {
+			if v != 0 {
+				t.Fatal("Private key not zeroized")
+			}
+		}
+	}
+
+	var prv CtidhPrivateKey
+	// Out of bounds of the first batch
+	buf = [CtidhPrivateKeySize]byte{}
+	buf[0], buf[1] = 2, 0xFE
+	Ok(t, !prv.Import(buf[:]), "Import accepted key out of bounds")
+	Ok(t, !prv.Import(buf[:CtidhPrivateKeySize-1]), "Import accepted key of wrong size")
+}
+
+func BenchmarkCtidhGeneratePublic(b *testing.B) {
+	var prv CtidhPrivateKey
+	var pub PublicKey
+	GenerateCtidhPrivateKey(&prv, rng)
+	for n := 0; n < b.N; n++ {
+		GenerateCtidhPublicKey(&pub, &prv, rng)
+	}
+}
@@ -4,7 +4,19 @@
 // by using twisted Edwards curves in the isogeny image curve
 // computations. This work has been described by M. Meyer and S. Reith
 // in the ia.cr/2018/782. Original cSIDH paper can be found in the
-// ia.cr/2018/383.
+// ia.cr/2018/383. Isogenies of large degree are computed with √élu
+// algorithm (ia.cr/2020/341). Public keys are validated with the
+// supersingularity test by J. Doliskani (arXiv:1801.02664), validated keys
+// can be kept in ValidationCache. Nike derives symmetric keys from shared
+// secrets, bound to public keys of both parties and a context string.
+//
+// CSIDH-512 is the default parameter set. Larger parameter sets, CSIDH-1024
+// and CSIDH-1792, use generic field arithmetic and are selected by creating
+// keys with NewPrivateKey and NewPublicKey.
+//
+// Group action used by cSIDH is not constant time. Package also implements
+// a constant-time variant based on CTIDH (ia.cr/2021/633), which uses the
+// same prime and public keys but its own private keys (CtidhPrivateKey).
 //
 // It is experimental implementation, not meant to be secure. Have fun!
 //
@@ -0,0 +1,124 @@
+package csidh
+
+// Supersingularity test by J. Doliskani (arXiv:1801.02664).
+//
+// Curve E: y^2 = x^3 + Ax^2 + x over GF(p) with p = 3 mod 4 is
+// supersingular iff Frobenius over GF(p^2) equals -p. In such case
+// E(GF(p^2)) = E[p+1] and its quadratic twist over GF(p^2) is E[p-1].
+// Any x from GF(p^2) is x-coordinate of a point on E or on the twist,
+// hence single x-only multiplication by p-1 is enough to check whether
+// [p-1]P or [p+1]P is the point at infinity. For an ordinary curve it
+// happens only with negligible probability. Computation is done in
+// GF(p^2) = GF(p)[i]/(i^2+1), which costs roughly three multiplications
+// by p+1 in GF(p). That is much cheaper than cofactor multiplication
+// from ia.cr/2018/383 (algo. 3), which needs a point of large order.
+//
+// Implementation is not constant time, but it operates on public data only.
+
+// Element a + b*i of GF(p^2)
+type fp2 struct {
+	a fpx
+	b fpx
+}
+
+// Projective point over GF(p^2)
+type point2 struct {
+	x fp2
+	z fp2
+}
+
+func (f *params) fp2Add(r, x, y *fp2) {
+	f.add(&r.a, &x.a, &y.a)
+	f.add(&r.b, &x.b, &y.b)
+}
+
+func (f *params) fp2Sub(r, x, y *fp2) {
+	f.sub(&r.a, &x.a, &y.a)
+	f.sub(&r.b, &x.b, &y.b)
+}
+
+// fp2Mul sets r = x*y with Karatsuba's formula.
+func (f *params) fp2Mul(r, x, y *fp2) {
+	var t0, t1, t2, t3 fpx
+	f.mul(&t0, &x.a, &y.a)
+	f.mul(&t1, &x.b, &y.b)
+	f.add(&t2, &x.a, &x.b)
+	f.add(&t3, &y.a, &y.b)
+	f.mul(&t2, &t2, &t3)
+	f.sub(&t2, &t2, &t0)
+	f.sub(&r.b, &t2, &t1)
+	f.sub(&r.a, &t0, &t1)
+}
+
+// fp2Sqr sets r = x^2.
+func (f *params) fp2Sqr(r, x *fp2) {
+	var t0, t1, t2 fpx
+	f.add(&t0, &x.a, &x.b)
+	f.sub(&t1, &x.a, &x.b)
+	f.add(&t2, &x.a, &x.a)
+	f.mul(&r.b, &t2, &x.b)
+	f.mul(&r.a, &t0, &t1)
+}
+
+// fp2MulFp sets r = x*y, where y is from GF(p).
+func (f *params) fp2MulFp(r, x *fp2, y *fpx) {
+	f.mul(&r.a, &x.a, y)
+	f.mul(&r.b, &x.b, y)
+}
+
+// xDblAddFp2 sets P = [2]P and Q = P+Q. x is affine x-coordinate of
+// P-Q and a2 = A+2. Works also for P and Q being the point at infinity.
+func (f *params) xDblAddFp2(P, Q *point2, x *fp2, a2 *fpx) {
+	var t0, t1, t2, t3 fp2
+
+	f.fp2Add(&t0, &P.x, &P.z)
+	f.fp2Sub(&t1, &P.x, &P.z)
+	f.fp2Add(&t2, &Q.x, &Q.z)
+	f.fp2Sub(&t3, &Q.x, &Q.z)
+	f.fp2Mul(&t3, &t3, &t0)
+	f.fp2Mul(&t2, &t2, &t1)
+	f.fp2Add(&Q.x, &t3, &t2)
+	f.fp2Sub(&Q.z, &t3, &t2)
+	f.fp2Sqr(&Q.x, &Q.x)
+	f.fp2Sqr(&Q.z, &Q.z)
+	f.fp2Mul(&Q.z, &Q.z, x)
+
+	f.fp2Sqr(&t0, &t0)
+	f.fp2Sqr(&t1, &t1)
+	f.fp2Sub(&t2, &t0, &t1)
+	// t1 = 4*(X-Z)^2
+	f.fp2Add(&t1, &t1, &t1)
+	f.fp2Add(&t1, &t1, &t1)
+	f.fp2Mul(&P.x, &t0, &t1)
+	f.fp2MulFp(&t3, &t2, a2)
+	f.fp2Add(&t3, &t3, &t1)
+	f.fp2Mul(&P.z, &t3, &t2)
+}
+
+// isSupersingular returns true if curve with coefficient 'a' is
+// supersingular. 'x' must be a random element of GF(p^2).
+func (f *params) isSupersingular(a *fpx, x *fp2) bool {
+	var a2 fpx
+	var P = point2{x: fp2{a: f.one}}
+	var Q = point2{x: *x, z: fp2{a: f.one}}
+	var n = f.p
+
+	// p is odd, n = p-1
+	n[0]--
+	f.add(&a2, a, &f.two)
+
+	// Ladder keeps Q = P + x
+	for i := f.pbits - 1; i >= 0; i-- {
+		if (n[i/64]>>uint(i%64))&1 != 0 {
+			f.xDblAddFp2(&Q, &P, x, &a2)
+		} else {
+			f.xDblAddFp2(&P, &Q, x, &a2)
+		}
+	}
+
+	// P = [p-1]x and Q = [p]x. Check if [p-1]x = O or [p]x = -x.
+	var t fp2
+	f.fp2Mul(&t, &Q.z, x)
+	f.fp2Sub(&t, &t, &Q.x)
+	return (f.isZero(&P.z.a) && f.isZero(&P.z.b)) || (f.isZero(&t.a) && f.isZero(&t.b))
+}
@@ -0,0 +1,58 @@
+package csidh
+
+import (
+	"errors"
+	"io"
+)
+
+// Errors returned by the package
+var (
+	// ErrKeySize is returned when encoded key has wrong length.
+	ErrKeySize = errors.New("csidh: invalid key length")
+	// ErrKeyFormat is returned when version of key encoding or parameter
+	// set is not supported.
+	ErrKeyFormat = errors.New("csidh: unsupported key encoding")
+	// ErrOutOfRange is returned when curve coefficient of a public key
+	// is not smaller than p or exponent of a private key is out of range.
+	ErrOutOfRange = errors.New("csidh: key coefficient out of range")
+	// ErrRng is returned when random number generator fails. Errors
+	// returned by the package in such case wrap the original error and
+	// match ErrRng with errors.Is.
+	ErrRng = errors.New("csidh: can't read random number")
+	// ErrInvalidPublicKey is returned when public key fails validation.
+	ErrInvalidPublicKey = errors.New("csidh: invalid public key")
+	// ErrParamsMismatch is returned when keys use different parameter sets.
+	ErrParamsMismatch = errors.New("csidh: keys use different parameter sets")
+	// ErrSharedSecretSize is returned when output buffer has wrong size.
+	ErrSharedSecretSize = errors.New("csidh: wrong size of shared secret buffer")
+	// ErrUnsupportedKDF is returned when Nike uses unknown KDF.
+	ErrUnsupportedKDF = errors.New("csidh: unsupported KDF")
+	// ErrBatchSize is returned by batch operations when number of
+	// outputs differs from number of keys.
+	ErrBatchSize = errors.New("csidh: number of outputs differs from number of keys")
+)
+
+// rngError wraps an error returned by random number generator.
+type rngError struct {
+	err error
+}
+
+func (e *rngError) Error() string {
+	return ErrRng.Error() + ": " + e.err.Error()
+}
+
+func (e *rngError) Is(target error) bool {
+	return target == ErrRng
+}
+
+func (e *rngError) Unwrap() error {
+	return e.err
+}
+
+// readRand fills 'buf' with random bytes from 'rng'.
+func readRand(rng io.Reader, buf []byte) error {
+	if _, err := io.ReadFull(rng, buf); err != nil {
+		return &rngError{err}
+	}
+	return nil
+}
@@ -3,7 +3,7 @@ package csidh
 import (
 	"math/bits"
 
-	"golang.org/x/sys/cpu"
+	"github.com/henrydcase/nobs/utils"
 )
 
 // CPU Capabilities. Those flags are referred by assembly code. According to
@@ -12,11 +12,15 @@ import (
 // We declare variables not constants, in order to facilitate testing.
 var (
 	// Signals support for BMI2 (MULX)
-	hasBMI2 = cpu.X86.HasBMI2 //nolint
+	hasBMI2 = utils.X86.HasBMI2 //nolint
 	// Signals support for ADX and BMI2
-	hasADXandBMI2 = cpu.X86.HasBMI2 && cpu.X86.HasADX
+	hasADXandBMI2 = utils.X86.HasBMI2 && utils.X86.HasADX
 )
 
+// 511-bit number representing prime field element GF(p). Used by
+// dedicated implementation of CSIDH-512 field arithmetic.
+type fp [numWords]uint64
+
 // Constant time select.
 // if pick == 0xFF..FF (out = in1)
 // if pick == 0 (out = in2)
@@ -241,12 +245,6 @@ func modExpRdc512(r, b, e *fp) {
 	modExpRdcCommon(r, b, e, 512)
 }
 
-// modExpRdc does modular exponentation of 64-bit number.
-// Constant-time.
-func modExpRdc64(r, b *fp, e uint64) {
-	modExpRdcCommon(r, b, &fp{e}, 64)
-}
-
 // isNonQuadRes checks whether value v is quadratic residue.
 // Implementation uses Fermat's little theorem (or
 // Euler's criterion)
@@ -5,12 +5,12 @@ import (
 	"math/rand"
 	"testing"
 
-	"golang.org/x/sys/cpu"
+	"github.com/henrydcase/nobs/utils"
 )
 
 func resetCPUFeatures() {
-	hasBMI2 = cpu.X86.HasBMI2
-	hasADXandBMI2 = cpu.X86.HasBMI2 && cpu.X86.HasADX
+	hasBMI2 = utils.X86.HasBMI2
+	hasADXandBMI2 = utils.X86.HasBMI2 && utils.X86.HasADX
 }
 
 func testFp512Mul3Nominal(t *testing.T) {
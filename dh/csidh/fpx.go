@@ -0,0 +1,224 @@
+package csidh
+
+import (
+	"math/bits"
+	"unsafe"
+)
+
+// Arithmetic in GF(p), used by all parameter sets. Elements are stored
+// in Montgomery domain with R=2^(64*words). CSIDH-512 uses dedicated
+// implementation from fp511.go, other parameter sets use generic code.
+// Unless stated otherwise, all functions are constant time.
+
+// as512 returns first numWords limbs of 'v' as CSIDH-512 field element.
+func as512(v *fpx) *fp {
+	return (*fp)(unsafe.Pointer(v))
+}
+
+// r = x + y mod p.
+func (f *params) add(r, x, y *fpx) {
+	if f.dedicated {
+		addRdc(as512(r), as512(x), as512(y))
+		return
+	}
+	var c, b uint64
+	var t fpx
+	for i := 0; i < f.words; i++ {
+		r[i], c = bits.Add64(x[i], y[i], c)
+	}
+	for i := 0; i < f.words; i++ {
+		t[i], b = bits.Sub64(r[i], f.p[i], b)
+	}
+	// p < R/2, so no carry out of the addition.
+	w := 0 - b
+	for i := 0; i < f.words; i++ {
+		r[i] = ctPick64(w, r[i], t[i])
+	}
+}
+
+// r = x - y mod p.
+func (f *params) sub(r, x, y *fpx) {
+	var b, c uint64
+	if f.dedicated {
+		subRdc(as512(r), as512(x), as512(y))
+		return
+	}
+	for i := 0; i < f.words; i++ {
+		r[i], b = bits.Sub64(x[i], y[i], b)
+	}
+	w := 0 - b
+	for i := 0; i < f.words; i++ {
+		r[i], c = bits.Add64(r[i], ctPick64(w, f.p[i], 0), c)
+	}
+}
+
+// mul performs Montgomery multiplication r = x * y * R^-1 mod p, with
+// coarsely integrated operand scanning. Result is fully reduced.
+func (f *params) mul(r, x, y *fpx) {
+	if f.dedicated {
+		mulRdc(as512(r), as512(x), as512(y))
+		return
+	}
+	var t [maxWords + 2]uint64
+	var s fpx
+	var c, cc, hi, lo, m uint64
+	n := f.words
+
+	for i := 0; i < n; i++ {
+		// t = t + x * y[i]
+		c = 0
+		for j := 0; j < n; j++ {
+			hi, lo = bits.Mul64(x[j], y[i])
+			lo, cc = bits.Add64(lo, t[j], 0)
+			hi += cc
+			t[j], cc = bits.Add64(lo, c, 0)
+			c = hi + cc
+		}
+		t[n], cc = bits.Add64(t[n], c, 0)
+		t[n+1] = cc
+
+		// t = (t + m * p) / 2^64
+		m = t[0] * f.pNegInv
+		hi, lo = bits.Mul64(m, f.p[0])
+		_, cc = bits.Add64(lo, t[0], 0)
+		c = hi + cc
+		for j := 1; j < n; j++ {
+			hi, lo = bits.Mul64(m, f.p[j])
+			lo, cc = bits.Add64(lo, t[j], 0)
+			hi += cc
+			t[j-1], cc = bits.Add64(lo, c, 0)
+			c = hi + cc
+		}
+		t[n-1], cc = bits.Add64(t[n], c, 0)
+		t[n] = t[n+1] + cc
+	}
+
+	// if p <= t < 2p then t = t-p
+	var b uint64
+	for j := 0; j < n; j++ {
+		s[j], b = bits.Sub64(t[j], f.p[j], b)
+	}
+	_, b = bits.Sub64(t[n], 0, b)
+	w := 0 - b
+	for j := 0; j < n; j++ {
+		r[j] = ctPick64(w, t[j], s[j])
+	}
+}
+
+// exp computes r = b^e mod p, with fixed 4-bit window. 'e' is
+// not in Montgomery domain. Time depends on the bit length of 'e',
+// which hence must be public.
+func (f *params) exp(r, b, e *fpx) {
+	var precomp [16]fpx
+	var t fpx
+	var n int
+
+	// Skip leading zero nibbles
+	for n = f.words*16 - 1; n > 0; n-- {
+		if (e[n/16]>>uint((n%16)*4))&15 != 0 {
+			break
+		}
+	}
+	if f.dedicated {
+		modExpRdcCommon(as512(r), as512(b), as512(e), 4*(n+1))
+		return
+	}
+
+	precomp[0] = f.one
+	precomp[1] = *b
+	for i := 2; i < 16; i += 2 {
+		f.mul(&precomp[i], &precomp[i/2], &precomp[i/2])
+		f.mul(&precomp[i+1], &precomp[i], b)
+	}
+
+	*r = f.one
+	for i := n; i >= 0; i-- {
+		for j := 0; j < 4; j++ {
+			f.mul(r, r, r)
+		}
+		idx := (e[i/16] >> uint((i%16)*4)) & 15
+		// constant time table lookup
+		for j := range precomp {
+			ctSelectFpx(&t, &precomp[j], -ctEq64(uint64(j), idx), f.words)
+		}
+		f.mul(r, r, &t)
+	}
+}
+
+// isNonQuadRes returns 0 in case v is quadratic residue
+// or 1 in case v is quadratic non-residue.
+func (f *params) isNonQuadRes(v *fpx) int {
+	var res fpx
+	var b uint64
+
+	f.exp(&res, v, &f.pMin1By2)
+	for i := 0; i < f.words; i++ {
+		b |= res[i] ^ f.one[i]
+	}
+	return ctIsNonZero64(b)
+}
+
+// isZero returns true if v is equal to 0.
+func (f *params) isZero(v *fpx) bool {
+	var r uint64
+	for i := 0; i < f.words; i++ {
+		r |= v[i]
+	}
+	return ctIsNonZero64(r) == 0
+}
+
+// equal returns true if x is equal to y.
+func (f *params) equal(x, y *fpx) bool {
+	var r uint64
+	for i := 0; i < f.words; i++ {
+		r |= x[i] ^ y[i]
+	}
+	return ctIsNonZero64(r) == 0
+}
+
+// isLess returns result of x<y operation. Not constant time.
+func (f *params) isLess(x, y *fpx) bool {
+	for i := f.words - 1; i >= 0; i-- {
+		if x[i] != y[i] {
+			return x[i] < y[i]
+		}
+	}
+	return false
+}
+
+// mulScalar computes r = x * y, where x is integer (not
+// in Montgomery domain). Result must fit in fpx.
+func mulScalar(r, x *fpx, y uint64) {
+	var c, cc, hi, lo uint64
+	for i := range x {
+		hi, lo = bits.Mul64(x[i], y)
+		r[i], cc = bits.Add64(lo, c, 0)
+		c = hi + cc
+	}
+}
+
+// cswap swaps x with y in constant time if choice is 1.
+func (f *params) cswap(x, y *fpx, choice uint8) {
+	if f.dedicated {
+		cswap512(as512(x), as512(y), choice)
+		return
+	}
+	mask := 0 - uint64(choice)
+	for i := 0; i < f.words; i++ {
+		t := mask & (x[i] ^ y[i])
+		x[i] ^= t
+		y[i] ^= t
+	}
+}
+
+// ctEq64 returns 1 if x == y, otherwise 0.
+func ctEq64(x, y uint64) uint64 {
+	return 1 ^ uint64(ctIsNonZero64(x^y))
+}
+
+// ctSelectFpx sets r = x if mask is 0xFF..FF, leaves r unchanged if mask is 0.
+func ctSelectFpx(r, x *fpx, mask uint64, words int) {
+	for i := 0; i < words; i++ {
+		r[i] = ctPick64(mask, x[i], r[i])
+	}
+}
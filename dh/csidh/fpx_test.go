@@ -0,0 +1,217 @@
+package csidh
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+// Parameter set CSIDH-512 computed by newParams. It uses generic
+// arithmetic, hence it is used to test the dedicated one.
+var params512Generic = newParams(Csidh512, primes[:], expMax)
+
+func fpxToBig(v *fpx, words int) *big.Int {
+	r := new(big.Int)
+	for i := words - 1; i >= 0; i-- {
+		r.Lsh(r, 64)
+		r.Or(r, new(big.Int).SetUint64(v[i]))
+	}
+	return r
+}
+
+func TestParamsMatchCsidh512(t *testing.T) {
+	f, g := params512Generic, params512
+	check := func(name string, x, y *fpx) {
+		t.Helper()
+		if *x != *y {
+			t.Errorf("%s differs", name)
+		}
+	}
+
+	if f.words != g.words || f.pbits != g.pbits || f.publicKeySize != g.publicKeySize {
+		t.Error("wrong size")
+	}
+	check("p", &f.p, &g.p)
+	check("one", &f.one, &g.one)
+	check("two", &f.two, &g.two)
+	check("twoNeg", &f.twoNeg, &g.twoNeg)
+	check("four", &f.four, &g.four)
+	check("pMin1By2", &f.pMin1By2, &g.pMin1By2)
+	check("pMin2", &f.pMin2, &g.pMin2)
+	if f.pNegInv != g.pNegInv {
+		t.Error("pNegInv differs")
+	}
+}
+
+func TestFpxArith(t *testing.T) {
+	for _, f := range []*params{params512, params512Generic, paramsFor(Csidh1024), paramsFor(Csidh1792)} {
+		var x, y, r fpx
+		p := fpxToBig(&f.p, f.words)
+		Rinv := new(big.Int).Lsh(big.NewInt(1), uint(64*f.words))
+		Rinv.ModInverse(Rinv, p)
+
+		for i := 0; i < numIter; i++ {
+			var s fpRngGen
+			f.randFp(&x, &s, rng)
+			f.randFp(&y, &s, rng)
+			bx, by := fpxToBig(&x, f.words), fpxToBig(&y, f.words)
+
+			f.mul(&r, &x, &y)
+			exp := new(big.Int).Mul(bx, by)
+			exp.Mul(exp, Rinv).Mod(exp, p)
+			if fpxToBig(&r, f.words).Cmp(exp) != 0 {
+				t.Errorf("[%d] mul failed", f.id)
+			}
+
+			f.add(&r, &x, &y)
+			exp.Add(bx, by).Mod(exp, p)
+			if fpxToBig(&r, f.words).Cmp(exp) != 0 {
+				t.Errorf("[%d] add failed", f.id)
+			}
+
+			f.sub(&r, &x, &y)
+			exp.Sub(bx, by).Mod(exp, p)
+			if fpxToBig(&r, f.words).Cmp(exp) != 0 {
+				t.Errorf("[%d] sub failed", f.id)
+			}
+		}
+
+	}
+}
+
+// Checks that group action with generic arithmetic gives the same result
+// as the one with dedicated CSIDH-512 arithmetic.
+func TestGroupActionGeneric(t *testing.T) {
+	var prv PrivateKey
+	var pub PublicKey
+	var a fpx
+
+	checkErr(t, GeneratePrivateKey(&prv, rng), "PrivateKey generation failed")
+	GeneratePublicKey(&pub, &prv, rng)
+
+	e := prv.Exponents()
+	for _, sampling := range []PointSampling{SampleElligator, SampleRandom} {
+		a = fpx{}
+		checkErr(t, params512Generic.groupAction(&a, e, sampling, params512Generic.simba, &prv.fpRngGen, rng),
+			"Group action failed")
+		if a != pub.a {
+			t.Fatalf("Public keys differ (sampling %d)", sampling)
+		}
+	}
+}
+
+func TestXIsoSqrtGeneric(t *testing.T) {
+	var f = paramsFor(Csidh1024)
+	var prv PrivateKey
+	var A = coeff{c: f.one}
+	var a, b fpx
+
+	for _, l := range f.primes[len(f.primes)-8:] {
+		var kern = kernelPoint(f, l)
+		var co1, co2 = A, A
+		var P1 = point{z: f.one}
+		f.randFp(&P1.x, &prv.fpRngGen, rng)
+		var P2 = P1
+
+		f.xIso(&co1, &kern, l, &P1)
+		f.xIsoSqrt(&co2, &kern, l, &P2)
+		f.mul(&a, &co1.a, &co2.c)
+		f.mul(&b, &co2.a, &co1.c)
+		if !f.equal(&a, &b) {
+			t.Errorf("image curves differ for l=%d", l)
+		}
+		f.mul(&a, &P1.x, &P2.z)
+		f.mul(&b, &P2.x, &P1.z)
+		if !f.equal(&a, &b) {
+			t.Errorf("image points differ for l=%d", l)
+		}
+	}
+}
+
+func testKeyExchangeParams(t *testing.T, id uint8, sparse bool) {
+	var ss1, ss2 []byte
+	var buf []byte
+
+	prv1, prv2 := NewPrivateKey(id), NewPrivateKey(id)
+	checkErr(t, GeneratePrivateKey(prv1, rng), "PrivateKey generation failed")
+	checkErr(t, GeneratePrivateKey(prv2, rng), "PrivateKey generation failed")
+	if sparse {
+		// Keep computation time reasonable
+		for i := 4; i < len(prv1.exps()); i++ {
+			prv1.e[i], prv2.e[i] = 0, 0
+		}
+	}
+
+	var pub1, pub2 PublicKey
+	GeneratePublicKey(&pub1, prv1, rng)
+	GeneratePublicKey(&pub2, prv2, rng)
+	if pub1.ID() != id || pub1.Size() != prv1.paramSet().publicKeySize {
+		t.Error("Wrong parameter set of public key")
+	}
+
+	// Export/Import
+	buf = make([]byte, pub1.Size())
+	Ok(t, pub1.Export(buf), "Export failed")
+	pub3 := NewPublicKey(id)
+	Ok(t, pub3.Import(buf), "Import failed")
+	Ok(t, pub3.a == pub1.a, "Public key export/import")
+
+	buf = make([]byte, prv1.Size())
+	Ok(t, prv1.Export(buf), "Export failed")
+	prv3 := NewPrivateKey(id)
+	Ok(t, prv3.Import(buf), "Import failed")
+	Ok(t, bytes.Equal(prv3.secbuf.Bytes(), prv1.secbuf.Bytes()), "Private key export/import")
+	buf[0] = byte(prv1.paramSet().expMax + 1)
+	Ok(t, !prv3.Import(buf), "Import accepted exponent out of range")
+
+	ss1 = make([]byte, pub1.SharedSecretSize())
+	ss2 = make([]byte, pub1.SharedSecretSize())
+	Ok(t, DeriveSharedSecret(ss1, &pub1, prv2, rng), "Derivation failed")
+	Ok(t, DeriveSharedSecret(ss2, &pub2, prv1, rng), "Derivation failed")
+	if !bytes.Equal(ss1, ss2) {
+		t.Error("ss1 != ss2")
+	}
+
+	// Mixing parameter sets
+	var prv512 PrivateKey
+	Ok(t, !DeriveSharedSecret(ss1, &pub1, &prv512, rng), "Derivation with mixed parameters succeeded")
+
+	// Invalid key
+	pub3.a = pub3.paramSet().two
+	Ok(t, !Validate(pub3, rng), "Invalid key passed validation")
+}
+
+func TestKeyExchangeParams(t *testing.T) {
+	t.Run("CSIDH-1024", func(t *testing.T) { testKeyExchangeParams(t, Csidh1024, false) })
+	t.Run("CSIDH-1792", func(t *testing.T) { testKeyExchangeParams(t, Csidh1792, true) })
+}
+
+func TestDeriveSharedSecret512(t *testing.T) {
+	var prv1, prv2 PrivateKey
+	var pub1, pub2 PublicKey
+	var ss1 [SharedSecretSize]byte
+	var ss2 = make([]byte, SharedSecretSize)
+
+	checkErr(t, GeneratePrivateKey(&prv1, rng), "PrivateKey generation failed")
+	checkErr(t, GeneratePrivateKey(&prv2, rng), "PrivateKey generation failed")
+	GeneratePublicKey(&pub1, &prv1, rng)
+	GeneratePublicKey(&pub2, &prv2, rng)
+	Ok(t, DeriveSecret(&ss1, &pub1, &prv2, rng), "Derivation failed")
+	Ok(t, DeriveSharedSecret(ss2, &pub2, &prv1, rng), "Derivation failed")
+	if !bytes.Equal(ss1[:], ss2) {
+		t.Error("ss1 != ss2")
+	}
+}
+
+func BenchmarkGeneratePublicParams(b *testing.B) {
+	for _, id := range []uint8{Csidh1024, Csidh1792} {
+		prv := NewPrivateKey(id)
+		GeneratePrivateKey(prv, rng)
+		b.Run(map[uint8]string{Csidh1024: "CSIDH-1024", Csidh1792: "CSIDH-1792"}[id], func(b *testing.B) {
+			var pub PublicKey
+			for n := 0; n < b.N; n++ {
+				GeneratePublicKey(&pub, prv, rng)
+			}
+		})
+	}
+}
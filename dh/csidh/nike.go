@@ -0,0 +1,103 @@
+package csidh
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/henrydcase/nobs/hash/sha3"
+	"github.com/henrydcase/nobs/utils"
+)
+
+// Non-interactive key exchange (NIKE) based on cSIDH. Shared secret
+// returned by ComputeSharedSecret is a curve coefficient, which must not
+// be used as a key directly. Nike hashes it together with both public
+// keys and a context string, so that derived key is bound to the whole
+// transcript. Public keys are hashed in canonical order (lexicographic
+// order of their encodings), hence both parties derive the same key,
+// no matter which of them is the initiator. The same applies to keys
+// derived from two static keys and from a static and an ephemeral key.
+
+// NikeKDF selects function used to derive keys from shared secrets.
+type NikeKDF uint8
+
+const (
+	// NikeShake256 derives keys with SHAKE256. Default.
+	NikeShake256 NikeKDF = iota
+	// NikeKMAC256 derives keys with KMAC256, keyed with shared secret.
+	NikeKMAC256
+)
+
+// Domain separation string of the NIKE
+var nikeLabel = []byte("CSIDH-NIKE-v1")
+
+// Nike derives symmetric keys from cSIDH key pairs. Zero value uses
+// SHAKE256 and empty context.
+type Nike struct {
+	// Function used to derive keys
+	KDF NikeKDF
+	// Context string, bound to all derived keys. It should identify
+	// the protocol which uses the NIKE.
+	Context []byte
+}
+
+// DeriveKey computes a key shared by owners of key pairs (prv, ownPub)
+// and a peer with public key 'peerPub'. Length of the key is equal to
+// len(out), which must be positive. 'ownPub' must be the public key
+// corresponding to 'prv'. Returns errors returned by ComputeSharedSecret
+// if 'peerPub' is invalid, keys use different parameter sets or 'rng'
+// fails, or ErrUnsupportedKDF if n.KDF is not supported.
+func (n *Nike) DeriveKey(out []byte, ownPub, peerPub *PublicKey, prv *PrivateKey, rng io.Reader) error {
+	if n.KDF != NikeShake256 && n.KDF != NikeKMAC256 {
+		return ErrUnsupportedKDF
+	}
+	if len(out) == 0 {
+		return ErrSharedSecretSize
+	}
+	if ownPub.paramSet() != peerPub.paramSet() {
+		return ErrParamsMismatch
+	}
+
+	ss := make([]byte, peerPub.SharedSecretSize())
+	defer utils.Zeroize(ss)
+	if err := ComputeSharedSecret(ss, peerPub, prv, rng); err != nil {
+		return err
+	}
+
+	pk1 := make([]byte, ownPub.Size())
+	pk2 := make([]byte, peerPub.Size())
+	ownPub.export(pk1)
+	peerPub.export(pk2)
+	if bytes.Compare(pk1, pk2) > 0 {
+		pk1, pk2 = pk2, pk1
+	}
+
+	// Transcript: id || len(out) || len(Context) || Context || pk1 || pk2,
+	// where lengths are encoded as 64-bit little-endian integers. Length
+	// of the output is bound, so that shorter keys aren't prefixes of
+	// longer ones.
+	var hdr [17]byte
+	hdr[0] = ownPub.ID()
+	binary.LittleEndian.PutUint64(hdr[1:], uint64(len(out)))
+	binary.LittleEndian.PutUint64(hdr[9:], uint64(len(n.Context)))
+
+	switch n.KDF {
+	case NikeKMAC256:
+		h := sha3.NewKMAC256(ss, len(out), nikeLabel)
+		h.Write(hdr[:])
+		h.Write(n.Context)
+		h.Write(pk1)
+		h.Write(pk2)
+		copy(out, h.Sum(nil))
+	default:
+		h := sha3.NewShake256()
+		h.Write(nikeLabel)
+		h.Write(hdr[:])
+		h.Write(n.Context)
+		h.Write(pk1)
+		h.Write(pk2)
+		h.Write(ss)
+		h.Read(out)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package csidh
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNike(t *testing.T) {
+	var prvA, prvB, prvE PrivateKey
+	var pubA, pubB, pubE PublicKey
+
+	for _, k := range []struct {
+		prv *PrivateKey
+		pub *PublicKey
+	}{{&prvA, &pubA}, {&prvB, &pubB}, {&prvE, &pubE}} {
+		checkErr(t, GeneratePrivateKey(k.prv, rng), "PrivateKey generation failed")
+		checkErr(t, ComputePublicKey(k.pub, k.prv, rng), "PublicKey generation failed")
+	}
+
+	for _, kdf := range []NikeKDF{NikeShake256, NikeKMAC256} {
+		var k1, k2, k3, k4 [32]byte
+		n := Nike{KDF: kdf, Context: []byte("test")}
+
+		// Static-static
+		checkErr(t, n.DeriveKey(k1[:], &pubA, &pubB, &prvA, rng), "Derivation failed")
+		checkErr(t, n.DeriveKey(k2[:], &pubB, &pubA, &prvB, rng), "Derivation failed")
+		Ok(t, k1 == k2, "Static-static keys differ")
+
+		// Static-ephemeral
+		checkErr(t, n.DeriveKey(k3[:], &pubE, &pubA, &prvE, rng), "Derivation failed")
+		checkErr(t, n.DeriveKey(k4[:], &pubA, &pubE, &prvA, rng), "Derivation failed")
+		Ok(t, k3 == k4, "Static-ephemeral keys differ")
+		Ok(t, k1 != k3, "Keys for different peers are equal")
+
+		// Context is bound to the key
+		n.Context = []byte("test2")
+		checkErr(t, n.DeriveKey(k2[:], &pubB, &pubA, &prvB, rng), "Derivation failed")
+		Ok(t, k1 != k2, "Context not bound to the key")
+
+		// Length of the output
+		var long [100]byte
+		checkErr(t, n.DeriveKey(long[:], &pubB, &pubA, &prvB, rng), "Derivation failed")
+		Ok(t, !bytes.Equal(long[:32], make([]byte, 32)), "Key not derived")
+		checkErr(t, n.DeriveKey(k2[:], &pubB, &pubA, &prvB, rng), "Derivation failed")
+		Ok(t, !bytes.Equal(long[:32], k2[:]), "Shorter key is a prefix of longer one")
+		Ok(t, n.DeriveKey(nil, &pubB, &pubA, &prvB, rng) == ErrSharedSecretSize,
+			"Expected ErrSharedSecretSize")
+	}
+
+	// KDFs give different keys
+	var k1, k2 [32]byte
+	checkErr(t, (&Nike{KDF: NikeShake256}).DeriveKey(k1[:], &pubA, &pubB, &prvA, rng), "Derivation failed")
+	checkErr(t, (&Nike{KDF: NikeKMAC256}).DeriveKey(k2[:], &pubA, &pubB, &prvA, rng), "Derivation failed")
+	Ok(t, k1 != k2, "Keys derived with different KDFs are equal")
+
+	// Unknown KDF
+	Ok(t, (&Nike{KDF: NikeKMAC256 + 1}).DeriveKey(k1[:], &pubA, &pubB, &prvA, rng) == ErrUnsupportedKDF,
+		"Expected ErrUnsupportedKDF")
+
+	// Invalid peer key
+	var n Nike
+	Ok(t, n.DeriveKey(k1[:], &pubA, &PublicKey{a: params512.two}, &prvA, rng) == ErrInvalidPublicKey,
+		"Expected ErrInvalidPublicKey")
+	Ok(t, n.DeriveKey(k1[:], &pubA, NewPublicKey(Csidh1024), &prvA, rng) == ErrParamsMismatch,
+		"Expected ErrParamsMismatch")
+}
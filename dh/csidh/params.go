@@ -0,0 +1,171 @@
+package csidh
+
+import (
+	"math/big"
+	"sync"
+)
+
+// Identifiers of the parameter sets. Number corresponds to the bitsize
+// of the prime field characteristic.
+const (
+	// CSIDH-512, the default parameter set, used by zero value of keys.
+	Csidh512 uint8 = iota
+	// CSIDH-1024, p = 4*l_1*...*l_130 - 1, where l_1..l_129 are first
+	// odd primes and l_130 = 983.
+	Csidh1024
+	// CSIDH-1792, p = 4*l_1*...*l_207 - 1, where l_1..l_206 are first
+	// odd primes and l_207 = 1619.
+	Csidh1792
+)
+
+const (
+	// Maximal number of limbs of field element
+	maxWords = 28
+	// Maximal number of primes l_i
+	maxPrimeCount = 207
+)
+
+// Field element of any parameter set. Only first params.words limbs
+// are used, remaining ones are zero.
+type fpx [maxWords]uint64
+
+// params describes parameter set with arbitrary prime p. Field arithmetic
+// is implemented in fpx.go.
+type params struct {
+	id uint8
+	// Field arithmetic uses dedicated CSIDH-512 implementation
+	dedicated bool
+	// Number of limbs used by field element
+	words int
+	// Bitsize of p
+	pbits int
+	// Primes l_i, such that p = 4 * l_1 * ... * l_n - 1
+	primes []uint64
+	// Exponents of private key are sampled from [-expMax, expMax]
+	expMax int8
+	// p and Montgomery constants
+	p, one, two, twoNeg, four fpx
+	// -p^-1 mod 2^64
+	pNegInv uint64
+	// Exponents, not in Montgomery domain
+	pMin1By2, pMin2 fpx
+	// Sizes of keys and shared secret in bytes
+	privateKeySize, publicKeySize int
+	// Batches of primes used by the group action
+	simba *simbaStrategy
+	// Isogenies of degree not smaller than that are computed with √élu,
+	// 0 if Vélu formulas are used for all degrees
+	sqrtVelu uint64
+}
+
+// CSIDH-512 parameter set, built from constants in consts.go.
+var params512 = &params{
+	id:             Csidh512,
+	dedicated:      true,
+	words:          numWords,
+	pbits:          pbits,
+	primes:         primes[:],
+	expMax:         expMax,
+	p:              fpx{p[0], p[1], p[2], p[3], p[4], p[5], p[6], p[7]},
+	one:            fpx{one[0], one[1], one[2], one[3], one[4], one[5], one[6], one[7]},
+	two:            fpx{two[0], two[1], two[2], two[3], two[4], two[5], two[6], two[7]},
+	twoNeg:         fpx{twoNeg[0], twoNeg[1], twoNeg[2], twoNeg[3], twoNeg[4], twoNeg[5], twoNeg[6], twoNeg[7]},
+	four:           fpx{four[0], four[1], four[2], four[3], four[4], four[5], four[6], four[7]},
+	pNegInv:        pNegInv[0],
+	pMin1By2:       fpx{pMin1By2[0], pMin1By2[1], pMin1By2[2], pMin1By2[3], pMin1By2[4], pMin1By2[5], pMin1By2[6], pMin1By2[7]},
+	pMin2:          fpx{pMin1[0], pMin1[1], pMin1[2], pMin1[3], pMin1[4], pMin1[5], pMin1[6], pMin1[7]},
+	privateKeySize: PrivateKeySize,
+	publicKeySize:  PublicKeySize,
+	simba:          newSimbaStrategy(primes[:], SimbaBatches, SimbaRounds),
+}
+
+// Larger parameter sets are computed on first use, as it takes a while.
+var (
+	params1024, params1792 *params
+	params1024Once         sync.Once
+	params1792Once         sync.Once
+)
+
+// paramsFor returns parameter set for 'id'.
+func paramsFor(id uint8) *params {
+	switch id {
+	case Csidh512:
+		return params512
+	case Csidh1024:
+		params1024Once.Do(func() {
+			params1024 = newParams(Csidh1024, firstOddPrimes(129, 983), 2)
+			params1024.sqrtVelu = sqrtVeluThreshold1024
+		})
+		return params1024
+	case Csidh1792:
+		params1792Once.Do(func() {
+			params1792 = newParams(Csidh1792, firstOddPrimes(206, 1619), 1)
+			params1792.sqrtVelu = sqrtVeluThreshold1792
+		})
+		return params1792
+	default:
+		panic("csidh: unsupported parameter set")
+	}
+}
+
+// firstOddPrimes returns list of first n odd primes with 'last' appended.
+func firstOddPrimes(n int, last uint64) []uint64 {
+	var l []uint64
+	for v := int64(3); len(l) < n; v += 2 {
+		if big.NewInt(v).ProbablyPrime(0) {
+			l = append(l, uint64(v))
+		}
+	}
+	return append(l, last)
+}
+
+// toFpx converts non-negative integer to field element.
+func toFpx(r *fpx, v *big.Int) {
+	var w big.Int
+	var mask = new(big.Int).SetUint64(^uint64(0))
+	for i := range r {
+		r[i] = w.Rsh(v, uint(64*i)).And(&w, mask).Uint64()
+	}
+}
+
+// newParams computes parameter set for a given list of primes.
+func newParams(id uint8, primes []uint64, expMax int8) *params {
+	var t big.Int
+	pp := &params{id: id, primes: primes, expMax: expMax}
+
+	p := big.NewInt(4)
+	for _, l := range primes {
+		p.Mul(p, t.SetUint64(l))
+	}
+	p.Sub(p, big.NewInt(1))
+	if len(primes) > maxPrimeCount || p.BitLen() > 64*maxWords-1 || !p.ProbablyPrime(20) {
+		panic("csidh: wrong parameter set")
+	}
+	// Generic arithmetic requires p < R/2
+	pp.words = (p.BitLen() + 1 + 63) / 64
+	pp.pbits = p.BitLen()
+	pp.privateKeySize = len(primes)
+	pp.publicKeySize = 8 * pp.words
+	pp.simba = newSimbaStrategy(primes, SimbaBatches, SimbaRounds)
+
+	R := new(big.Int).Lsh(big.NewInt(1), uint(64*pp.words))
+	mont := func(r *fpx, v int64) {
+		var m big.Int
+		m.Mul(big.NewInt(v), R)
+		toFpx(r, m.Mod(&m, p))
+	}
+
+	toFpx(&pp.p, p)
+	mont(&pp.one, 1)
+	mont(&pp.two, 2)
+	mont(&pp.twoNeg, -2)
+	mont(&pp.four, 4)
+
+	w := new(big.Int).Lsh(big.NewInt(1), 64)
+	t.ModInverse(t.Mod(p, w), w)
+	pp.pNegInv = t.Sub(w, &t).Uint64()
+
+	toFpx(&pp.pMin1By2, t.Rsh(p, 1))
+	toFpx(&pp.pMin2, t.Sub(p, big.NewInt(2)))
+	return pp
+}
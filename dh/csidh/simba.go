@@ -0,0 +1,80 @@
+package csidh
+
+// Batched strategy of evaluating the group action, based on SIMBA
+// by M. Meyer, F. Campos and S. Reith (ia.cr/2018/1198).
+//
+// Group action computes isogenies of degree l_i with kernels [k/l_i]P,
+// for a random point P of order k. Algorithm 2 from ia.cr/2018/383
+// handles all primes in each round, so each kernel point is computed
+// with scalar multiplication by a product of up to n-1 primes, which
+// makes the cost quadratic in n. SIMBA splits primes into m batches and
+// each round handles only primes from one batch. Point P is multiplied
+// by a product of primes outside of the batch once per round, after that
+// kernel points are computed with much shorter scalars. After a given
+// number of rounds most of the exponents are zero and the batches are
+// merged into one.
+//
+// Within a round, primes are processed from the largest one, so that
+// kernel points are computed with products of smaller primes.
+
+const (
+	// Default number of batches and number of rounds over all batches
+	// after which they are merged, see PrivateKey.SetSimba. Values have
+	// been chosen by counting field multiplications in CSIDH-512 public
+	// key generation, averaged over 40 random keys (thousands):
+	//
+	//   batches   rounds=0   rounds=1   rounds=2   rounds=3
+	//         1        466          -          -          -
+	//         2        469        441        434        437
+	//         3        468        444        441        451
+	//         4        465        454        460        487
+	//         5        470        463        486        525
+	//         6        469        477        510        565
+	//
+	// Timings from BenchmarkSimba follow the same pattern, but on a
+	// loaded machine differences are within the noise.
+	SimbaBatches = 2
+	SimbaRounds  = 2
+)
+
+// simbaStrategy describes split of primes into batches.
+type simbaStrategy struct {
+	// Indices of primes in each batch. The last batch is the merged
+	// one, it contains all primes.
+	batches [][]int
+	// cofactors[j] is 4 times product of primes outside of batches[j]
+	cofactors []fpx
+	// Number of rounds over batches, after which the merged batch is used
+	rounds int
+}
+
+// newSimbaStrategy precomputes strategy with m batches and k rounds
+// for a given list of primes. Prime with index i is assigned to the
+// batch i mod m, so that each batch contains primes of different sizes.
+func newSimbaStrategy(primes []uint64, m, k int) *simbaStrategy {
+	s := &simbaStrategy{
+		batches:   make([][]int, m+1),
+		cofactors: make([]fpx, m+1),
+		rounds:    k,
+	}
+	for j := range s.batches {
+		s.cofactors[j] = fpx{4}
+		for i, l := range primes {
+			if j == m || i%m == j {
+				s.batches[j] = append(s.batches[j], i)
+			} else {
+				mulScalar(&s.cofactors[j], &s.cofactors[j], l)
+			}
+		}
+	}
+	return s
+}
+
+// batch returns index of the batch to be processed in step 'r'.
+func (s *simbaStrategy) batch(r int) int {
+	m := len(s.batches) - 1
+	if r < s.rounds*m {
+		return r % m
+	}
+	return m
+}
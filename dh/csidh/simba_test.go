@@ -0,0 +1,47 @@
+package csidh
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestSimbaStrategy(t *testing.T) {
+	const m, k = 4, 3
+	var s = newSimbaStrategy(primes[:], m, k)
+	var all big.Int
+	var seen [primeCount]int
+
+	intSetU64(&all, p[:])
+	all.Add(&all, big.NewInt(1))
+
+	if len(s.batches) != m+1 || len(s.batches[m]) != primeCount {
+		t.Fatal("wrong number of batches")
+	}
+	for j, batch := range s.batches {
+		prod := fpxToBig(&s.cofactors[j], numWords)
+		for _, i := range batch {
+			prod.Mul(prod, new(big.Int).SetUint64(primes[i]))
+			if j < m {
+				seen[i]++
+			}
+		}
+		// cofactor times primes of the batch must be p+1
+		if prod.Cmp(&all) != 0 {
+			t.Errorf("wrong cofactor of batch %d", j)
+		}
+	}
+	for i := range seen {
+		if seen[i] != 1 {
+			t.Errorf("prime %d is in %d batches", primes[i], seen[i])
+		}
+	}
+
+	for r := 0; r < m*k; r++ {
+		if s.batch(r) != r%m {
+			t.Errorf("wrong batch in step %d", r)
+		}
+	}
+	if s.batch(m*k) != m {
+		t.Error("batches not merged")
+	}
+}
@@ -0,0 +1,501 @@
+package csidh
+
+// Implementation of √élu algorithm by D. J. Bernstein, L. De Feo, A. Leroux
+// and B. Smith (ia.cr/2020/341).
+//
+// Isogeny is computed with the same formulas as used by xIso, which
+// require values of the polynomial
+//   h(X) = prod_{s=1}^{(l-1)/2} (X - x([s]K))
+// at X=1, X=-1 (image curve) and at x(P), 1/x(P) (image point). Set of
+// indices s is replaced by the set S of odd numbers from [1, l-2], which
+// defines the same set of x-coordinates, and S is split into
+// (I+J) u (I-J) u K. Then, for given α,
+//   prod_{i in I, j in J} (α - x([i+j]K))(α - x([i-j]K))
+// is computed as prod_{i in I} E_J(x([i]K)), where E_J is a product of
+// biquadratic polynomials related to x-only addition formulas. This needs
+// only |I| + |J| + |K| points instead of (l-1)/2 used by Vélu formulas.
+//
+// The product over I is, up to a constant, the resultant of
+// h_I(X) = prod_{i in I} (Z_i*X - X_i) and E_J. It is computed as in the
+// paper: product tree of h_I is built once per isogeny, then for each α
+// polynomial E_J is reduced modulo nodes of the tree (remainder tree)
+// until its values at x_i are obtained at leaves. Remainders are computed
+// with precomputed reciprocals of the nodes, so that they need only
+// multiplications of polynomials, done with Karatsuba. E_J is itself
+// computed with a product tree.
+//
+// All values are computed projectively, as homogeneous forms. Factors
+// not depending on α cancel in the formulas, so they are skipped. In
+// particular remainders are scaled by powers of leading coefficients of
+// the nodes, hence no inversion is needed.
+//
+// Temporary polynomials are taken from a single buffer allocated once
+// per isogeny, see fpxStack.
+
+const (
+	// Smallest degrees of isogenies computed with √élu for CSIDH-1024
+	// and CSIDH-1792, Vélu formulas are used for smaller ones. For
+	// CSIDH-512 Vélu formulas are faster for all primes.
+	//
+	// Crossover is much higher than ~100 reported in the paper. For
+	// degrees below ~600 this implementation of √élu needs about as
+	// many multiplications as Vélu formulas (3323 and 3073 for l=503
+	// and CSIDH-1024) and 3-4 times more additions, which are not
+	// negligible compared to multiplication in generic field arithmetic.
+	// For CSIDH-1024 the threshold makes a difference only for l=983,
+	// the other primes are smaller than 740. At that degree both
+	// methods perform the same, within noise of the measurements.
+	//
+	// Values have been chosen with BenchmarkXIso (Intel Xeon,
+	// -benchtime 10x -count 10), median in µs/op:
+	//
+	//      l   CSIDH-512      CSIDH-1024      CSIDH-1792
+	//          Vélu  √élu     Vélu   √élu     Vélu   √élu
+	//    101     69   264      956   1423     3648   6082
+	//    211    229   421     2070   2132     7207   7169
+	//    307    333   533     2350   4322     8370  10701
+	//    373    335   697     4669   5885    12262  13046
+	//    409      -     -     4301   6275    10945  15365
+	//    503      -     -     6132   6411    15757  19697
+	//    587    441   953     6684   7405    19761  20880
+	//    601      -     -     6952   7586    17738  20693
+	//    701      -     -     7835   8654    21835  23600
+	//    809      -     -        -      -    23545  22924
+	//    907      -     -        -      -    30446  26812
+	//    983      -     -     9536  10376    27128  21830
+	//   1103      -     -        -      -    33064  26623
+	//   1201      -     -        -      -    30226  25053
+	//   1619      -     -        -      -    42694  40399
+	sqrtVeluThreshold1024 = 983
+	sqrtVeluThreshold1792 = 800
+	// Polynomials shorter than that are multiplied with schoolbook
+	// method, otherwise Karatsuba is used.
+	karatsubaThreshold = 2
+)
+
+// fpxStack is a preallocated buffer from which polynomials are taken.
+// Memory is reclaimed by restoring 'top' to its previous value.
+type fpxStack struct {
+	buf []fpx
+	top int
+}
+
+// alloc returns 'n' elements from the stack. Elements are not zeroed.
+func (s *fpxStack) alloc(n int) []fpx {
+	if s.top+n > len(s.buf) {
+		// Not expected, size of the buffer is an upper bound
+		return make([]fpx, n)
+	}
+	r := s.buf[s.top : s.top+n : s.top+n]
+	s.top += n
+	return r
+}
+
+// polyTree is a product tree of polynomials Z_i*X - X_i.
+type polyTree struct {
+	// Product of polynomials Z_i*X - X_i from leaves of the node
+	g []fpx
+	// Reciprocal of g, scaled by ck, see polyRecip
+	rinv []fpx
+	// c^len(rinv), where c is the leading coefficient of g
+	ck fpx
+	// Children, nil for leaves
+	left, right *polyTree
+}
+
+// sqrtVeluSizes returns b=|J| and b'=|I| for an isogeny of degree ell.
+func sqrtVeluSizes(ell uint64) (b, bp uint64) {
+	// b = floor(sqrt(l-1)/2), at least 1
+	b = 1
+	for (2*b+2)*(2*b+2) <= ell-1 {
+		b++
+	}
+	bp = (ell - 1) / (4 * b)
+	return
+}
+
+// polyMul sets r = a*b. Length of 'r' must be len(a)+len(b)-1. 'r'
+// must not overlap with 'a' nor 'b'. Temporary values are taken
+// from 's'.
+func (f *params) polyMul(r, a, b []fpx, s *fpxStack) {
+	var t fpx
+	la, lb := len(a), len(b)
+	if la < karatsubaThreshold || lb < karatsubaThreshold {
+		for i := range r {
+			r[i] = fpx{}
+		}
+		for i := range a {
+			for j := range b {
+				f.mul(&t, &a[i], &b[j])
+				f.add(&r[i+j], &r[i+j], &t)
+			}
+		}
+		return
+	}
+
+	// Karatsuba: a = a0 + X^h*a1, b = b0 + X^h*b1. Products a0*b0
+	// and a1*b1 are stored directly in 'r'.
+	h := la / 2
+	if lb < la {
+		h = lb / 2
+	}
+	a0, a1, b0, b1 := a[:h], a[h:], b[:h], b[h:]
+	top := s.top
+	sa := s.alloc(la - h)
+	sb := s.alloc(lb - h)
+	z1 := s.alloc(la + lb - 2*h - 1)
+	copy(sa, a1)
+	copy(sb, b1)
+	for i := 0; i < h; i++ {
+		f.add(&sa[i], &sa[i], &a0[i])
+		f.add(&sb[i], &sb[i], &b0[i])
+	}
+
+	z0, z2 := r[:2*h-1], r[2*h:]
+	r[2*h-1] = fpx{}
+	f.polyMul(z0, a0, b0, s)
+	f.polyMul(z2, a1, b1, s)
+	f.polyMul(z1, sa, sb, s)
+	for i := range z0 {
+		f.sub(&z1[i], &z1[i], &z0[i])
+	}
+	for i := range z2 {
+		f.sub(&z1[i], &z1[i], &z2[i])
+	}
+	for i := range z1 {
+		f.add(&r[i+h], &r[i+h], &z1[i])
+	}
+	s.top = top
+}
+
+// polyProduct sets 'r' to the product of polynomials from 'q', computed
+// with a product tree. Length of 'r' must be equal to the length of
+// the product.
+func (f *params) polyProduct(r []fpx, q [][]fpx, s *fpxStack) {
+	if len(q) == 1 {
+		copy(r, q[0])
+		return
+	}
+	var n = 1
+	for _, v := range q[:len(q)/2] {
+		n += len(v) - 1
+	}
+	top := s.top
+	a := s.alloc(n)
+	b := s.alloc(len(r) - n + 1)
+	f.polyProduct(a, q[:len(q)/2], s)
+	f.polyProduct(b, q[len(q)/2:], s)
+	f.polyMul(r, a, b, s)
+	s.top = top
+}
+
+// polyRecip sets 'r' to c^k/(X^d * g(1/X)) mod X^k and 'ck' to c^k,
+// where d=deg(g), c is the leading coefficient of 'g' and k=len(r).
+// Scaling by c^k avoids inversion of c.
+func (f *params) polyRecip(r []fpx, ck *fpx, g []fpx, s *fpxStack) {
+	var t fpx
+	d := len(g) - 1
+	k := len(r)
+	top := s.top
+	// c^0, ..., c^(k-1)
+	c := s.alloc(k)
+	c[0] = f.one
+	for i := 1; i < k; i++ {
+		f.mul(&c[i], &c[i-1], &g[d])
+	}
+	// r[i] = c^(k-1-i) * P_i, where P_0 = 1 and
+	// P_i = -sum_{j=1}^{min(i,d)} g[d-j] * c^(j-1) * P_(i-j)
+	r[0] = f.one
+	for i := 1; i < k; i++ {
+		r[i] = fpx{}
+		for j := 1; j <= d && j <= i; j++ {
+			f.mul(&t, &g[d-j], &r[i-j])
+			if j > 1 {
+				f.mul(&t, &t, &c[j-1])
+			}
+			f.sub(&r[i], &r[i], &t)
+		}
+	}
+	for i := 0; i < k-1; i++ {
+		f.mul(&r[i], &r[i], &c[k-1-i])
+	}
+	f.mul(ck, &c[k-1], &g[d])
+	s.top = top
+}
+
+// buildTree builds product tree of Z_i*X - X_i for points (X_i:Z_i)
+// from 'x'. Nodes are taken from 'nodes', the rest of them is returned.
+// Reciprocal of 't' is computed modulo X^k, which allows to reduce
+// polynomials of length len(x)+k.
+func (f *params) buildTree(t *polyTree, nodes []polyTree, x []point, k int, s *fpxStack) []polyTree {
+	n := len(x)
+	t.g = s.alloc(n + 1)
+	t.left, t.right = nil, nil
+	if n == 1 {
+		f.sub(&t.g[0], &fpx{}, &x[0].x)
+		t.g[1] = x[0].z
+	} else {
+		m := n / 2
+		t.left, t.right = &nodes[0], &nodes[1]
+		// Children reduce remainders modulo t.g, which have length n
+		nodes = f.buildTree(t.left, nodes[2:], x[:m], n-m, s)
+		nodes = f.buildTree(t.right, nodes, x[m:], m, s)
+		f.polyMul(t.g, t.left.g, t.right.g, s)
+	}
+	if k > 0 {
+		t.rinv = s.alloc(k)
+		f.polyRecip(t.rinv, &t.ck, t.g, s)
+	}
+	return nodes
+}
+
+// polyRem sets r = c^k*a mod t.g, where c is the leading coefficient
+// of t.g and k=len(a)-deg(t.g), or r=a if k is not positive. Length
+// of 'r' must be equal to deg(t.g) and 'k' to len(t.rinv). Factor c^k
+// doesn't depend on coefficients of 'a'.
+func (f *params) polyRem(r, a []fpx, t *polyTree, s *fpxStack) {
+	d := len(t.g) - 1
+	k := len(a) - d
+	if k <= 0 {
+		copy(r, a)
+		for i := len(a); i < d; i++ {
+			r[i] = fpx{}
+		}
+		return
+	}
+
+	// Reversed quotient, multiplied by c^k, is the product of reversed
+	// 'a' and the reciprocal of t.g, modulo X^k.
+	top := s.top
+	q := s.alloc(k)
+	qr := s.alloc(2*k - 1)
+	for i := range q {
+		q[i] = a[len(a)-1-i]
+	}
+	f.polyMul(qr, q, t.rinv[:k], s)
+	for i := range q {
+		q[i] = qr[k-1-i]
+	}
+	// r = c^k*a - q*t.g, only d lowest coefficients are needed
+	p := s.alloc(k + d - 1)
+	f.polyMul(p, q, t.g[:d], s)
+	for i := 0; i < d; i++ {
+		f.mul(&r[i], &a[i], &t.ck)
+		f.sub(&r[i], &r[i], &p[i])
+	}
+	s.top = top
+}
+
+// remTree multiplies 'res' by values of 'a' at the roots of t.g,
+// computed with remainder tree. Values are multiplied by factors which
+// depend only on t.g and the length of 'a'.
+func (f *params) remTree(res *fpx, a []fpx, t *polyTree, s *fpxStack) {
+	top := s.top
+	r := s.alloc(len(t.g) - 1)
+	f.polyRem(r, a, t, s)
+	if t.left == nil {
+		f.mul(res, res, &r[0])
+	} else {
+		f.remTree(res, r, t.left, s)
+		f.remTree(res, r, t.right, s)
+	}
+	s.top = top
+}
+
+// sqrtVelu keeps values used by √élu which don't depend on α.
+type sqrtVelu struct {
+	// Product tree of h_I
+	tree *polyTree
+	// Points from J and K
+	J, K []point
+	// Values used by E_J, see sqrtVeluEJ
+	xz, sq []fpx
+	// Coefficient C of the curve
+	c fpx
+	// Biquadratic factors of E_J
+	q [][]fpx
+	// Temporary polynomials
+	s fpxStack
+}
+
+// sqrtVeluEJ sets E to E_J for a given α=(ax:az), as polynomial in the
+// x-coordinate of points from I. Precomputed values for each point
+// (X_j:Z_j) from J are xz[j] = C*X_j*Z_j and
+// sq[j] = C*(X_j^2+Z_j^2) + 2A*X_j*Z_j.
+func (f *params) sqrtVeluEJ(E []fpx, ax, az *fpx, v *sqrtVelu) {
+	var t, m, u fpx
+
+	// t = ax^2 + az^2, m = ax*az
+	f.mul(&t, ax, ax)
+	f.mul(&u, az, az)
+	f.add(&t, &t, &u)
+	f.mul(&m, ax, az)
+
+	for j := range v.J {
+		c := v.q[j]
+		// c2 = C*(ax*Z_j - az*X_j)^2
+		f.mul(&u, ax, &v.J[j].z)
+		f.mul(&c[2], az, &v.J[j].x)
+		f.sub(&c[2], &u, &c[2])
+		f.mul(&c[2], &c[2], &c[2])
+		f.mul(&c[2], &c[2], &v.c)
+		// c0 = C*(ax*X_j - az*Z_j)^2
+		f.mul(&u, ax, &v.J[j].x)
+		f.mul(&c[0], az, &v.J[j].z)
+		f.sub(&c[0], &u, &c[0])
+		f.mul(&c[0], &c[0], &c[0])
+		f.mul(&c[0], &c[0], &v.c)
+		// c1 = -2*(xz*t + m*sq)
+		f.mul(&u, &v.xz[j], &t)
+		f.mul(&c[1], &m, &v.sq[j])
+		f.add(&c[1], &c[1], &u)
+		f.add(&c[1], &c[1], &c[1])
+		f.sub(&c[1], &fpx{}, &c[1])
+	}
+	f.polyProduct(E, v.q, &v.s)
+}
+
+// sqrtVeluH computes value of h at α=(ax:az), up to the factor not
+// depending on α. E is E_J for α.
+func (f *params) sqrtVeluH(res *fpx, ax, az *fpx, E []fpx, v *sqrtVelu) {
+	var t, u fpx
+	*res = f.one
+	f.remTree(res, E, v.tree, &v.s)
+	for k := range v.K {
+		// ax*Z_k - az*X_k
+		f.mul(&t, ax, &v.K[k].z)
+		f.mul(&u, az, &v.K[k].x)
+		f.sub(&t, &t, &u)
+		f.mul(res, res, &t)
+	}
+}
+
+// xIsoSqrt computes the isogeny with kernel point kern of a given order
+// kernOrder with √élu. Returns the new curve coefficient co and images
+// of points from img. Order must be at least 5.
+//
+// Non-constant time.
+func (f *params) xIsoSqrt(co *coeff, kern *point, kernOrder uint64, img ...*point) {
+	var A = point{x: co.a, z: co.c}
+	var coEd coeff
+	var P2, P4b point
+	var h1, h2, hX, hZ, t, minusOne fpx
+	var v sqrtVelu
+
+	b, bp := sqrtVeluSizes(kernOrder)
+	nb, nbp := int(b), int(bp)
+	pts := make([]point, b+bp+(kernOrder-1)/2-2*b*bp)
+	v.J, pts = pts[:nb], pts[nb:]
+	I, K := pts[:nbp], pts[nbp:]
+	v.K = K
+
+	// Upper bound of memory used by polynomials, see buildTree,
+	// polyRem, polyProduct and polyMul.
+	var depth = 1
+	for 1<<uint(depth-1) < nbp {
+		depth++
+	}
+	v.s.buf = make([]fpx, 4*depth*nbp+8*(nb+nbp)+16)
+	v.q = make([][]fpx, nb)
+	for j := range v.q {
+		v.q[j] = v.s.alloc(3)
+	}
+	nodes := make([]polyTree, 2*nbp-1)
+
+	// J = {[1]K, [3]K, ..., [2b-1]K}
+	f.xDbl(&P2, kern, &A)
+	v.J[0] = *kern
+	for j := 1; j < nb; j++ {
+		if j == 1 {
+			f.xAdd(&v.J[1], &P2, kern, kern)
+		} else {
+			f.xAdd(&v.J[j], &v.J[j-1], &P2, &v.J[j-2])
+		}
+	}
+
+	// I = {[2b]K, [6b]K, ..., [2b(2b'-1)]K}
+	f.xMul(&I[0], kern, co, &fpx{2 * b})
+	f.xDbl(&P4b, &I[0], &A)
+	for i := 1; i < nbp; i++ {
+		if i == 1 {
+			f.xAdd(&I[1], &P4b, &I[0], &I[0])
+		} else {
+			f.xAdd(&I[i], &I[i-1], &P4b, &I[i-2])
+		}
+	}
+
+	// K = {[4bb'+1]K, ..., [l-2]K}, computed as {[2]K, [4]K, ..., [l-1-4bb']K}
+	for k := range K {
+		switch k {
+		case 0:
+			K[0] = P2
+		case 1:
+			f.xDbl(&K[1], &P2, &A)
+		default:
+			f.xAdd(&K[k], &K[k-1], &P2, &K[k-2])
+		}
+	}
+
+	// Product tree of h_I. Root reduces E_J, which has length 2b+1.
+	v.tree = &nodes[0]
+	f.buildTree(v.tree, nodes[1:], I, 2*nb+1-nbp, &v.s)
+
+	// Values used by E_J, which don't depend on α
+	v.c = co.c
+	v.xz = v.s.alloc(nb)
+	v.sq = v.s.alloc(nb)
+	for j := range v.J {
+		f.mul(&v.xz[j], &v.J[j].x, &v.J[j].z)
+		f.mul(&v.sq[j], &v.J[j].x, &v.J[j].x)
+		f.mul(&t, &v.J[j].z, &v.J[j].z)
+		f.add(&v.sq[j], &v.sq[j], &t)
+		f.mul(&v.sq[j], &v.sq[j], &co.c)
+		f.mul(&t, &v.xz[j], &co.a)
+		f.add(&t, &t, &t)
+		f.add(&v.sq[j], &v.sq[j], &t)
+		f.mul(&v.xz[j], &v.xz[j], &co.c)
+	}
+	E := v.s.alloc(2*nb + 1)
+	Er := v.s.alloc(2*nb + 1)
+
+	// h(1) and h(-1)
+	f.sub(&minusOne, &fpx{}, &f.one)
+	f.sqrtVeluEJ(E, &f.one, &f.one, &v)
+	f.sqrtVeluH(&h1, &f.one, &f.one, E, &v)
+	f.sqrtVeluEJ(E, &minusOne, &f.one, &v)
+	f.sqrtVeluH(&h2, &minusOne, &f.one, E, &v)
+
+	for _, P := range img {
+		// h(x(P)) and h(1/x(P)). E_J for (Z:X) is the one for (X:Z) with
+		// coefficients in reversed order.
+		f.sqrtVeluEJ(E, &P.x, &P.z, &v)
+		for i := range E {
+			Er[i] = E[len(E)-1-i]
+		}
+		f.sqrtVeluH(&hX, &P.x, &P.z, E, &v)
+		f.sqrtVeluH(&hZ, &P.z, &P.x, Er, &v)
+
+		// Image point
+		f.mul(&hX, &hX, &hX)
+		f.mul(&hZ, &hZ, &hZ)
+		f.mul(&P.x, &P.x, &hZ)
+		f.mul(&P.z, &P.z, &hX)
+	}
+
+	// Image curve, see xIso
+	f.add(&coEd.c, &co.c, &co.c)
+	f.add(&coEd.a, &co.a, &coEd.c)
+	f.sub(&coEd.c, &co.a, &coEd.c)
+	f.exp(&coEd.a, &coEd.a, &fpx{kernOrder})
+	f.exp(&coEd.c, &coEd.c, &fpx{kernOrder})
+	for i := 0; i < 3; i++ {
+		f.mul(&h1, &h1, &h1)
+		f.mul(&h2, &h2, &h2)
+	}
+	f.mul(&coEd.c, &coEd.c, &h1)
+	f.mul(&coEd.a, &coEd.a, &h2)
+
+	f.add(&co.a, &coEd.a, &coEd.c)
+	f.sub(&co.c, &coEd.a, &coEd.c)
+	f.add(&co.a, &co.a, &co.a)
+}
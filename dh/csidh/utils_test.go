@@ -70,7 +70,7 @@ func eqFp(l, r *fp) bool {
 
 // return x==y for point.
 func ceqpoint(l, r *point) bool {
-	return eqFp(&l.x, &r.x) && eqFp(&l.z, &r.z)
+	return params512.equal(&l.x, &r.x) && params512.equal(&l.z, &r.z)
 }
 
 // Converts src to big.Int. Function assumes that src is a slice of uint64
@@ -132,3 +132,11 @@ func toFp(num string) fp {
 	copy(ret[:], intGetU64(&tmp))
 	return ret
 }
+
+// Converts string to fpx element in Montgomery domain of cSIDH-512.
+func toFpxMont(num string) fpx {
+	var ret fpx
+	var v = toFp(num)
+	copy(ret[:], v[:])
+	return ret
+}
@@ -0,0 +1,133 @@
+package csidh
+
+import (
+	"container/list"
+	"io"
+	"sync"
+)
+
+// ValidatedPublicKey is a public key which has passed Validate. It can
+// be obtained only with ValidatePublicKey or ValidationCache, hence
+// shared secret can be derived with DeriveSecretValidated without
+// repeating the validation. ValidatedPublicKey is immutable, so it can
+// be used by multiple goroutines at the same time.
+type ValidatedPublicKey struct {
+	pub PublicKey
+}
+
+// ValidatePublicKey checks 'pub' with CheckPublicKey. If 'pub' is valid,
+// it returns a copy of 'pub' as ValidatedPublicKey, otherwise error
+// returned by CheckPublicKey.
+func ValidatePublicKey(pub *PublicKey, rng io.Reader) (*ValidatedPublicKey, error) {
+	var v = &ValidatedPublicKey{
+		pub: PublicKey{params: pub.params, a: pub.a},
+	}
+	// Validation uses buffers from the key, so copy is used.
+	if err := CheckPublicKey(&v.pub, rng); err != nil {
+		return nil, err
+	}
+	v.pub.fpRngGen = fpRngGen{}
+	return v, nil
+}
+
+// PublicKey returns a copy of the validated public key.
+func (v *ValidatedPublicKey) PublicKey() *PublicKey {
+	return &PublicKey{params: v.pub.params, a: v.pub.a}
+}
+
+// ID returns identifier of the parameter set of the key.
+func (v *ValidatedPublicKey) ID() uint8 {
+	return v.pub.ID()
+}
+
+// DeriveSecretValidated works as ComputeSharedSecret, but skips
+// validation of the public key.
+func DeriveSecretValidated(out []byte, pub *ValidatedPublicKey, prv *PrivateKey, rng io.Reader) error {
+	if pub.pub.paramSet() != prv.paramSet() {
+		return ErrParamsMismatch
+	}
+	if len(out) != pub.pub.SharedSecretSize() {
+		return ErrSharedSecretSize
+	}
+	return deriveSecret(out, &pub.pub, prv, rng)
+}
+
+// ValidationCache keeps recently validated public keys, so that
+// deriving shared secrets repeatedly with the same public keys doesn't
+// require validation each time. Keys are identified by the parameter set
+// and encoding of the key. When cache is full, the least recently used
+// key is evicted. Only valid keys are cached. ValidationCache is safe
+// for concurrent use.
+type ValidationCache struct {
+	mu   sync.Mutex
+	size int
+	// Keys ordered from the most recently used one
+	lru *list.List
+	// Elements of the lru list by encoded key
+	keys map[string]*list.Element
+}
+
+// Element of ValidationCache.lru
+type cacheEntry struct {
+	key string
+	pub *ValidatedPublicKey
+}
+
+// NewValidationCache returns a cache which keeps up to 'size' validated
+// public keys. Panics if 'size' is not positive.
+func NewValidationCache(size int) *ValidationCache {
+	if size <= 0 {
+		panic("csidh: cache size must be positive")
+	}
+	return &ValidationCache{
+		size: size,
+		lru:  list.New(),
+		keys: make(map[string]*list.Element),
+	}
+}
+
+// Validate returns validated 'pub'. Public key found in the cache is
+// returned without validation, otherwise 'pub' is validated and added
+// to the cache. Returns error returned by CheckPublicKey if 'pub' is
+// invalid.
+func (c *ValidationCache) Validate(pub *PublicKey, rng io.Reader) (*ValidatedPublicKey, error) {
+	var key = make([]byte, 1+pub.Size())
+	key[0] = pub.ID()
+	pub.export(key[1:])
+
+	c.mu.Lock()
+	if e, ok := c.keys[string(key)]; ok {
+		c.lru.MoveToFront(e)
+		c.mu.Unlock()
+		return e.Value.(*cacheEntry).pub, nil
+	}
+	c.mu.Unlock()
+
+	// Validation is done without holding the lock. In case the same
+	// key is validated concurrently, only one copy is cached.
+	v, err := ValidatePublicKey(pub, rng)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.keys[string(key)]; ok {
+		c.lru.MoveToFront(e)
+		return e.Value.(*cacheEntry).pub, nil
+	}
+	c.keys[string(key)] = c.lru.PushFront(&cacheEntry{key: string(key), pub: v})
+	if c.lru.Len() > c.size {
+		e := c.lru.Back()
+		c.lru.Remove(e)
+		delete(c.keys, e.Value.(*cacheEntry).key)
+	}
+	return v, nil
+}
+
+// Len returns number of keys in the cache.
+func (c *ValidationCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
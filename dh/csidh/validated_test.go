@@ -0,0 +1,123 @@
+package csidh
+
+import (
+	"bytes"
+	crand "crypto/rand"
+	"sync"
+	"testing"
+)
+
+func TestValidateSupersingular(t *testing.T) {
+	for _, id := range []uint8{Csidh512, Csidh1024, Csidh1792} {
+		var buf = make([]byte, NewPublicKey(id).Size())
+
+		// Curve with A=0 is supersingular
+		pub := NewPublicKey(id)
+		Ok(t, Validate(pub, rng), "Starting curve hasn't been validated")
+
+		// Random curves are ordinary with overwhelming probability
+		for i := 0; i < numIter; i++ {
+			_, _ = rng.Read(buf)
+			buf[len(buf)-1] &= 0x3f
+			pub = NewPublicKey(id)
+			Ok(t, pub.Import(buf), "Import failed")
+			Ok(t, !Validate(pub, rng), "Random curve has been validated")
+		}
+	}
+}
+
+func TestDeriveSecretValidated(t *testing.T) {
+	for _, id := range []uint8{Csidh512, Csidh1024} {
+		var prv1, prv2 = NewPrivateKey(id), NewPrivateKey(id)
+		var pub1, pub2 = NewPublicKey(id), NewPublicKey(id)
+
+		checkErr(t, GeneratePrivateKey(prv1, rng), "PrivateKey generation failed")
+		checkErr(t, GeneratePrivateKey(prv2, rng), "PrivateKey generation failed")
+		GeneratePublicKey(pub1, prv1, rng)
+		GeneratePublicKey(pub2, prv2, rng)
+
+		ss1 := make([]byte, pub1.SharedSecretSize())
+		ss2 := make([]byte, pub1.SharedSecretSize())
+		vpub, err := ValidatePublicKey(pub2, rng)
+		Ok(t, err == nil, "Validation failed")
+		Ok(t, vpub.ID() == id, "Wrong parameter set")
+		Ok(t, *vpub.PublicKey() == PublicKey{params: pub2.params, a: pub2.a},
+			"Validated key differs")
+		Ok(t, DeriveSecretValidated(ss1, vpub, prv1, rng) == nil, "Derivation failed")
+		Ok(t, DeriveSharedSecret(ss2, pub1, prv2, rng), "Derivation failed")
+		if !bytes.Equal(ss1, ss2) {
+			t.Error("ss1 != ss2")
+		}
+
+		// Mixing parameter sets
+		var prv512 PrivateKey
+		if id != Csidh512 {
+			Ok(t, DeriveSecretValidated(ss1, vpub, &prv512, rng) == ErrParamsMismatch,
+				"Derivation with mixed parameters succeeded")
+		}
+	}
+
+	pub := PublicKey{a: params512.two}
+	_, err := ValidatePublicKey(&pub, rng)
+	Ok(t, err == ErrInvalidPublicKey, "Invalid key passed validation")
+}
+
+func TestValidationCache(t *testing.T) {
+	var keys [4]PublicKey
+	var prv PrivateKey
+	var wg sync.WaitGroup
+
+	for i := range keys {
+		checkErr(t, GeneratePrivateKey(&prv, rng), "PrivateKey generation failed")
+		GeneratePublicKey(&keys[i], &prv, rng)
+	}
+
+	c := NewValidationCache(2)
+	v1, err := c.Validate(&keys[0], rng)
+	Ok(t, err == nil, "Validation failed")
+	v2, err := c.Validate(&keys[0], rng)
+	Ok(t, err == nil && v1 == v2, "Key not found in the cache")
+
+	// keys[0] is used recently, keys[1] gets evicted
+	_, _ = c.Validate(&keys[1], rng)
+	_, _ = c.Validate(&keys[0], rng)
+	_, _ = c.Validate(&keys[2], rng)
+	Ok(t, c.Len() == 2, "Wrong cache size")
+	v2, _ = c.Validate(&keys[0], rng)
+	Ok(t, v1 == v2, "Recently used key has been evicted")
+
+	// Invalid keys are not cached
+	_, err = c.Validate(&PublicKey{a: params512.twoNeg}, rng)
+	Ok(t, err == ErrInvalidPublicKey, "Invalid key passed validation")
+	Ok(t, c.Len() == 2, "Invalid key has been cached")
+
+	// Concurrent use, each goroutine needs its own rng
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(pub PublicKey) {
+			defer wg.Done()
+			var prv PrivateKey
+			var ss1, ss2 [SharedSecretSize]byte
+			checkErr(t, GeneratePrivateKey(&prv, crand.Reader), "PrivateKey generation failed")
+			v, err := c.Validate(&pub, crand.Reader)
+			Ok(t, err == nil, "Validation failed")
+			Ok(t, DeriveSecretValidated(ss1[:], v, &prv, crand.Reader) == nil, "Derivation failed")
+			Ok(t, DeriveSecret(&ss2, &pub, &prv, crand.Reader), "Derivation failed")
+			Ok(t, ss1 == ss2, "Shared secrets differ")
+		}(keys[i%len(keys)])
+	}
+	wg.Wait()
+	Ok(t, c.Len() == 2, "Wrong cache size")
+}
+
+func BenchmarkDeriveValidated(b *testing.B) {
+	var ss [SharedSecretSize]byte
+	_ = GeneratePrivateKey(&prv1, rng)
+	GeneratePublicKey(&pub1, &prv1, rng)
+	c := NewValidationCache(16)
+
+	for n := 0; n < b.N; n++ {
+		v, _ := c.Validate(&pub1, rng)
+		DeriveSecretValidated(ss[:], v, &prv1, rng)
+	}
+}
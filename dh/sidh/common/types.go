@@ -23,6 +23,7 @@ const (
 	Fp503 uint8 = iota
 	Fp751
 	Fp434
+	Fp610
 )
 
 // Representation of an element of the base field F_p.
@@ -50,6 +51,8 @@ type DomainParams struct {
 	SecretBitLen uint
 	// Max size of secret key for x-torsion group
 	SecretByteLen uint
+	// Size of compressed public key generated with these parameters
+	CompressedPublicKeySize int
 }
 
 type SidhParams struct {
@@ -73,6 +76,8 @@ type SidhParams struct {
 	KemSize int
 	// Byte size of ciphertext that KEM produces
 	CiphertextSize int
+	// Byte size of ciphertext that KEM with compressed keys produces
+	CompressedCiphertextSize int
 	// Defines A,C constant for starting curve Cy^2 = x^3 + Ax^2 + x
 	InitCurve ProjectiveCurveParameters
 }
@@ -88,6 +93,16 @@ type CurveCoefficientsEquiv struct {
 	C Fp2
 }
 
+// Isogeny of Montgomery curves of small degree, in x-only arithmetic.
+type Isogeny interface {
+	// Constructs isogeny with kernel generated by p. Returns coefficients
+	// of the codomain curve in a form equivalent to (A:C), which depends
+	// on the degree (see CurveCoefficientsEquiv).
+	GenerateCurve(p *ProjectivePoint) CurveCoefficientsEquiv
+	// Evaluates isogeny at a point p.
+	EvaluatePoint(p *ProjectivePoint) ProjectivePoint
+}
+
 // A point on the projective line P^1(F_{p^2}).
 //
 // This represents a point on the Kummer line of a Montgomery curve.  The
@@ -97,6 +112,14 @@ type ProjectivePoint struct {
 	Z Fp2
 }
 
+// A point on a Montgomery curve y^2 = x^3 + A*x^2 + x in projective
+// coordinates (X:Y:Z). The point at infinity has Z = 0.
+type Point struct {
+	X Fp2
+	Y Fp2
+	Z Fp2
+}
+
 // A point on the projective line P^1(F_{p^2}).
 //
 // This is used to work projectively with the curve coefficients.
@@ -0,0 +1,28 @@
+package sidh
+
+import (
+	"errors"
+)
+
+// Errors returned by the package
+var (
+	// ErrWrongVariant is returned when key variant can't be used by the
+	// operation, for example when SIDH key is passed to KEM or both keys
+	// used for derivation of a shared secret come from the same torsion
+	// group.
+	ErrWrongVariant = errors.New("sidh: wrong key variant")
+	// ErrParamMismatch is returned when keys or KEM use different
+	// parameter sets.
+	ErrParamMismatch = errors.New("sidh: parameter sets don't match")
+	// ErrBufferSize is returned when input or output buffer has wrong
+	// size.
+	ErrBufferSize = errors.New("sidh: wrong buffer size")
+	// ErrUnsupportedField is returned when key uses a field which isn't
+	// supported by the package.
+	ErrUnsupportedField = errors.New("sidh: unsupported field")
+	// ErrUnallocated is returned when KEM is used before Allocate.
+	ErrUnallocated = errors.New("sidh: KEM unallocated")
+	// ErrInvalidPublicKey is returned by Validate when public key is
+	// malformed.
+	ErrInvalidPublicKey = errors.New("sidh: invalid public key")
+)
@@ -11,7 +11,7 @@ import (
 	"testing/quick"
 
 	"github.com/henrydcase/nobs/dh/sidh/common"
-	"golang.org/x/sys/cpu"
+	"github.com/henrydcase/nobs/utils"
 )
 
 type OptimFlag uint
@@ -24,7 +24,7 @@ const (
 )
 
 func resetCpuFeatures() {
-	HasADXandBMI2 = cpu.X86.HasBMI2 && cpu.X86.HasADX
+	HasADXandBMI2 = utils.X86.HasBMI2 && utils.X86.HasADX
 }
 
 // Utility function used for testing Mul implementations. Tests caller provided
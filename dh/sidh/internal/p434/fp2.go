@@ -193,3 +193,110 @@ func FromMontgomery(out, in *common.Fp2) {
 	rdcP434(&out.B, &aR)
 	modP434(&out.B)
 }
+
+// Returns true if x = 0 mod p. Takes variable time.
+func isZeroP(x *common.Fp) bool {
+	var t = *x
+	var acc uint64
+
+	modP434(&t)
+	for i := range t {
+		acc |= t[i]
+	}
+	return acc == 0
+}
+
+// Returns true if x = y mod p. Takes variable time.
+func equalP(x, y *common.Fp) bool {
+	var t common.Fp
+	subP434(&t, x, y)
+	return isZeroP(&t)
+}
+
+// Returns true if x is a square in Fp, i.e. x^((p-1)/2) is 0 or 1.
+func isSquareP(x *common.Fp) bool {
+	var t common.Fp
+
+	p34(&t, x)
+	mulP(&t, &t, &t)
+	mulP(&t, &t, x)
+	return isZeroP(x) || equalP(&t, &params.OneFp2.A)
+}
+
+// Sets dest = x^((p+1)/4), which is a square root of x if x is a square.
+func sqrtP(dest, x *common.Fp) {
+	var t common.Fp
+
+	p34(&t, x)
+	mulP(dest, &t, x)
+}
+
+// Sets dest = 1/x = x^(p-2).
+func invP(dest, x *common.Fp) {
+	var t common.Fp
+
+	p34(&t, x)
+	mulP(&t, &t, &t)
+	mulP(&t, &t, &t)
+	mulP(dest, &t, x)
+}
+
+// Returns true if x = 0. Takes variable time.
+func isZero(x *common.Fp2) bool {
+	return isZeroP(&x.A) && isZeroP(&x.B)
+}
+
+// Returns true if x = y. Takes variable time.
+func equal(x, y *common.Fp2) bool {
+	return equalP(&x.A, &y.A) && equalP(&x.B, &y.B)
+}
+
+// IsSquare returns true if x is a square in Fp2. That's the case if and
+// only if the norm x*conj(x) = a^2 + b^2 is a square in Fp. Takes
+// variable time.
+func IsSquare(x *common.Fp2) bool {
+	var n, t common.Fp
+
+	mulP(&n, &x.A, &x.A)
+	mulP(&t, &x.B, &x.B)
+	addP434(&n, &n, &t)
+	return isSquareP(&n)
+}
+
+// Sqrt sets dest to a square root of x. Result is undefined if x is not
+// a square. For x = a + bi, the root is x0 + x1*i, where
+// x0^2 = (a +/- sqrt(a^2+b^2))/2 and x1 = b/(2*x0). Takes variable time.
+func Sqrt(dest, x *common.Fp2) {
+	var n, d, t common.Fp
+	var a, b = x.A, x.B
+
+	if isZeroP(&b) {
+		if isSquareP(&a) {
+			sqrtP(&dest.A, &a)
+			dest.B = common.Fp{}
+		} else {
+			subP434(&t, &common.Fp{}, &a)
+			sqrtP(&dest.B, &t)
+			dest.A = common.Fp{}
+		}
+		return
+	}
+
+	// n = sqrt(a^2 + b^2)
+	mulP(&n, &a, &a)
+	mulP(&t, &b, &b)
+	addP434(&n, &n, &t)
+	sqrtP(&n, &n)
+	// d = (a + n)/2 or d = (a - n)/2, whichever is a square
+	addP434(&d, &a, &n)
+	mulP(&d, &d, &params.HalfFp2.A)
+	if !isSquareP(&d) {
+		subP434(&d, &a, &n)
+		mulP(&d, &d, &params.HalfFp2.A)
+	}
+	// x0 = sqrt(d), x1 = b/(2*x0)
+	sqrtP(&dest.A, &d)
+	invP(&t, &dest.A)
+	mulP(&t, &t, &params.HalfFp2.A)
+	mulP(&dest.B, &b, &t)
+}
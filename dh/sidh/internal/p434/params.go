@@ -4,7 +4,7 @@ package p434
 
 import (
 	"github.com/henrydcase/nobs/dh/sidh/common"
-	"golang.org/x/sys/cpu"
+	"github.com/henrydcase/nobs/utils"
 )
 
 const (
@@ -14,7 +14,7 @@ const (
 
 var (
 	// HasADXandBMI2 signals support for ADX and BMI2
-	HasADXandBMI2 = cpu.X86.HasBMI2 && cpu.X86.HasADX
+	HasADXandBMI2 = utils.X86.HasBMI2 && utils.X86.HasADX
 
 	// P434 is a prime used by field Fp434
 	P434 = common.Fp{
@@ -112,6 +112,8 @@ var (
 			SecretBitLen: 216,
 			// SecretBitLen in bytes.
 			SecretByteLen: 28,
+			// Size of compressed public key: 2*Bytelen + 3*ceil(log_256(3^e3)) + 1
+			CompressedPublicKeySize: 195,
 			// 2-torsion group computation strategy
 			IsogenyStrategy: []uint32{
 				0x30, 0x1C, 0x10, 0x08, 0x04, 0x02, 0x01, 0x01, 0x02, 0x01,
@@ -164,6 +166,8 @@ var (
 			SecretBitLen: 217,
 			// SecretBitLen in bytes.
 			SecretByteLen: 28,
+			// Size of compressed public key: 2*Bytelen + 3*ceil(e2/8) + 1
+			CompressedPublicKeySize: 192,
 			// 3-torsion group computation strategy
 			IsogenyStrategy: []uint32{
 				0x42, 0x21, 0x11, 0x09, 0x05, 0x03, 0x02, 0x01, 0x01, 0x01,
@@ -187,8 +191,9 @@ var (
 		// SIKEp434 provides 192 bit of classical security ([SIKE], 5.1)
 		KemSize: 16,
 		// ceil(434+7/8)
-		Bytelen:        55,
-		CiphertextSize: 16 + 330,
+		Bytelen:                  55,
+		CiphertextSize:           16 + 330,
+		CompressedCiphertextSize: 16 + 195,
 	}
 )
 
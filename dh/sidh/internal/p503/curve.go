@@ -4,6 +4,8 @@
 package p503
 
 import (
+	"math/big"
+
 	. "github.com/henrydcase/nobs/dh/sidh/common"
 )
 
@@ -19,6 +21,12 @@ type isogeny4 struct {
 	K3 Fp2
 }
 
+// Stores isogeny 2 curve constants
+type isogeny2 struct {
+	K1 Fp2
+	K2 Fp2
+}
+
 // Computes j-invariant for a curve y2=x3+A/Cx+x with A,C in F_(p^2). Result
 // is returned in jBytes buffer, encoded in little-endian format. Caller
 // provided jBytes buffer has to be big enough to j-invariant value. In case
@@ -252,6 +260,181 @@ func ScalarMul3Pt(cparams *ProjectiveCurveParameters, P, Q, PmQ *ProjectivePoint
 	return R1
 }
 
+// -----------------------------------------------------------------------------
+// Arithmetic on points with both coordinates. Functions take variable time
+// and must be used with public data only.
+//
+
+// Sets y = x^3 + a*x^2 + x, the right hand side of the curve equation
+func curveRHS(y, x, a *Fp2) {
+	var t Fp2
+
+	add(&t, x, a)
+	mul(&t, &t, x)
+	add(&t, &t, &params.OneFp2)
+	mul(y, &t, x)
+}
+
+// ToAffine converts P to affine coordinates (Z = 1), unless P is a point
+// at infinity.
+func ToAffine(P *Point) {
+	var t Fp2
+
+	if isZero(&P.Z) {
+		return
+	}
+	inv(&t, &P.Z)
+	mul(&P.X, &P.X, &t)
+	mul(&P.Y, &P.Y, &t)
+	P.Z = params.OneFp2
+}
+
+// AddPoints sets R = P + Q on a curve y^2 = x^3 + a*x^2 + x. P and Q must
+// be affine or at infinity, R is affine or at infinity.
+func AddPoints(R, P, Q *Point, a *Fp2) {
+	var l, t, x3, y3 Fp2
+
+	if isZero(&P.Z) {
+		*R = *Q
+		return
+	}
+	if isZero(&Q.Z) {
+		*R = *P
+		return
+	}
+
+	if equal(&P.X, &Q.X) {
+		add(&t, &P.Y, &Q.Y)
+		if isZero(&t) {
+			*R = Point{}
+			return
+		}
+		// l = (3*x^2 + 2*a*x + 1) / 2y
+		sqr(&l, &P.X)
+		add(&t, &l, &l)
+		add(&l, &l, &t)
+		mul(&t, a, &P.X)
+		add(&t, &t, &t)
+		add(&l, &l, &t)
+		add(&l, &l, &params.OneFp2)
+		add(&t, &P.Y, &P.Y)
+	} else {
+		// l = (yQ - yP) / (xQ - xP)
+		sub(&l, &Q.Y, &P.Y)
+		sub(&t, &Q.X, &P.X)
+	}
+	inv(&t, &t)
+	mul(&l, &l, &t)
+
+	sqr(&x3, &l) // x3 = l^2 - a - xP - xQ
+	sub(&x3, &x3, a)
+	sub(&x3, &x3, &P.X)
+	sub(&x3, &x3, &Q.X)
+	sub(&t, &P.X, &x3) // y3 = l*(xP - x3) - yP
+	mul(&y3, &l, &t)
+	sub(&y3, &y3, &P.Y)
+
+	R.X, R.Y, R.Z = x3, y3, params.OneFp2
+}
+
+// SubPoints sets R = P - Q. Same requirements as for AddPoints apply.
+func SubPoints(R, P, Q *Point, a *Fp2) {
+	var mQ = *Q
+	sub(&mQ.Y, &Fp2{}, &Q.Y)
+	AddPoints(R, P, &mQ, a)
+}
+
+// LiftX sets P to an affine point with x-coordinate x. The y-coordinate
+// is computed as a square root of the curve equation, hence sign of it
+// is not specified. Returns false if x is not an x-coordinate of a point
+// on the curve y^2 = x^3 + a*x^2 + x defined over Fp2.
+func LiftX(P *Point, x, a *Fp2) bool {
+	var y Fp2
+
+	curveRHS(&y, x, a)
+	if !IsSquare(&y) {
+		return false
+	}
+	Sqrt(&P.Y, &y)
+	P.X, P.Z = *x, params.OneFp2
+	return true
+}
+
+// RecoverPoint recovers y-coordinate of a point Q, given an affine point
+// P, x(Q) and x(Q+P) on a curve y^2 = x^3 + a*x^2 + x. Result is stored
+// in R in affine coordinates. x(Q) and x(Q+P) are typically outputs of
+// Montgomery ladder. Uses Okeya-Sakurai formulas, Q and Q+P must not be
+// points at infinity.
+func RecoverPoint(R, P *Point, xQ, xQP *ProjectivePoint, a *Fp2) {
+	var t1, t2, t3, t4 Fp2
+	X1, Z1, X2, Z2 := &xQ.X, &xQ.Z, &xQP.X, &xQP.Z
+
+	mul(&t1, &P.X, Z1)  // t1 = xP * Z1
+	add(&t2, X1, &t1)   // t2 = X1 + t1
+	sub(&t3, X1, &t1)   // t3 = X1 - t1
+	sqr(&t3, &t3)       // t3 = t3^2
+	mul(&t3, &t3, X2)   // t3 = t3 * X2
+	add(&t1, a, a)      // t1 = 2*a * Z1
+	mul(&t1, &t1, Z1)   //
+	add(&t2, &t2, &t1)  // t2 = t2 + t1
+	mul(&t4, &P.X, X1)  // t4 = xP * X1 + Z1
+	add(&t4, &t4, Z1)   //
+	mul(&t2, &t2, &t4)  // t2 = t2 * t4
+	mul(&t1, &t1, Z1)   // t1 = t1 * Z1
+	sub(&t2, &t2, &t1)  // t2 = (t2 - t1) * Z2
+	mul(&t2, &t2, Z2)   //
+	sub(&R.Y, &t2, &t3) // Y = t2 - t3
+	add(&t1, &P.Y, &P.Y)
+	mul(&t1, &t1, Z1)  // t1 = 2 * yP * Z1 * Z2
+	mul(&t1, &t1, Z2)  //
+	mul(&R.X, &t1, X1) // X = t1 * X1
+	mul(&R.Z, &t1, Z1) // Z = t1 * Z1
+	ToAffine(R)
+}
+
+// ScalarMulPoint sets R = [k]P on a curve y^2 = x^3 + a*x^2 + x. P must be
+// affine or at infinity. Uses Montgomery ladder followed by recovery of
+// y-coordinate. Result is in affine coordinates.
+func ScalarMulPoint(R, P *Point, a *Fp2, k *big.Int) {
+	var xP = ProjectivePoint{X: P.X, Z: params.OneFp2}
+	var R0 = ProjectivePoint{X: params.OneFp2}
+	var R1 = xP
+	var a24 Fp2
+
+	if isZero(&P.Z) {
+		*R = Point{}
+		return
+	}
+
+	// a24 = (a+2)/4
+	add(&a24, a, &params.OneFp2)
+	add(&a24, &a24, &params.OneFp2)
+	mul(&a24, &a24, &params.HalfFp2)
+	mul(&a24, &a24, &params.HalfFp2)
+
+	// Invariant: R1 - R0 = P
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		if k.Bit(i) == 1 {
+			R1, R0 = xDbladd(&R1, &R0, &xP, &a24)
+		} else {
+			R0, R1 = xDbladd(&R0, &R1, &xP, &a24)
+		}
+	}
+
+	if isZero(&R0.Z) {
+		// [k]P = O
+		*R = Point{}
+		return
+	}
+	if isZero(&R1.Z) {
+		// [k]P = -P
+		*R = *P
+		sub(&R.Y, &Fp2{}, &P.Y)
+		return
+	}
+	RecoverPoint(R, P, &R0, &R1, a)
+}
+
 // Given a three-torsion point p = x(PB) on the curve E_(A:C), construct the
 // three-isogeny phi : E_(A:C) -> E_(A:C)/<P_3> = E_(A':C').
 //
@@ -360,3 +543,41 @@ func (phi *isogeny4) EvaluatePoint(p *ProjectivePoint) ProjectivePoint {
 	mul(zq, zq, &t0)
 	return q
 }
+
+// Given a two-torsion point p = x(P2) on the curve E_(A:C), construct the
+// two-isogeny phi : E_(A:C) -> E_(A:C)/<P_2> = E_(A':C'). Point P_2 must
+// not be (0,0).
+//
+// Input: (XP_2: ZP_2), where P_2 has exact order 2 on E_A/C
+// Output: * Curve coordinates (A' + 2C', 4C') corresponding to E_A'/C' = A_E/C/<P2>
+//         * Isogeny phi with constants in F_p^2
+func (phi *isogeny2) GenerateCurve(p *ProjectivePoint) CurveCoefficientsEquiv {
+	var coefEq CurveCoefficientsEquiv
+	var K1, K2 = &phi.K1, &phi.K2
+
+	add(K1, &p.X, &p.Z)                   // K1 = XP2 + ZP2
+	sub(K2, &p.X, &p.Z)                   // K2 = XP2 - ZP2
+	sqr(&coefEq.A, &p.X)                  // A24p = XP2^2
+	sqr(&coefEq.C, &p.Z)                  // C24 = ZP2^2
+	sub(&coefEq.A, &coefEq.C, &coefEq.A)  // A24p = C24 - A24p
+	return coefEq
+}
+
+// Given a 2-isogeny phi and a point xP = x(P), compute x(Q), the x-coordinate
+// of the image Q = phi(P) of P under phi : E_(A:C) -> E_(A':C').
+func (phi *isogeny2) EvaluatePoint(p *ProjectivePoint) ProjectivePoint {
+	var t0, t1, t2 Fp2
+	var q ProjectivePoint
+	var K1, K2 = &phi.K1, &phi.K2
+	var px, pz = &p.X, &p.Z
+
+	sub(&t0, px, pz)   // t0 = XP - ZP
+	add(&t1, px, pz)   // t1 = XP + ZP
+	mul(&t0, K1, &t0)  // t0 = K1 * t0
+	mul(&t1, K2, &t1)  // t1 = K2 * t1
+	add(&t2, &t0, &t1) // t2 = t0 + t1
+	sub(&t0, &t0, &t1) // t0 = t0 - t1
+	mul(&q.X, px, &t2) // XQ'= XP * t2
+	mul(&q.Z, pz, &t0) // ZQ'= ZP * t0
+	return q
+}
@@ -90,6 +90,9 @@ func TestFp2ToBytesRoundTrip(t *testing.T) {
 		var xBytes = make([]byte, 2*params.Bytelen)
 		var xPrime common.Fp2
 
+		// Encoding is defined for elements smaller than p
+		modP503(&x.ExtElem.A)
+		modP503(&x.ExtElem.B)
 		common.Fp2ToBytes(xBytes[:], &x.ExtElem, params.Bytelen)
 		common.BytesToFp2(&xPrime, xBytes[:], params.Bytelen)
 		return vartimeEqFp2(&xPrime, &x.ExtElem)
@@ -199,6 +202,33 @@ func TestFp2Batch3Inv(t *testing.T) {
 	}
 }
 
+func TestFp2Sqrt(t *testing.T) {
+	sqrtTest := func(x testParams) bool {
+		var sq, r common.Fp2
+
+		sqr(&sq, &x.ExtElem)
+		if !IsSquare(&sq) {
+			return false
+		}
+		Sqrt(&r, &sq)
+		sqr(&r, &r)
+		if !equal(&r, &sq) {
+			return false
+		}
+
+		// Random element is a square with probability 1/2. IsSquare
+		// must agree with the result of Sqrt.
+		Sqrt(&r, &x.ExtElem)
+		sqr(&r, &r)
+		return IsSquare(&x.ExtElem) == equal(&r, &x.ExtElem)
+	}
+
+	var quickCheckConfig = &quick.Config{MaxCount: (1 << 10)}
+	if err := quick.Check(sqrtTest, quickCheckConfig); err != nil {
+		t.Error(err)
+	}
+}
+
 func BenchmarkFp2Mul(b *testing.B) {
 	z := &common.Fp2{A: bench_x, B: bench_y}
 	w := new(common.Fp2)
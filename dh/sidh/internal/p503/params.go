@@ -4,7 +4,7 @@ package p503
 
 import (
 	"github.com/henrydcase/nobs/dh/sidh/common"
-	"golang.org/x/sys/cpu"
+	"github.com/henrydcase/nobs/utils"
 )
 
 const (
@@ -17,9 +17,9 @@ var (
 	// According to https://github.com/golang/go/issues/28230,
 	// variables referred from the assembly must be in the same package.
 	// HasBMI2 signals support for MULX which is in BMI2
-	HasBMI2 = cpu.X86.HasBMI2
+	HasBMI2 = utils.X86.HasBMI2
 	// HasADXandBMI2 signals support for ADX and BMI2
-	HasADXandBMI2 = cpu.X86.HasBMI2 && cpu.X86.HasADX
+	HasADXandBMI2 = utils.X86.HasBMI2 && utils.X86.HasADX
 
 	// P503 is a prime used by field Fp503
 	P503 = common.Fp{
@@ -118,6 +118,8 @@ var (
 			SecretBitLen: 250,
 			// SecretBitLen in bytes.
 			SecretByteLen: 32,
+			// Size of compressed public key: 2*Bytelen + 3*ceil(log_256(3^e3)) + 1
+			CompressedPublicKeySize: 223,
 			// 2-torsion group computation strategy
 			IsogenyStrategy: []uint32{
 				0x3D, 0x20, 0x10, 0x08, 0x04, 0x02, 0x01, 0x01, 0x02, 0x01, 0x01, 0x04, 0x02, 0x01,
@@ -168,6 +170,8 @@ var (
 			SecretBitLen: 252,
 			// SecretBitLen in bytes.
 			SecretByteLen: 32,
+			// Size of compressed public key: 2*Bytelen + 3*ceil(e2/8) + 1
+			CompressedPublicKeySize: 223,
 			// 3-torsion group computation strategy
 			IsogenyStrategy: []uint32{
 				0x47, 0x26, 0x15, 0x0D, 0x08, 0x04, 0x02, 0x01, 0x01, 0x02, 0x01, 0x01, 0x04, 0x02,
@@ -189,8 +193,9 @@ var (
 		// SIKEp503 provides 192 bit of classical security ([SIKE], 5.1)
 		KemSize: 24,
 		// ceil(503+7/8)
-		Bytelen:        63,
-		CiphertextSize: 24 + 378,
+		Bytelen:                  63,
+		CiphertextSize:           24 + 378,
+		CompressedCiphertextSize: 24 + 223,
 		InitCurve: common.ProjectiveCurveParameters{
 			A: six,
 			C: one,
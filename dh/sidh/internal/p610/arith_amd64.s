@@ -0,0 +1,719 @@
+// +build amd64,!noasm
+
+#include "textflag.h"
+
+// p610
+#define P610_0 $0xFFFFFFFFFFFFFFFF
+#define P610_1 $0xFFFFFFFFFFFFFFFF
+#define P610_2 $0xFFFFFFFFFFFFFFFF
+#define P610_3 $0xFFFFFFFFFFFFFFFF
+#define P610_4 $0x6E01FFFFFFFFFFFF
+#define P610_5 $0xB1784DE8AA5AB02E
+#define P610_6 $0x9AE7BF45048FF9AB
+#define P610_7 $0xB255B2FA10C4252A
+#define P610_8 $0x819010C251E7D88C
+#define P610_9 $0x000000027BF6A768
+
+// p610 x 2
+#define P610X2_0 $0xFFFFFFFFFFFFFFFE
+#define P610X2_1 $0xFFFFFFFFFFFFFFFF
+#define P610X2_2 $0xFFFFFFFFFFFFFFFF
+#define P610X2_3 $0xFFFFFFFFFFFFFFFF
+#define P610X2_4 $0xDC03FFFFFFFFFFFF
+#define P610X2_5 $0x62F09BD154B5605C
+#define P610X2_6 $0x35CF7E8A091FF357
+#define P610X2_7 $0x64AB65F421884A55
+#define P610X2_8 $0x03202184A3CFB119
+#define P610X2_9 $0x00000004F7ED4ED1
+
+// p610 + 1
+#define P610P1_4 $0x6E02000000000000
+#define P610P1_5 $0xB1784DE8AA5AB02E
+#define P610P1_6 $0x9AE7BF45048FF9AB
+#define P610P1_7 $0xB255B2FA10C4252A
+#define P610P1_8 $0x819010C251E7D88C
+#define P610P1_9 $0x000000027BF6A768
+
+// Redefine P610p1Zeros
+#define P610_P1_ZEROS 4
+
+// Multiplies 64-bit words X and Y and adds 128-bit result to the
+// 192-bit accumulator (C2:C1:C0).
+//
+// Uses registers: AX, DX
+#define MULACC(X, Y, C0, C1, C2) \
+    MOVQ    X, AX   \
+    MULQ    Y       \
+    ADDQ    AX, C0  \
+    ADCQ    DX, C1  \
+    ADCQ    $0, C2
+
+TEXT ·cswapP610(SB),NOSPLIT,$0-17
+
+    MOVQ    x+0(FP), DI
+    MOVQ    y+8(FP), SI
+    MOVB    choice+16(FP), AL   // AL = 0 or 1
+    MOVBLZX AL, AX  // AX = 0 or 1
+    NEGQ    AX          // AX = 0x00..00 or 0xff..ff
+#ifndef CSWAP_BLOCK
+#define CSWAP_BLOCK(idx)    \
+    MOVQ    (idx*8)(DI), BX \ // BX = x[idx]
+    MOVQ    (idx*8)(SI), CX \ // CX = y[idx]
+    MOVQ    CX, DX          \ // DX = y[idx]
+    XORQ    BX, DX          \ // DX = y[idx] ^ x[idx]
+    ANDQ    AX, DX          \ // DX = (y[idx] ^ x[idx]) & mask
+    XORQ    DX, BX          \ // BX = (y[idx] ^ x[idx]) & mask) ^ x[idx] = x[idx] or y[idx]
+    XORQ    DX, CX          \ // CX = (y[idx] ^ x[idx]) & mask) ^ y[idx] = y[idx] or x[idx]
+    MOVQ    BX, (idx*8)(DI) \
+    MOVQ    CX, (idx*8)(SI)
+#endif
+    CSWAP_BLOCK(0)
+    CSWAP_BLOCK(1)
+    CSWAP_BLOCK(2)
+    CSWAP_BLOCK(3)
+    CSWAP_BLOCK(4)
+    CSWAP_BLOCK(5)
+    CSWAP_BLOCK(6)
+    CSWAP_BLOCK(7)
+    CSWAP_BLOCK(8)
+    CSWAP_BLOCK(9)
+#ifdef CSWAP_BLOCK
+#undef CSWAP_BLOCK
+#endif
+    RET
+
+TEXT ·addP610(SB),NOSPLIT,$0-24
+    MOVQ    z+0(FP), DX
+    MOVQ    x+8(FP), DI
+    MOVQ    y+16(FP), SI
+
+    // [R8-R15,AX,BX]: z = x + y
+    MOVQ    ( 0)(DI), R8;  ADDQ    ( 0)(SI), R8
+    MOVQ    ( 8)(DI), R9;  ADCQ    ( 8)(SI), R9
+    MOVQ    (16)(DI), R10; ADCQ    (16)(SI), R10
+    MOVQ    (24)(DI), R11; ADCQ    (24)(SI), R11
+    MOVQ    (32)(DI), R12; ADCQ    (32)(SI), R12
+    MOVQ    (40)(DI), R13; ADCQ    (40)(SI), R13
+    MOVQ    (48)(DI), R14; ADCQ    (48)(SI), R14
+    MOVQ    (56)(DI), R15; ADCQ    (56)(SI), R15
+    MOVQ    (64)(DI), AX;  ADCQ    (64)(SI), AX
+    MOVQ    (72)(DI), BX;  ADCQ    (72)(SI), BX
+
+    // z = z - p610x2
+    MOVQ    P610X2_0, DI;   SUBQ    DI, R8
+    MOVQ    P610X2_1, DI;   SBBQ    DI, R9
+    MOVQ    P610X2_2, DI;   SBBQ    DI, R10
+    MOVQ    P610X2_3, DI;   SBBQ    DI, R11
+    MOVQ    P610X2_4, DI;   SBBQ    DI, R12
+    MOVQ    P610X2_5, DI;   SBBQ    DI, R13
+    MOVQ    P610X2_6, DI;   SBBQ    DI, R14
+    MOVQ    P610X2_7, DI;   SBBQ    DI, R15
+    MOVQ    P610X2_8, DI;   SBBQ    DI, AX
+    MOVQ    P610X2_9, DI;   SBBQ    DI, BX
+
+    // mask
+    SBBQ    CX, CX
+
+    MOVQ    R8, ( 0)(DX)
+    MOVQ    R9, ( 8)(DX)
+    MOVQ    R10, (16)(DX)
+    MOVQ    R11, (24)(DX)
+    MOVQ    R12, (32)(DX)
+    MOVQ    R13, (40)(DX)
+    MOVQ    R14, (48)(DX)
+    MOVQ    R15, (56)(DX)
+    MOVQ    AX, (64)(DX)
+    MOVQ    BX, (72)(DX)
+
+    // if z<0 add p610x2 back
+    MOVQ    P610X2_0, R8;  ANDQ    CX, R8
+    MOVQ    P610X2_1, R9;  ANDQ    CX, R9
+    MOVQ    P610X2_2, R10; ANDQ    CX, R10
+    MOVQ    P610X2_3, R11; ANDQ    CX, R11
+    MOVQ    P610X2_4, R12; ANDQ    CX, R12
+    MOVQ    P610X2_5, R13; ANDQ    CX, R13
+    MOVQ    P610X2_6, R14; ANDQ    CX, R14
+    MOVQ    P610X2_7, R15; ANDQ    CX, R15
+    MOVQ    P610X2_8, AX;  ANDQ    CX, AX
+    MOVQ    P610X2_9, BX;  ANDQ    CX, BX
+    ADDQ    R8,  ( 0)(DX)
+    ADCQ    R9,  ( 8)(DX)
+    ADCQ    R10, (16)(DX)
+    ADCQ    R11, (24)(DX)
+    ADCQ    R12, (32)(DX)
+    ADCQ    R13, (40)(DX)
+    ADCQ    R14, (48)(DX)
+    ADCQ    R15, (56)(DX)
+    ADCQ    AX,  (64)(DX)
+    ADCQ    BX,  (72)(DX)
+    RET
+
+TEXT ·subP610(SB),NOSPLIT,$0-24
+    MOVQ    z+0(FP), DX
+    MOVQ    x+8(FP), DI
+    MOVQ    y+16(FP), SI
+
+    // [R8-R15,AX,BX]: z = x - y
+    MOVQ    ( 0)(DI), R8;  SUBQ    ( 0)(SI), R8
+    MOVQ    ( 8)(DI), R9;  SBBQ    ( 8)(SI), R9
+    MOVQ    (16)(DI), R10; SBBQ    (16)(SI), R10
+    MOVQ    (24)(DI), R11; SBBQ    (24)(SI), R11
+    MOVQ    (32)(DI), R12; SBBQ    (32)(SI), R12
+    MOVQ    (40)(DI), R13; SBBQ    (40)(SI), R13
+    MOVQ    (48)(DI), R14; SBBQ    (48)(SI), R14
+    MOVQ    (56)(DI), R15; SBBQ    (56)(SI), R15
+    MOVQ    (64)(DI), AX;  SBBQ    (64)(SI), AX
+    MOVQ    (72)(DI), BX;  SBBQ    (72)(SI), BX
+
+    // mask
+    SBBQ    CX, CX
+
+    MOVQ    R8, ( 0)(DX)
+    MOVQ    R9, ( 8)(DX)
+    MOVQ    R10, (16)(DX)
+    MOVQ    R11, (24)(DX)
+    MOVQ    R12, (32)(DX)
+    MOVQ    R13, (40)(DX)
+    MOVQ    R14, (48)(DX)
+    MOVQ    R15, (56)(DX)
+    MOVQ    AX, (64)(DX)
+    MOVQ    BX, (72)(DX)
+
+    // if z<0 add p610x2 back
+    MOVQ    P610X2_0, R8;  ANDQ    CX, R8
+    MOVQ    P610X2_1, R9;  ANDQ    CX, R9
+    MOVQ    P610X2_2, R10; ANDQ    CX, R10
+    MOVQ    P610X2_3, R11; ANDQ    CX, R11
+    MOVQ    P610X2_4, R12; ANDQ    CX, R12
+    MOVQ    P610X2_5, R13; ANDQ    CX, R13
+    MOVQ    P610X2_6, R14; ANDQ    CX, R14
+    MOVQ    P610X2_7, R15; ANDQ    CX, R15
+    MOVQ    P610X2_8, AX;  ANDQ    CX, AX
+    MOVQ    P610X2_9, BX;  ANDQ    CX, BX
+    ADDQ    R8,  ( 0)(DX)
+    ADCQ    R9,  ( 8)(DX)
+    ADCQ    R10, (16)(DX)
+    ADCQ    R11, (24)(DX)
+    ADCQ    R12, (32)(DX)
+    ADCQ    R13, (40)(DX)
+    ADCQ    R14, (48)(DX)
+    ADCQ    R15, (56)(DX)
+    ADCQ    AX,  (64)(DX)
+    ADCQ    BX,  (72)(DX)
+    RET
+
+TEXT ·adlP610(SB),NOSPLIT,$0-24
+    MOVQ    z+0(FP), DX
+    MOVQ    x+8(FP), DI
+    MOVQ    y+16(FP), SI
+
+    MOVQ    (  0)(DI), AX; ADDQ    (  0)(SI), AX; MOVQ    AX, (  0)(DX)
+    MOVQ    (  8)(DI), AX; ADCQ    (  8)(SI), AX; MOVQ    AX, (  8)(DX)
+    MOVQ    ( 16)(DI), AX; ADCQ    ( 16)(SI), AX; MOVQ    AX, ( 16)(DX)
+    MOVQ    ( 24)(DI), AX; ADCQ    ( 24)(SI), AX; MOVQ    AX, ( 24)(DX)
+    MOVQ    ( 32)(DI), AX; ADCQ    ( 32)(SI), AX; MOVQ    AX, ( 32)(DX)
+    MOVQ    ( 40)(DI), AX; ADCQ    ( 40)(SI), AX; MOVQ    AX, ( 40)(DX)
+    MOVQ    ( 48)(DI), AX; ADCQ    ( 48)(SI), AX; MOVQ    AX, ( 48)(DX)
+    MOVQ    ( 56)(DI), AX; ADCQ    ( 56)(SI), AX; MOVQ    AX, ( 56)(DX)
+    MOVQ    ( 64)(DI), AX; ADCQ    ( 64)(SI), AX; MOVQ    AX, ( 64)(DX)
+    MOVQ    ( 72)(DI), AX; ADCQ    ( 72)(SI), AX; MOVQ    AX, ( 72)(DX)
+    MOVQ    ( 80)(DI), AX; ADCQ    ( 80)(SI), AX; MOVQ    AX, ( 80)(DX)
+    MOVQ    ( 88)(DI), AX; ADCQ    ( 88)(SI), AX; MOVQ    AX, ( 88)(DX)
+    MOVQ    ( 96)(DI), AX; ADCQ    ( 96)(SI), AX; MOVQ    AX, ( 96)(DX)
+    MOVQ    (104)(DI), AX; ADCQ    (104)(SI), AX; MOVQ    AX, (104)(DX)
+    MOVQ    (112)(DI), AX; ADCQ    (112)(SI), AX; MOVQ    AX, (112)(DX)
+    MOVQ    (120)(DI), AX; ADCQ    (120)(SI), AX; MOVQ    AX, (120)(DX)
+    MOVQ    (128)(DI), AX; ADCQ    (128)(SI), AX; MOVQ    AX, (128)(DX)
+    MOVQ    (136)(DI), AX; ADCQ    (136)(SI), AX; MOVQ    AX, (136)(DX)
+    MOVQ    (144)(DI), AX; ADCQ    (144)(SI), AX; MOVQ    AX, (144)(DX)
+    MOVQ    (152)(DI), AX; ADCQ    (152)(SI), AX; MOVQ    AX, (152)(DX)
+    RET
+
+TEXT ·sulP610(SB),NOSPLIT,$0-24
+    MOVQ    z+0(FP), DX
+    MOVQ    x+8(FP), DI
+    MOVQ    y+16(FP), SI
+
+    MOVQ    (  0)(DI), AX; SUBQ    (  0)(SI), AX; MOVQ    AX, (  0)(DX)
+    MOVQ    (  8)(DI), AX; SBBQ    (  8)(SI), AX; MOVQ    AX, (  8)(DX)
+    MOVQ    ( 16)(DI), AX; SBBQ    ( 16)(SI), AX; MOVQ    AX, ( 16)(DX)
+    MOVQ    ( 24)(DI), AX; SBBQ    ( 24)(SI), AX; MOVQ    AX, ( 24)(DX)
+    MOVQ    ( 32)(DI), AX; SBBQ    ( 32)(SI), AX; MOVQ    AX, ( 32)(DX)
+    MOVQ    ( 40)(DI), AX; SBBQ    ( 40)(SI), AX; MOVQ    AX, ( 40)(DX)
+    MOVQ    ( 48)(DI), AX; SBBQ    ( 48)(SI), AX; MOVQ    AX, ( 48)(DX)
+    MOVQ    ( 56)(DI), AX; SBBQ    ( 56)(SI), AX; MOVQ    AX, ( 56)(DX)
+    MOVQ    ( 64)(DI), AX; SBBQ    ( 64)(SI), AX; MOVQ    AX, ( 64)(DX)
+    MOVQ    ( 72)(DI), AX; SBBQ    ( 72)(SI), AX; MOVQ    AX, ( 72)(DX)
+    MOVQ    ( 80)(DI), AX; SBBQ    ( 80)(SI), AX; MOVQ    AX, ( 80)(DX)
+    MOVQ    ( 88)(DI), AX; SBBQ    ( 88)(SI), AX; MOVQ    AX, ( 88)(DX)
+    MOVQ    ( 96)(DI), AX; SBBQ    ( 96)(SI), AX; MOVQ    AX, ( 96)(DX)
+    MOVQ    (104)(DI), AX; SBBQ    (104)(SI), AX; MOVQ    AX, (104)(DX)
+    MOVQ    (112)(DI), AX; SBBQ    (112)(SI), AX; MOVQ    AX, (112)(DX)
+    MOVQ    (120)(DI), AX; SBBQ    (120)(SI), AX; MOVQ    AX, (120)(DX)
+    MOVQ    (128)(DI), AX; SBBQ    (128)(SI), AX; MOVQ    AX, (128)(DX)
+    MOVQ    (136)(DI), AX; SBBQ    (136)(SI), AX; MOVQ    AX, (136)(DX)
+    MOVQ    (144)(DI), AX; SBBQ    (144)(SI), AX; MOVQ    AX, (144)(DX)
+    MOVQ    (152)(DI), AX; SBBQ    (152)(SI), AX; MOVQ    AX, (152)(DX)
+
+    // mask
+    SBBQ    CX, CX
+
+    // if z<0 add p610 to the upper half
+    MOVQ    P610_0, R8;  ANDQ    CX, R8
+    MOVQ    P610_1, R9;  ANDQ    CX, R9
+    MOVQ    P610_2, R10; ANDQ    CX, R10
+    MOVQ    P610_3, R11; ANDQ    CX, R11
+    MOVQ    P610_4, R12; ANDQ    CX, R12
+    MOVQ    P610_5, R13; ANDQ    CX, R13
+    MOVQ    P610_6, R14; ANDQ    CX, R14
+    MOVQ    P610_7, R15; ANDQ    CX, R15
+    MOVQ    P610_8, AX;  ANDQ    CX, AX
+    MOVQ    P610_9, BX;  ANDQ    CX, BX
+    ADDQ    R8,  ( 80)(DX)
+    ADCQ    R9,  ( 88)(DX)
+    ADCQ    R10, ( 96)(DX)
+    ADCQ    R11, (104)(DX)
+    ADCQ    R12, (112)(DX)
+    ADCQ    R13, (120)(DX)
+    ADCQ    R14, (128)(DX)
+    ADCQ    R15, (136)(DX)
+    ADCQ    AX,  (144)(DX)
+    ADCQ    BX,  (152)(DX)
+    RET
+
+TEXT ·modP610(SB),NOSPLIT,$0-8
+    MOVQ    x+0(FP), DI
+
+    // Set x <- x - p
+    MOVQ    P610_0, AX;    SUBQ    AX, ( 0)(DI)
+    MOVQ    P610_1, AX;    SBBQ    AX, ( 8)(DI)
+    MOVQ    P610_2, AX;    SBBQ    AX, (16)(DI)
+    MOVQ    P610_3, AX;    SBBQ    AX, (24)(DI)
+    MOVQ    P610_4, AX;    SBBQ    AX, (32)(DI)
+    MOVQ    P610_5, AX;    SBBQ    AX, (40)(DI)
+    MOVQ    P610_6, AX;    SBBQ    AX, (48)(DI)
+    MOVQ    P610_7, AX;    SBBQ    AX, (56)(DI)
+    MOVQ    P610_8, AX;    SBBQ    AX, (64)(DI)
+    MOVQ    P610_9, AX;    SBBQ    AX, (72)(DI)
+
+    // mask
+    SBBQ    CX, CX
+
+    // Conditionally add p to x if x-p < 0
+    MOVQ    P610_0, R8;  ANDQ    CX, R8
+    MOVQ    P610_1, R9;  ANDQ    CX, R9
+    MOVQ    P610_2, R10; ANDQ    CX, R10
+    MOVQ    P610_3, R11; ANDQ    CX, R11
+    MOVQ    P610_4, R12; ANDQ    CX, R12
+    MOVQ    P610_5, R13; ANDQ    CX, R13
+    MOVQ    P610_6, R14; ANDQ    CX, R14
+    MOVQ    P610_7, R15; ANDQ    CX, R15
+    MOVQ    P610_8, AX;  ANDQ    CX, AX
+    MOVQ    P610_9, BX;  ANDQ    CX, BX
+    ADDQ    R8,  ( 0)(DI)
+    ADCQ    R9,  ( 8)(DI)
+    ADCQ    R10, (16)(DI)
+    ADCQ    R11, (24)(DI)
+    ADCQ    R12, (32)(DI)
+    ADCQ    R13, (40)(DI)
+    ADCQ    R14, (48)(DI)
+    ADCQ    R15, (56)(DI)
+    ADCQ    AX,  (64)(DI)
+    ADCQ    BX,  (72)(DI)
+    RET
+
+// 610-bit multiplication, operand scanning with column-wise
+// accumulation (Comba). Uses MULQ only.
+TEXT ·mulP610(SB),NOSPLIT,$0-24
+    MOVQ    z+0(FP), CX
+    MOVQ    x+8(FP), DI
+    MOVQ    y+16(FP), SI
+
+    XORQ    R8, R8
+    XORQ    R9, R9
+    XORQ    R10, R10
+
+    // z[0]
+    MULACC(( 0)(DI), ( 0)(SI), R8, R9, R10)
+    MOVQ    R8, (  0)(CX)
+    XORQ    R8, R8
+
+    // z[1]
+    MULACC(( 0)(DI), ( 8)(SI), R9, R10, R8)
+    MULACC(( 8)(DI), ( 0)(SI), R9, R10, R8)
+    MOVQ    R9, (  8)(CX)
+    XORQ    R9, R9
+
+    // z[2]
+    MULACC(( 0)(DI), (16)(SI), R10, R8, R9)
+    MULACC(( 8)(DI), ( 8)(SI), R10, R8, R9)
+    MULACC((16)(DI), ( 0)(SI), R10, R8, R9)
+    MOVQ    R10, ( 16)(CX)
+    XORQ    R10, R10
+
+    // z[3]
+    MULACC(( 0)(DI), (24)(SI), R8, R9, R10)
+    MULACC(( 8)(DI), (16)(SI), R8, R9, R10)
+    MULACC((16)(DI), ( 8)(SI), R8, R9, R10)
+    MULACC((24)(DI), ( 0)(SI), R8, R9, R10)
+    MOVQ    R8, ( 24)(CX)
+    XORQ    R8, R8
+
+    // z[4]
+    MULACC(( 0)(DI), (32)(SI), R9, R10, R8)
+    MULACC(( 8)(DI), (24)(SI), R9, R10, R8)
+    MULACC((16)(DI), (16)(SI), R9, R10, R8)
+    MULACC((24)(DI), ( 8)(SI), R9, R10, R8)
+    MULACC((32)(DI), ( 0)(SI), R9, R10, R8)
+    MOVQ    R9, ( 32)(CX)
+    XORQ    R9, R9
+
+    // z[5]
+    MULACC(( 0)(DI), (40)(SI), R10, R8, R9)
+    MULACC(( 8)(DI), (32)(SI), R10, R8, R9)
+    MULACC((16)(DI), (24)(SI), R10, R8, R9)
+    MULACC((24)(DI), (16)(SI), R10, R8, R9)
+    MULACC((32)(DI), ( 8)(SI), R10, R8, R9)
+    MULACC((40)(DI), ( 0)(SI), R10, R8, R9)
+    MOVQ    R10, ( 40)(CX)
+    XORQ    R10, R10
+
+    // z[6]
+    MULACC(( 0)(DI), (48)(SI), R8, R9, R10)
+    MULACC(( 8)(DI), (40)(SI), R8, R9, R10)
+    MULACC((16)(DI), (32)(SI), R8, R9, R10)
+    MULACC((24)(DI), (24)(SI), R8, R9, R10)
+    MULACC((32)(DI), (16)(SI), R8, R9, R10)
+    MULACC((40)(DI), ( 8)(SI), R8, R9, R10)
+    MULACC((48)(DI), ( 0)(SI), R8, R9, R10)
+    MOVQ    R8, ( 48)(CX)
+    XORQ    R8, R8
+
+    // z[7]
+    MULACC(( 0)(DI), (56)(SI), R9, R10, R8)
+    MULACC(( 8)(DI), (48)(SI), R9, R10, R8)
+    MULACC((16)(DI), (40)(SI), R9, R10, R8)
+    MULACC((24)(DI), (32)(SI), R9, R10, R8)
+    MULACC((32)(DI), (24)(SI), R9, R10, R8)
+    MULACC((40)(DI), (16)(SI), R9, R10, R8)
+    MULACC((48)(DI), ( 8)(SI), R9, R10, R8)
+    MULACC((56)(DI), ( 0)(SI), R9, R10, R8)
+    MOVQ    R9, ( 56)(CX)
+    XORQ    R9, R9
+
+    // z[8]
+    MULACC(( 0)(DI), (64)(SI), R10, R8, R9)
+    MULACC(( 8)(DI), (56)(SI), R10, R8, R9)
+    MULACC((16)(DI), (48)(SI), R10, R8, R9)
+    MULACC((24)(DI), (40)(SI), R10, R8, R9)
+    MULACC((32)(DI), (32)(SI), R10, R8, R9)
+    MULACC((40)(DI), (24)(SI), R10, R8, R9)
+    MULACC((48)(DI), (16)(SI), R10, R8, R9)
+    MULACC((56)(DI), ( 8)(SI), R10, R8, R9)
+    MULACC((64)(DI), ( 0)(SI), R10, R8, R9)
+    MOVQ    R10, ( 64)(CX)
+    XORQ    R10, R10
+
+    // z[9]
+    MULACC(( 0)(DI), (72)(SI), R8, R9, R10)
+    MULACC(( 8)(DI), (64)(SI), R8, R9, R10)
+    MULACC((16)(DI), (56)(SI), R8, R9, R10)
+    MULACC((24)(DI), (48)(SI), R8, R9, R10)
+    MULACC((32)(DI), (40)(SI), R8, R9, R10)
+    MULACC((40)(DI), (32)(SI), R8, R9, R10)
+    MULACC((48)(DI), (24)(SI), R8, R9, R10)
+    MULACC((56)(DI), (16)(SI), R8, R9, R10)
+    MULACC((64)(DI), ( 8)(SI), R8, R9, R10)
+    MULACC((72)(DI), ( 0)(SI), R8, R9, R10)
+    MOVQ    R8, ( 72)(CX)
+    XORQ    R8, R8
+
+    // z[10]
+    MULACC(( 8)(DI), (72)(SI), R9, R10, R8)
+    MULACC((16)(DI), (64)(SI), R9, R10, R8)
+    MULACC((24)(DI), (56)(SI), R9, R10, R8)
+    MULACC((32)(DI), (48)(SI), R9, R10, R8)
+    MULACC((40)(DI), (40)(SI), R9, R10, R8)
+    MULACC((48)(DI), (32)(SI), R9, R10, R8)
+    MULACC((56)(DI), (24)(SI), R9, R10, R8)
+    MULACC((64)(DI), (16)(SI), R9, R10, R8)
+    MULACC((72)(DI), ( 8)(SI), R9, R10, R8)
+    MOVQ    R9, ( 80)(CX)
+    XORQ    R9, R9
+
+    // z[11]
+    MULACC((16)(DI), (72)(SI), R10, R8, R9)
+    MULACC((24)(DI), (64)(SI), R10, R8, R9)
+    MULACC((32)(DI), (56)(SI), R10, R8, R9)
+    MULACC((40)(DI), (48)(SI), R10, R8, R9)
+    MULACC((48)(DI), (40)(SI), R10, R8, R9)
+    MULACC((56)(DI), (32)(SI), R10, R8, R9)
+    MULACC((64)(DI), (24)(SI), R10, R8, R9)
+    MULACC((72)(DI), (16)(SI), R10, R8, R9)
+    MOVQ    R10, ( 88)(CX)
+    XORQ    R10, R10
+
+    // z[12]
+    MULACC((24)(DI), (72)(SI), R8, R9, R10)
+    MULACC((32)(DI), (64)(SI), R8, R9, R10)
+    MULACC((40)(DI), (56)(SI), R8, R9, R10)
+    MULACC((48)(DI), (48)(SI), R8, R9, R10)
+    MULACC((56)(DI), (40)(SI), R8, R9, R10)
+    MULACC((64)(DI), (32)(SI), R8, R9, R10)
+    MULACC((72)(DI), (24)(SI), R8, R9, R10)
+    MOVQ    R8, ( 96)(CX)
+    XORQ    R8, R8
+
+    // z[13]
+    MULACC((32)(DI), (72)(SI), R9, R10, R8)
+    MULACC((40)(DI), (64)(SI), R9, R10, R8)
+    MULACC((48)(DI), (56)(SI), R9, R10, R8)
+    MULACC((56)(DI), (48)(SI), R9, R10, R8)
+    MULACC((64)(DI), (40)(SI), R9, R10, R8)
+    MULACC((72)(DI), (32)(SI), R9, R10, R8)
+    MOVQ    R9, (104)(CX)
+    XORQ    R9, R9
+
+    // z[14]
+    MULACC((40)(DI), (72)(SI), R10, R8, R9)
+    MULACC((48)(DI), (64)(SI), R10, R8, R9)
+    MULACC((56)(DI), (56)(SI), R10, R8, R9)
+    MULACC((64)(DI), (48)(SI), R10, R8, R9)
+    MULACC((72)(DI), (40)(SI), R10, R8, R9)
+    MOVQ    R10, (112)(CX)
+    XORQ    R10, R10
+
+    // z[15]
+    MULACC((48)(DI), (72)(SI), R8, R9, R10)
+    MULACC((56)(DI), (64)(SI), R8, R9, R10)
+    MULACC((64)(DI), (56)(SI), R8, R9, R10)
+    MULACC((72)(DI), (48)(SI), R8, R9, R10)
+    MOVQ    R8, (120)(CX)
+    XORQ    R8, R8
+
+    // z[16]
+    MULACC((56)(DI), (72)(SI), R9, R10, R8)
+    MULACC((64)(DI), (64)(SI), R9, R10, R8)
+    MULACC((72)(DI), (56)(SI), R9, R10, R8)
+    MOVQ    R9, (128)(CX)
+    XORQ    R9, R9
+
+    // z[17]
+    MULACC((64)(DI), (72)(SI), R10, R8, R9)
+    MULACC((72)(DI), (64)(SI), R10, R8, R9)
+    MOVQ    R10, (136)(CX)
+    XORQ    R10, R10
+
+    // z[18]
+    MULACC((72)(DI), (72)(SI), R8, R9, R10)
+    MOVQ    R8, (144)(CX)
+    MOVQ    R9, (152)(CX)
+    RET
+
+// Montgomery reduction z = x * R^-1 (mod 2*p). Takes advantage of
+// P610p1Zeros least significant words of p610+1 being equal to zero.
+// Works column-wise, same as mulP610.
+TEXT ·rdcP610(SB),NOSPLIT,$0-16
+    MOVQ    z+0(FP), SI
+    MOVQ    x+8(FP), DI
+
+    XORQ    R8, R8
+    XORQ    R9, R9
+    XORQ    R10, R10
+
+    // column 0
+    ADDQ    (  0)(DI), R8
+    ADCQ    $0, R9
+    ADCQ    $0, R10
+    MOVQ    R8, ( 0)(SI)
+    XORQ    R8, R8
+
+    // column 1
+    ADDQ    (  8)(DI), R9
+    ADCQ    $0, R10
+    ADCQ    $0, R8
+    MOVQ    R9, ( 8)(SI)
+    XORQ    R9, R9
+
+    // column 2
+    ADDQ    ( 16)(DI), R10
+    ADCQ    $0, R8
+    ADCQ    $0, R9
+    MOVQ    R10, (16)(SI)
+    XORQ    R10, R10
+
+    // column 3
+    ADDQ    ( 24)(DI), R8
+    ADCQ    $0, R9
+    ADCQ    $0, R10
+    MOVQ    R8, (24)(SI)
+    XORQ    R8, R8
+
+    // column 4
+    MULACC(P610P1_4, ( 0)(SI), R9, R10, R8)
+    ADDQ    ( 32)(DI), R9
+    ADCQ    $0, R10
+    ADCQ    $0, R8
+    MOVQ    R9, (32)(SI)
+    XORQ    R9, R9
+
+    // column 5
+    MULACC(P610P1_5, ( 0)(SI), R10, R8, R9)
+    MULACC(P610P1_4, ( 8)(SI), R10, R8, R9)
+    ADDQ    ( 40)(DI), R10
+    ADCQ    $0, R8
+    ADCQ    $0, R9
+    MOVQ    R10, (40)(SI)
+    XORQ    R10, R10
+
+    // column 6
+    MULACC(P610P1_6, ( 0)(SI), R8, R9, R10)
+    MULACC(P610P1_5, ( 8)(SI), R8, R9, R10)
+    MULACC(P610P1_4, (16)(SI), R8, R9, R10)
+    ADDQ    ( 48)(DI), R8
+    ADCQ    $0, R9
+    ADCQ    $0, R10
+    MOVQ    R8, (48)(SI)
+    XORQ    R8, R8
+
+    // column 7
+    MULACC(P610P1_7, ( 0)(SI), R9, R10, R8)
+    MULACC(P610P1_6, ( 8)(SI), R9, R10, R8)
+    MULACC(P610P1_5, (16)(SI), R9, R10, R8)
+    MULACC(P610P1_4, (24)(SI), R9, R10, R8)
+    ADDQ    ( 56)(DI), R9
+    ADCQ    $0, R10
+    ADCQ    $0, R8
+    MOVQ    R9, (56)(SI)
+    XORQ    R9, R9
+
+    // column 8
+    MULACC(P610P1_8, ( 0)(SI), R10, R8, R9)
+    MULACC(P610P1_7, ( 8)(SI), R10, R8, R9)
+    MULACC(P610P1_6, (16)(SI), R10, R8, R9)
+    MULACC(P610P1_5, (24)(SI), R10, R8, R9)
+    MULACC(P610P1_4, (32)(SI), R10, R8, R9)
+    ADDQ    ( 64)(DI), R10
+    ADCQ    $0, R8
+    ADCQ    $0, R9
+    MOVQ    R10, (64)(SI)
+    XORQ    R10, R10
+
+    // column 9
+    MULACC(P610P1_9, ( 0)(SI), R8, R9, R10)
+    MULACC(P610P1_8, ( 8)(SI), R8, R9, R10)
+    MULACC(P610P1_7, (16)(SI), R8, R9, R10)
+    MULACC(P610P1_6, (24)(SI), R8, R9, R10)
+    MULACC(P610P1_5, (32)(SI), R8, R9, R10)
+    MULACC(P610P1_4, (40)(SI), R8, R9, R10)
+    ADDQ    ( 72)(DI), R8
+    ADCQ    $0, R9
+    ADCQ    $0, R10
+    MOVQ    R8, (72)(SI)
+    XORQ    R8, R8
+
+    // column 10
+    MULACC(P610P1_9, ( 8)(SI), R9, R10, R8)
+    MULACC(P610P1_8, (16)(SI), R9, R10, R8)
+    MULACC(P610P1_7, (24)(SI), R9, R10, R8)
+    MULACC(P610P1_6, (32)(SI), R9, R10, R8)
+    MULACC(P610P1_5, (40)(SI), R9, R10, R8)
+    MULACC(P610P1_4, (48)(SI), R9, R10, R8)
+    ADDQ    ( 80)(DI), R9
+    ADCQ    $0, R10
+    ADCQ    $0, R8
+    MOVQ    R9, ( 0)(SI)
+    XORQ    R9, R9
+
+    // column 11
+    MULACC(P610P1_9, (16)(SI), R10, R8, R9)
+    MULACC(P610P1_8, (24)(SI), R10, R8, R9)
+    MULACC(P610P1_7, (32)(SI), R10, R8, R9)
+    MULACC(P610P1_6, (40)(SI), R10, R8, R9)
+    MULACC(P610P1_5, (48)(SI), R10, R8, R9)
+    MULACC(P610P1_4, (56)(SI), R10, R8, R9)
+    ADDQ    ( 88)(DI), R10
+    ADCQ    $0, R8
+    ADCQ    $0, R9
+    MOVQ    R10, ( 8)(SI)
+    XORQ    R10, R10
+
+    // column 12
+    MULACC(P610P1_9, (24)(SI), R8, R9, R10)
+    MULACC(P610P1_8, (32)(SI), R8, R9, R10)
+    MULACC(P610P1_7, (40)(SI), R8, R9, R10)
+    MULACC(P610P1_6, (48)(SI), R8, R9, R10)
+    MULACC(P610P1_5, (56)(SI), R8, R9, R10)
+    MULACC(P610P1_4, (64)(SI), R8, R9, R10)
+    ADDQ    ( 96)(DI), R8
+    ADCQ    $0, R9
+    ADCQ    $0, R10
+    MOVQ    R8, (16)(SI)
+    XORQ    R8, R8
+
+    // column 13
+    MULACC(P610P1_9, (32)(SI), R9, R10, R8)
+    MULACC(P610P1_8, (40)(SI), R9, R10, R8)
+    MULACC(P610P1_7, (48)(SI), R9, R10, R8)
+    MULACC(P610P1_6, (56)(SI), R9, R10, R8)
+    MULACC(P610P1_5, (64)(SI), R9, R10, R8)
+    MULACC(P610P1_4, (72)(SI), R9, R10, R8)
+    ADDQ    (104)(DI), R9
+    ADCQ    $0, R10
+    ADCQ    $0, R8
+    MOVQ    R9, (24)(SI)
+    XORQ    R9, R9
+
+    // column 14
+    MULACC(P610P1_9, (40)(SI), R10, R8, R9)
+    MULACC(P610P1_8, (48)(SI), R10, R8, R9)
+    MULACC(P610P1_7, (56)(SI), R10, R8, R9)
+    MULACC(P610P1_6, (64)(SI), R10, R8, R9)
+    MULACC(P610P1_5, (72)(SI), R10, R8, R9)
+    ADDQ    (112)(DI), R10
+    ADCQ    $0, R8
+    ADCQ    $0, R9
+    MOVQ    R10, (32)(SI)
+    XORQ    R10, R10
+
+    // column 15
+    MULACC(P610P1_9, (48)(SI), R8, R9, R10)
+    MULACC(P610P1_8, (56)(SI), R8, R9, R10)
+    MULACC(P610P1_7, (64)(SI), R8, R9, R10)
+    MULACC(P610P1_6, (72)(SI), R8, R9, R10)
+    ADDQ    (120)(DI), R8
+    ADCQ    $0, R9
+    ADCQ    $0, R10
+    MOVQ    R8, (40)(SI)
+    XORQ    R8, R8
+
+    // column 16
+    MULACC(P610P1_9, (56)(SI), R9, R10, R8)
+    MULACC(P610P1_8, (64)(SI), R9, R10, R8)
+    MULACC(P610P1_7, (72)(SI), R9, R10, R8)
+    ADDQ    (128)(DI), R9
+    ADCQ    $0, R10
+    ADCQ    $0, R8
+    MOVQ    R9, (48)(SI)
+    XORQ    R9, R9
+
+    // column 17
+    MULACC(P610P1_9, (64)(SI), R10, R8, R9)
+    MULACC(P610P1_8, (72)(SI), R10, R8, R9)
+    ADDQ    (136)(DI), R10
+    ADCQ    $0, R8
+    ADCQ    $0, R9
+    MOVQ    R10, (56)(SI)
+    XORQ    R10, R10
+
+    // column 18
+    MULACC(P610P1_9, (72)(SI), R8, R9, R10)
+    ADDQ    (144)(DI), R8
+    ADCQ    $0, R9
+    ADCQ    $0, R10
+    MOVQ    R8, (64)(SI)
+    XORQ    R8, R8
+
+    ADDQ    (152)(DI), R9
+    MOVQ    R9, (72)(SI)
+    RET
@@ -0,0 +1,63 @@
+// +build amd64,!noasm
+
+package p610
+
+import (
+	"math/big"
+	"testing"
+	"testing/quick"
+
+	"github.com/henrydcase/nobs/dh/sidh/common"
+)
+
+// Only implementation based on MUL instruction is available for P610, hence
+// mul and redc are tested against math/big.
+
+func toBigInt(x []uint64) *big.Int {
+	var r big.Int
+	for i := len(x) - 1; i >= 0; i-- {
+		r.Lsh(&r, 64)
+		r.Or(&r, new(big.Int).SetUint64(x[i]))
+	}
+	return &r
+}
+
+// Ensures correctness of implementation of mul operation
+func TestMulAgainstBigInt(t *testing.T) {
+	doMulTest := func(multiplier, multiplicant common.Fp) bool {
+		var res common.FpX2
+		mulP610(&res, &multiplier, &multiplicant)
+		exp := new(big.Int).Mul(toBigInt(multiplier[:FpWords]), toBigInt(multiplicant[:FpWords]))
+		return toBigInt(res[:2*FpWords]).Cmp(exp) == 0
+	}
+
+	if err := quick.Check(doMulTest, quickCheckConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+// Ensures correctness of Montgomery reduction implementation. Input is
+// a product of two elements in [0, 2*p), output must be congruent to
+// aRR*R^-1 mod p and in [0, 2*p).
+func TestRedcAgainstBigInt(t *testing.T) {
+	p := toBigInt(P610[:FpWords])
+	p2 := toBigInt(P610x2[:FpWords])
+	rInv := new(big.Int).Lsh(big.NewInt(1), 64*FpWords)
+	rInv.ModInverse(rInv, p)
+
+	doRedcTest := func(x, y testParams) bool {
+		var aRR common.FpX2
+		var res common.Fp
+		mulP610(&aRR, &x.ExtElem.A, &y.ExtElem.B)
+		exp := new(big.Int).Mul(toBigInt(aRR[:2*FpWords]), rInv)
+		exp.Mod(exp, p)
+
+		rdcP610(&res, &aRR)
+		r := toBigInt(res[:FpWords])
+		return r.Cmp(p2) < 0 && new(big.Int).Mod(r, p).Cmp(exp) == 0
+	}
+
+	if err := quick.Check(doRedcTest, quickCheckConfig); err != nil {
+		t.Error(err)
+	}
+}
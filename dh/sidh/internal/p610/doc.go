@@ -0,0 +1,2 @@
+// Package p610 provides implementation of field arithmetic used in SIDH and SIKE.
+package p610
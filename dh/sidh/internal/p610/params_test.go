@@ -0,0 +1,75 @@
+package p610
+
+// Contains values used by tests
+import (
+	"testing/quick"
+
+	. "github.com/henrydcase/nobs/dh/sidh/common"
+)
+
+// Values computed using Sage
+var (
+	// j = 2289115233638372101530938791865400079286029480689975650309538155027705766073089845601688903112627863998535796031069774838548301012232793599287863000674056727028856080421293068716131375*i + 302582455208570183597486216960969502173519140037077553189032366267328347796445393026243689767264140036865760193912808176747017555660789001599450394227068494197443854120595159504205008
+	expectedJ = Fp2{
+		A: Fp{0xBDF6C596B5B5AB95, 0x25EA62D1D8DB67F6, 0x4589BD3A4DBD1A5C, 0xDB0245F72B294D66, 0xDFE527F83D0D9F3F, 0x63327CB8A5443638, 0x41593CB5F7E6C763, 0x20D2393E4B949C81, 0x9AF920B3F2C490E2, 0xB8667037},
+		B: Fp{0x43F5006784C9F8D7, 0x6EEA7D0278B9C2F3, 0xA2E8664057670393, 0x8DF5078CAC80C329, 0xBD6A121D4D00B10B, 0x7E9860ECC3D1407F, 0x1C4E9829EE928824, 0x41B601702CCC0C20, 0x92630CD2693C7BC0, 0x1454346C2}}
+
+	// A = 1317993198358872797765089116830327780319058645754510107123685262888478108803893875846486458664793635871818354823307861820430527379211777909936314623658346765026075603701411118431055596*i + 897237650900107113779478373145543271747526467657948362764787475632317630359595865413075856124788520404638684451327606187361599959922217414250993358769149287943722424067157139130407266
+	curveA = Fp2{
+		A: Fp{0xDF53D34BB2BD6702, 0x7373DC2E1EE6BC21, 0xF74E2631863C8F1A, 0x8325484F6DCD24AC, 0xDCD52DB09C7F659A, 0x62783240D4896337, 0xD58C19A2EB1902AA, 0xC4B8001E35AE4D8, 0xEC89BA1777BA97BC, 0x2604058D8},
+		B: Fp{0x93CE998916003E4, 0x81D72FFEB437D43, 0x131E9B271EE46D9E, 0x82F84E11BAD9817F, 0x85D0F1F201E1F643, 0xC9E5865C66F45144, 0x74C96625E321662A, 0xA1A9001574B03056, 0x26B4A997D3132B22, 0x1B61C9288}}
+
+	// C = 82221706465990463640336211512193760202393079847651106150661548854907960206523397992834879652326064972519834603202020329249850712546935045560146202658791012396249564311615774576026177*i + 57383846484335245584715589556747304170311846715350762823532629624303542709744253435169942498848465872074759533678631281506929063654198006721011782361309649643605005917164955133667054
+	curveC = Fp2{
+		A: Fp{0xF05CA4B2A9A8DCFE, 0xD08367E23901C667, 0x469260F127F5E8B, 0x52AAA786D1B8425D, 0xE3824198451F22C9, 0x508F088559C891A5, 0x28C9CE3015DDA025, 0x8FECBF834740924D, 0xC0EE846BE7188080, 0x241D189BB},
+		B: Fp{0x714D3EBEE7394801, 0x73DF169284AF4639, 0xB08DC88AC0AA8281, 0x1AC21F99F4D8A42C, 0x264A11140E26F6D3, 0x3FDFBA013921EBA0, 0xC3235AAC2EC335DF, 0x6CF11F70E7293F5F, 0x82BEB1024ED5E0CA, 0x25111D6B0}}
+
+	// x(P) = 2070537269449343960951549027240987767285501997750742938265168872490366624941956282684762927153297723956722950205600745023889744400357826405438954681728340124911445765388708149475791775*i + 53237260191459422091996727452705207843849653594123993128163794566523035567130025571767567866333656376246767997379227476075493100661441248318337057678057494508060963857979880570474305
+	affineXP = Fp2{
+		A: Fp{0x9B8EB068856C0F70, 0xF75605DE2F169AA3, 0x74B37EC167341627, 0x2B10CD1610D9DD3A, 0x91838E6DC4011970, 0x865F87A0FD2B700, 0xE504F34990AD1F38, 0x5F7A1C28C98E240C, 0xDA69846F73D73DE9, 0xA9A9A82E},
+		B: Fp{0xFEF9BFCBA2376369, 0xD67CE0A4834132E6, 0x74C4CAA78C18ED78, 0xC1E3DC27F0F4B249, 0xE4D46457986D19F4, 0xA5C0675772D5938F, 0x45E865A58CFED5D8, 0x65EDA4C750C4BA47, 0x44638D6F7902F232, 0x18B6C855B}}
+
+	// x([2]P) = 1092608832088765942094018572137875453469046912249760469046480619804864965615103526683349768626190946746645752689170765983526406535130229469594700645696576577722763091538481912026268874*i + 141695148646910556698002591481191790740127496729128709372349775587058836665035502805142839756013175098025805041659503254405337046146674891379294510730564323316771856372636766690148681
+	affineXP2 = Fp2{
+		A: Fp{0xC13FE567FA8F4CBE, 0xB68892978A1492C2, 0x8D4D66275B7E6457, 0x77A3EBBFD723244, 0x26608CA5D602506A, 0xFF1B2237B770E0CC, 0xFC67F1F652FECF72, 0xB88908B5729447FB, 0x3C4A8280C7028BE3, 0x221730FA2},
+		B: Fp{0x47202E3E35BFB972, 0xD98DBC0E2EC99563, 0xC892B2930F42A1C1, 0x5127EB8BAF0E4A48, 0x170C6A31AAFC09E4, 0x687FB7EC314A485D, 0x4546C3C67062C10A, 0x27B392458909BFDF, 0xCBF650AB2F3A5C82, 0x1D03AAD4}}
+
+	// x([4]P) = 2002343792043209038334469888315230392474229050534348032942767110755468066127760077598224728421461320218593495306814033364053389005543839363017766764425371269627926523874389487224703194*i + 2072864394913728722732597357232169493119949244521083624814340409054859203302814239372770882949971697664948224406891013820663742076853621440969530841888460700663639563657265061565383746
+	affineXP4 = Fp2{
+		A: Fp{0x7689DDCAB478BACB, 0x4A960C65CFF7CD25, 0x2196F2D8192F0A1C, 0x5F347FBA958277D6, 0x739666FFF413909F, 0xEF07C3CB92266A3, 0x6D8CF844E880FBC2, 0x8315DD9D1C948AD2, 0x3CAEE67FF99DD116, 0x676CBB8A},
+		B: Fp{0xC01ADBF7729CBD08, 0x78DB9FAFEDB653E7, 0x642647C96966D40, 0x9877FAEB2E6D2262, 0xB8729B5B524F179D, 0xCF6654D2916A8E48, 0xD4C284679497CEE9, 0xE94CB3411C4528B, 0x47B8B629A248543F, 0x21CB83F6}}
+
+	// x([9]P) = 91979977247267546950346186842966324371391411609949476200316213547196075130459966974310088612492044402298391374376472140030014960947643075794211211148351043959654994031469243303509834*i + 143398915055817344486091911197049127005790925531125288230896141942405756899699897025118147533584092786558087719157914684076337420004738683560911640911614863970827418260589669667738082
+	affineXP9 = Fp2{
+		A: Fp{0xE641D7FB91BECAF9, 0x258948300BAF8202, 0x7543BCE76B9B9577, 0x647805B4B6987532, 0xDCC1811B2907D7C5, 0x72E1FBE30460B20A, 0x17BC592CC10880E7, 0x6A87B3BB1922B126, 0xFD5EA26A9C4476A7, 0x276C02046},
+		B: Fp{0x1639D9672A09099, 0x849D56EAEC2D8251, 0x9514AD23297A6EA5, 0xCBBCE7511B636EC1, 0x54087587B04389E4, 0x52AE149570127BEC, 0x338EEA1F248E66D2, 0x1157666ECB9BD411, 0xEBBE80017A7A6DD, 0x14C5D4D34}}
+	// Inputs for testing 3-point-ladder
+	threePointLadderInputs = []ProjectivePoint{
+		// x(P)
+		{
+			X: Fp2{
+				A: Fp{0x2F8C354CA6D6D43C, 0x43D2E309985AB76E, 0xD67C2B816857F0BE, 0x99682930D6C0E718, 0xBBA336766EECC105, 0x3FB32EE6E44DF980, 0x4B182C80ADA7AAB6, 0x91DED16146D9EC36, 0x9396C5C5EE042337, 0x2633E2AA7},
+				B: Fp{0xC0714932625C7314, 0xA52E80F38487920F, 0xF55656222C26A6C8, 0x62CCA0AC83645421, 0x8DAD139F3BE2804F, 0x8AF5387865ACD70D, 0x1282B162E9050F3A, 0xED1521EEC4DF525F, 0x631DA8F1F1208AC0, 0x1A72B390E}},
+			Z: params.OneFp2,
+		},
+		// x(Q)
+		{
+			X: Fp2{
+				A: Fp{0x9558B5611E99E962, 0xC74295899284F97B, 0x22D773C0E14FAF13, 0xD66BCF1BC0715B86, 0x58D19CF85ABC1A82, 0x2D8BB44D7516F5F6, 0xB4F014D1FC3AD26, 0x3C5125093D522D67, 0x5A1EC49DBDCA6613, 0x12BFC17D},
+				B: Fp{0x3B07AC68BEDA781D, 0x663E13D033A39376, 0x970A263E87B2FC04, 0xF6DAE1F9FEDBCD68, 0xF998ABF58438DE06, 0xDFDE4EB52162A1F3, 0xDA09C5DEF5A52BB8, 0x1898C0493B6F339C, 0xDD7FACB7CDAD20F8, 0x348C59BB}},
+			Z: params.OneFp2,
+		},
+		// x(P-Q)
+		{
+			X: Fp2{
+				A: Fp{0x5A6068C7443AD381, 0xDDF9FC953CF18881, 0xB797C4528BBD7906, 0xFEEE4212B84F991D, 0x9C025C609C895A96, 0x9385CEDB20497DB6, 0xD887564927724C0D, 0x8A68737B457962CF, 0xFE672E16203DB30E, 0x19410CBE6},
+				B: Fp{0xF276A28596E19F45, 0x7E1244BD84778034, 0xF978739F9F4E420B, 0x8A3F757CD1FA0379, 0xD26C9696BE84A8BE, 0x2B7585A80A8A9410, 0xD726181D1271385F, 0xF98FDA97A647931C, 0x401B51A229E453F3, 0x144EB9135}},
+			Z: params.OneFp2,
+		},
+	}
+	scalar3Pt = [...]uint8{0x65, 0x0b, 0x41, 0xa1, 0x4e, 0x10, 0xed, 0x22, 0xb3, 0xfc, 0xe2, 0xb0, 0xe8, 0x63, 0x32, 0xe0, 0x6c, 0x49, 0xd1, 0xeb, 0xba, 0xb1, 0x99, 0x1a, 0x45, 0x48, 0xbe, 0x5f, 0x5b, 0x4e, 0x1e, 0xa2, 0x9a, 0x78, 0xec, 0xb1, 0xf6, 0xa5, 0xd8}
+)
+
+var quickCheckConfig = &quick.Config{
+	MaxCount: (1 << 15),
+}
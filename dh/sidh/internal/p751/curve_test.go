@@ -5,6 +5,7 @@ package p751
 
 import (
 	"bytes"
+	"math/big"
 	"testing"
 
 	. "github.com/henrydcase/nobs/dh/sidh/common"
@@ -98,3 +99,66 @@ func BenchmarkThreePointLadder(b *testing.B) {
 		ScalarMul3Pt(&curve, &threePointLadderInputs[0], &threePointLadderInputs[1], &threePointLadderInputs[2], uint(len(scalar3Pt)*8), scalar3Pt[:])
 	}
 }
+
+func TestPointArithmetic(t *testing.T) {
+	var P, Q, R, S, T Point
+	var a = params.InitCurve.A
+	var y2, rhs Fp2
+	var e2 = params.A.SecretBitLen
+
+	// Lifted point lies on the curve
+	if !LiftX(&P, &params.A.AffineP, &a) {
+		t.Fatal("x(PA) must be an x-coordinate of a point on starting curve")
+	}
+	sqr(&y2, &P.Y)
+	curveRHS(&rhs, &P.X, &a)
+	if !vartimeEqFp2(&y2, &rhs) {
+		t.Fatal("lifted point isn't on the curve")
+	}
+	LiftX(&Q, &params.A.AffineQ, &a)
+
+	// P + Q = Q + P and (P + Q) + P = P + (Q + P)
+	AddPoints(&R, &P, &Q, &a)
+	AddPoints(&S, &Q, &P, &a)
+	if !vartimeEqFp2(&R.X, &S.X) || !vartimeEqFp2(&R.Y, &S.Y) {
+		t.Error("addition isn't commutative")
+	}
+	AddPoints(&R, &R, &P, &a)
+	AddPoints(&S, &P, &P, &a)
+	AddPoints(&S, &S, &Q, &a)
+	if !vartimeEqFp2(&R.X, &S.X) || !vartimeEqFp2(&R.Y, &S.Y) {
+		t.Error("addition isn't associative")
+	}
+
+	// P - P = O
+	SubPoints(&R, &P, &P, &a)
+	if !isZero(&R.Z) {
+		t.Error("P - P must be point at infinity")
+	}
+
+	// [k]P computed with ladder and y-recovery must match repeated addition
+	S = P
+	for k := int64(2); k < 20; k++ {
+		AddPoints(&S, &S, &P, &a)
+		ScalarMulPoint(&T, &P, &a, big.NewInt(k))
+		if !vartimeEqFp2(&T.X, &S.X) || !vartimeEqFp2(&T.Y, &S.Y) {
+			t.Fatalf("[%d]P computed incorrectly", k)
+		}
+	}
+
+	// PA has order 2^e2
+	n := new(big.Int).Lsh(big.NewInt(1), e2-1)
+	ScalarMulPoint(&T, &P, &a, n)
+	if isZero(&T.Z) {
+		t.Error("[2^(e2-1)]PA must not be point at infinity")
+	}
+	ScalarMulPoint(&T, &P, &a, n.Lsh(n, 1))
+	if !isZero(&T.Z) {
+		t.Error("[2^e2]PA must be point at infinity")
+	}
+	ScalarMulPoint(&T, &P, &a, n.Sub(n, big.NewInt(1)))
+	AddPoints(&T, &T, &P, &a)
+	if !isZero(&T.Z) {
+		t.Error("[2^e2-1]PA + PA must be point at infinity")
+	}
+}
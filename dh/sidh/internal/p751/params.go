@@ -4,7 +4,7 @@ package p751
 
 import (
 	"github.com/henrydcase/nobs/dh/sidh/common"
-	"golang.org/x/sys/cpu"
+	"github.com/henrydcase/nobs/utils"
 )
 
 const (
@@ -14,9 +14,9 @@ const (
 
 var (
 	// HasBMI2 signals support for MULX which is in BMI2
-	HasBMI2 = cpu.X86.HasBMI2
+	HasBMI2 = utils.X86.HasBMI2
 	// HasADXandBMI2 signals support for ADX and BMI2
-	HasADXandBMI2 = cpu.X86.HasBMI2 && cpu.X86.HasADX
+	HasADXandBMI2 = utils.X86.HasBMI2 && utils.X86.HasADX
 	// P751 is a prime used by field Fp751
 	P751 = common.Fp{
 		0xffffffffffffffff, 0xffffffffffffffff, 0xffffffffffffffff,
@@ -129,6 +129,8 @@ var (
 			SecretBitLen: 372,
 			// SecretBitLen in bytes.
 			SecretByteLen: 47,
+			// Size of compressed public key: 2*Bytelen + 3*ceil(log_256(3^e3)) + 1
+			CompressedPublicKeySize: 333,
 			// 2-torsion group computation strategy
 			IsogenyStrategy: []uint32{
 				0x50, 0x30, 0x1B, 0x0F, 0x08, 0x04, 0x02, 0x01, 0x01, 0x02,
@@ -201,6 +203,8 @@ var (
 			SecretBitLen: 378,
 			// SecretBitLen in bytes.
 			SecretByteLen: 48,
+			// Size of compressed public key: 2*Bytelen + 3*ceil(e2/8) + 1
+			CompressedPublicKeySize: 330,
 			// 3-torsion group computation strategy
 			IsogenyStrategy: []uint32{
 				0x70, 0x3F, 0x20, 0x10, 0x08, 0x04, 0x02, 0x01, 0x01, 0x02,
@@ -235,8 +239,9 @@ var (
 		// SIKEp751 provides 128 bit of classical security ([SIKE], 5.1)
 		KemSize: 32,
 		// ceil(751+7/8)
-		Bytelen:        94,
-		CiphertextSize: 32 + 564,
+		Bytelen:                  94,
+		CiphertextSize:           32 + 564,
+		CompressedCiphertextSize: 32 + 333,
 		InitCurve: common.ProjectiveCurveParameters{
 			A: six,
 			C: one,
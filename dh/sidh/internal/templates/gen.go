@@ -2,56 +2,461 @@
 
 // +build ignore
 
-// This program generates contributors.go. It can be invoked by running
-// go generate
+// This program generates implementation of SIDH for a field GF(p^2), where
+// p = f*2^e2*3^e3 - 1. It can be invoked by running go generate in the
+// directory of the package.
+//
+// Fields supported by the library are generated by passing name of the
+// field only, e.g. "gen.go P434". Their params.go, assembly and tests with
+// values computed by Sage are maintained by hand, as torsion basis points
+// and strategies are fixed by the SIKE specification.
+//
+// Custom fields are described by exponents e2, e3, cofactor f and id under
+// which parameters are registered, e.g.:
+//
+//	mkdir ../p434t && cd ../p434t
+//	go run ../templates/gen.go -e2 216 -e3 137 -f 1 -id 32 P434T
+//
+// In this case params.go, doc.go and params_test.go are generated as well.
+// Constants, optimal strategies and torsion basis points are computed from
+// the prime, hence the output is reproducible. Custom fields use generic
+// implementation of arithmetic only. Generated package registers its
+// parameters in dh/sidh/common, but it needs to be imported explicitly.
 package main
 
 import (
+	"bytes"
+	"errors"
+	"flag"
 	"fmt"
+	"go/format"
+	"math/big"
 	"os"
 	"strings"
 	"text/template"
 )
 
-var p34 = map[string]struct {
-	pow_strategy string
-	mul_strategy string
-	mul_initial  int
+// Fields supported by the library
+var known = map[string]struct {
+	e2, e3 uint
+	// P434 and P610 optimized implementation
+	// for ARM64 is not ported yet
+	optArm bool
 }{
-	"P434": {
-		pow_strategy: "[]uint8{3, 10, 7, 5, 6, 5, 3, 8, 4, 7, 5, 6, 4, 5, 9, 6, 3, 11, 5, 5, 2, 8, 4, 7, 7, 8, 5, 6, 4, 8, 5, 2, 10, 6, 5, 4, 8, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 1}",
-		mul_strategy: "[]uint8{2, 15, 9, 8, 14, 12, 2, 8, 5, 15, 8, 15, 6, 6, 3, 2, 0, 10, 9, 13, 1, 12, 3, 7, 1, 10, 8, 11, 2, 15, 14, 1, 11, 12, 14, 3, 11, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 0}",
-		mul_initial:  8,
-	},
-	"P503": {
-		pow_strategy: "[]uint8{12, 5, 5, 2, 7, 11, 3, 8, 4, 11, 4, 7, 5, 6, 3, 7, 5, 7, 2, 12, 5, 6, 4, 6, 8, 6, 4, 7, 5, 5, 8, 5, 8, 5, 5, 8, 9, 3, 6, 2, 10, 6, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 3}",
-		mul_strategy: "[]uint8{12, 11, 10, 0, 1, 8, 3, 7, 1, 8, 3, 6, 7, 14, 2, 14, 14, 9, 0, 13, 9, 15, 5, 12, 7, 13, 7, 15, 6, 7, 9, 0, 5, 7, 6, 8, 8, 3, 7, 0, 10, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 3}",
-		mul_initial:  0,
-	},
-	"P751": {
-		pow_strategy: "[]uint8{5, 7, 6, 2, 10, 4, 6, 9, 8, 5, 9, 4, 7, 5, 5, 4, 8, 3, 9, 5, 5, 4, 10, 4, 6, 6, 6, 5, 8, 9, 3, 4, 9, 4, 5, 6, 6, 2, 9, 4, 5, 5, 5, 7, 7, 9, 4, 6, 4, 8, 5, 8, 6, 6, 2, 9, 7, 4, 8, 8, 8, 4, 6, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 2}",
-		mul_strategy: "[]uint8{15, 11, 10, 0, 15, 3, 3, 3, 4, 4, 9, 7, 11, 11, 5, 3, 12, 2, 10, 8, 5, 2, 8, 3, 5, 4, 11, 4, 0, 9, 2, 1, 12, 7, 5, 14, 15, 0, 14, 5, 6, 4, 5, 13, 6, 9, 7, 15, 1, 14, 11, 15, 12, 5, 0, 10, 9, 7, 7, 10, 14, 6, 11, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 1}",
-		mul_initial:  13,
-	},
-}
-
-// P434 optimized implementation for
-// ARM64 is not ported yet
-var opt_arm = map[string]bool{
-	"P434": false,
-	"P503": true,
-	"P751": true,
+	"P434": {e2: 216, e3: 137, optArm: false},
+	"P503": {e2: 250, e3: 159, optArm: true},
+	"P610": {e2: 305, e3: 192, optArm: false},
+	"P751": {e2: 372, e3: 239, optArm: true},
 }
 
+// Limits imposed by sizes of buffers in dh/sidh/common
+const (
+	maxWords       = 12
+	maxSecretBytes = 48
+	maxMsgBytes    = 40
+)
+
+// Costs of x-only operations expressed in multiplications in GF(p^2) (scaled
+// by 10), assuming that squaring costs 0.8 of multiplication. Used to find
+// optimal strategies.
+const (
+	costDbl   = 4*10 + 2*8 // xDBL: 4M + 2S
+	costTpl   = 7*10 + 5*8 // xTPL: 7M + 5S
+	costEval4 = 6*10 + 2*8 // evaluation of 4-isogeny: 6M + 2S
+	costEval3 = 4*10 + 2*8 // evaluation of 3-isogeny: 4M + 2S
+)
+
+// Window size used by p34 exponentiation. Lookup table in fp2.gotemp has
+// 2^(p34Window-1) entries.
+const p34Window = 5
+
+// -----------------------------------------------------------------------------
+// Arithmetic in GF(p^2) = GF(p)[i]/(i^2+1) and on the curve
+// E_6: y^2 = x^3 + 6x^2 + x. Performance is not a concern.
+//
+
+type gfp2 struct {
+	p     *big.Int
+	words int
+}
+
+type fp2 struct{ a, b *big.Int }
+
+type point struct {
+	x, y fp2
+	inf  bool
+}
+
+func (f *gfp2) elem(a, b int64) fp2 {
+	return f.reduce(fp2{big.NewInt(a), big.NewInt(b)})
+}
+
+func (f *gfp2) reduce(x fp2) fp2 {
+	return fp2{new(big.Int).Mod(x.a, f.p), new(big.Int).Mod(x.b, f.p)}
+}
+
+func (f *gfp2) add(x, y fp2) fp2 {
+	return f.reduce(fp2{new(big.Int).Add(x.a, y.a), new(big.Int).Add(x.b, y.b)})
+}
+
+func (f *gfp2) sub(x, y fp2) fp2 {
+	return f.reduce(fp2{new(big.Int).Sub(x.a, y.a), new(big.Int).Sub(x.b, y.b)})
+}
+
+func (f *gfp2) mul(x, y fp2) fp2 {
+	var t big.Int
+	a := new(big.Int).Mul(x.a, y.a)
+	a.Sub(a, t.Mul(x.b, y.b))
+	b := new(big.Int).Mul(x.a, y.b)
+	b.Add(b, t.Mul(x.b, y.a))
+	return f.reduce(fp2{a, b})
+}
+
+func (f *gfp2) inv(x fp2) fp2 {
+	// 1/(a+bi) = (a-bi)/(a^2+b^2)
+	n := new(big.Int).Mul(x.a, x.a)
+	n.Add(n, new(big.Int).Mul(x.b, x.b))
+	n.ModInverse(n, f.p)
+	return f.reduce(fp2{new(big.Int).Mul(x.a, n), new(big.Int).Neg(new(big.Int).Mul(x.b, n))})
+}
+
+func (f *gfp2) exp(x fp2, e *big.Int) fp2 {
+	var r = f.elem(1, 0)
+	for i := e.BitLen() - 1; i >= 0; i-- {
+		r = f.mul(r, r)
+		if e.Bit(i) == 1 {
+			r = f.mul(r, x)
+		}
+	}
+	return r
+}
+
+func (f *gfp2) equal(x, y fp2) bool {
+	return x.a.Cmp(y.a) == 0 && x.b.Cmp(y.b) == 0
+}
+
+func (f *gfp2) isZero(x fp2) bool {
+	return x.a.Sign() == 0 && x.b.Sign() == 0
+}
+
+// Returns square root of x and true, or false if x is not a square. Uses
+// algorithm 9 from ePrint #2012/685, which requires p = 3 mod 4.
+func (f *gfp2) sqrt(x fp2) (fp2, bool) {
+	var e = new(big.Int)
+	var minusOne = f.elem(-1, 0)
+
+	// a1 = x^((p-3)/4)
+	a1 := f.exp(x, e.Rsh(e.Sub(f.p, big.NewInt(3)), 2))
+	alpha := f.mul(f.mul(a1, a1), x)
+	x0 := f.mul(a1, x)
+
+	var r fp2
+	if f.equal(alpha, minusOne) {
+		r = f.mul(f.elem(0, 1), x0)
+	} else {
+		b := f.exp(f.add(f.elem(1, 0), alpha), e.Rsh(e.Sub(f.p, big.NewInt(1)), 1))
+		r = f.mul(b, x0)
+	}
+	return r, f.equal(f.mul(r, r), x)
+}
+
+// Returns point on E_6 with x-coordinate x, if such point exists.
+func (f *gfp2) lift(x fp2) (point, bool) {
+	// y^2 = x^3 + 6x^2 + x
+	rhs := f.mul(x, f.add(f.mul(x, f.add(x, f.elem(6, 0))), f.elem(1, 0)))
+	y, ok := f.sqrt(rhs)
+	return point{x: x, y: y}, ok
+}
+
+func (f *gfp2) neg(P point) point {
+	return point{x: P.x, y: f.sub(f.elem(0, 0), P.y), inf: P.inf}
+}
+
+func (f *gfp2) addPoints(P, Q point) point {
+	var l fp2
+	if P.inf {
+		return Q
+	}
+	if Q.inf {
+		return P
+	}
+	if f.equal(P.x, Q.x) {
+		if !f.equal(P.y, Q.y) || f.isZero(P.y) {
+			return point{inf: true}
+		}
+		// l = (3x^2 + 12x + 1) / 2y
+		n := f.mul(P.x, f.add(f.mul(f.elem(3, 0), P.x), f.elem(12, 0)))
+		l = f.mul(f.add(n, f.elem(1, 0)), f.inv(f.add(P.y, P.y)))
+	} else {
+		l = f.mul(f.sub(Q.y, P.y), f.inv(f.sub(Q.x, P.x)))
+	}
+	// x3 = l^2 - 6 - x1 - x2, y3 = l*(x1 - x3) - y1
+	x3 := f.sub(f.sub(f.sub(f.mul(l, l), f.elem(6, 0)), P.x), Q.x)
+	y3 := f.sub(f.mul(l, f.sub(P.x, x3)), P.y)
+	return point{x: x3, y: y3}
+}
+
+func (f *gfp2) scalarMul(P point, k *big.Int) point {
+	var R = point{inf: true}
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		R = f.addPoints(R, R)
+		if k.Bit(i) == 1 {
+			R = f.addPoints(R, P)
+		}
+	}
+	return R
+}
+
+// Finds basis P, Q of E_6[l^e], where l is 2 or 3, and returns x-coordinates
+// of P, Q and P-Q. Candidates for basis points are points with x = c + i,
+// for c = 1, 2, ..., multiplied by the cofactor (p+1)/l^e. For l = 2, Q is
+// chosen so that [2^(e-1)]Q = (0,0) and [2^(e-1)]P != (0,0), hence kernels
+// of isogenies computed by key generation never lie above (0,0).
+func (f *gfp2) torsionBasis(l, e uint) (xP, xQ, xR fp2) {
+	var P, Q, bottomP point
+	var found int
+	var haveP, haveQ bool
+	var n = new(big.Int).Exp(big.NewInt(int64(l)), big.NewInt(int64(e)), nil)
+	var cofactor = new(big.Int).Add(f.p, big.NewInt(1))
+	var bottomOrder = new(big.Int).Div(n, big.NewInt(int64(l)))
+	cofactor.Div(cofactor, n)
+
+	for c := int64(1); found < 2; c++ {
+		T, ok := f.lift(f.elem(c, 1))
+		if !ok {
+			continue
+		}
+		T = f.scalarMul(T, cofactor)
+		bottom := f.scalarMul(T, bottomOrder)
+		if bottom.inf {
+			continue
+		}
+
+		if l == 2 {
+			if f.isZero(bottom.x) && !haveQ {
+				Q, haveQ = T, true
+				found++
+			} else if !f.isZero(bottom.x) && !haveP {
+				P, haveP = T, true
+				found++
+			}
+			continue
+		}
+
+		// Points of order 3 generate the same subgroup if and only if
+		// their x-coordinates are equal.
+		if found == 0 {
+			P, bottomP = T, bottom
+			found++
+		} else if !f.equal(bottom.x, bottomP.x) {
+			Q = T
+			found++
+		}
+	}
+	return P.x, Q.x, f.addPoints(P, f.neg(Q)).x
+}
+
+// -----------------------------------------------------------------------------
+// Strategies and addition chains
+//
+
+// Returns optimal strategy for a chain of n isogenies, where p is cost of
+// multiplication by degree and q is cost of isogeny evaluation (see
+// [SIDH], 4.2.2). Strategy is encoded as S(n) = [i] ++ S(n-i) ++ S(i).
+func optimalStrategy(n int, p, q uint64) []uint32 {
+	var cost = make([]uint64, n+1)
+	var split = make([]int, n+1)
+	for k := 2; k <= n; k++ {
+		cost[k] = ^uint64(0)
+		for i := 1; i < k; i++ {
+			c := cost[k-i] + cost[i] + uint64(i)*p + uint64(k-i)*q
+			if c < cost[k] {
+				cost[k], split[k] = c, i
+			}
+		}
+	}
+
+	var strat []uint32
+	var build func(k int)
+	build = func(k int) {
+		if k < 2 {
+			return
+		}
+		strat = append(strat, uint32(split[k]))
+		build(k - split[k])
+		build(split[k])
+	}
+	build(n)
+	return strat
+}
+
+// Computes sliding window addition chain for x^((p-3)/4), used by p34
+// from fp2.gotemp. Exponent is processed from the most significant bit.
+// Window is a bit string of length at most p34Window which starts and ends
+// with 1. Exponentiation starts from x^w for the first window w, then for
+// each next window w, preceded by z zeros, it squares z+len(w) times
+// (pow) and multiplies by x^w (mul = w/2 indexes table of odd powers).
+func p34Chain(p *big.Int) (pow, mul []uint8, initial uint8) {
+	var e = new(big.Int).Sub(p, big.NewInt(3))
+	e.Rsh(e, 2)
+
+	var first = true
+	for i := e.BitLen() - 1; i >= 0; {
+		var zeros, w, l int
+		for ; i >= 0 && e.Bit(i) == 0; i-- {
+			zeros++
+		}
+		if i < 0 {
+			// p34 multiplies after each window, (p-3)/4 is odd
+			panic("(p-3)/4 must be odd")
+		}
+
+		// longest window which ends with 1
+		for j := 0; j < p34Window && i-j >= 0; j++ {
+			if e.Bit(i-j) == 1 {
+				l = j + 1
+			}
+		}
+		for j := 0; j < l; j++ {
+			w = w<<1 | int(e.Bit(i-j))
+		}
+		i -= l
+
+		if first {
+			initial, first = uint8(w/2), false
+			continue
+		}
+		pow = append(pow, uint8(zeros+l))
+		mul = append(mul, uint8(w/2))
+	}
+	return
+}
+
+// -----------------------------------------------------------------------------
+// Formatting
+//
+
+func goSlice(v []uint8) string {
+	var s = make([]string, len(v))
+	for i := range v {
+		s[i] = fmt.Sprint(v[i])
+	}
+	return "[]uint8{" + strings.Join(s, ", ") + "}"
+}
+
+// Formats words of x in Montgomery domain, if mont is true.
+func (f *gfp2) fp(x *big.Int, mont bool) string {
+	var b strings.Builder
+	var t = new(big.Int).Set(x)
+	var mask = new(big.Int).SetUint64(^uint64(0))
+
+	if mont {
+		t.Lsh(t, uint(64*f.words))
+		t.Mod(t, f.p)
+	}
+	for i := 0; i < f.words; i++ {
+		if i%4 == 0 {
+			b.WriteString("\n")
+		} else {
+			b.WriteString(" ")
+		}
+		fmt.Fprintf(&b, "0x%016X,", new(big.Int).And(t, mask).Uint64())
+		t.Rsh(t, 64)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+func formatStrategy(s []uint32) string {
+	var b strings.Builder
+	for i := range s {
+		if i%10 == 0 {
+			b.WriteString("\n")
+		} else {
+			b.WriteString(" ")
+		}
+		fmt.Fprintf(&b, "0x%02X,", s[i])
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+type fp2Str struct{ A, B string }
+
+type domain struct {
+	P, Q, R  fp2Str
+	STRATEGY string
+	BITLEN   uint
+	BYTELEN  uint
+	CPK_SIZE int
+}
+
+// Computes values for params.gotemp.
+func (f *gfp2) params(e2, e3 uint, cofactor *big.Int, msgLen int) (map[string]interface{}, error) {
+	var bitlen = f.p.BitLen()
+	var bytelen = (bitlen + 7) / 8
+	var order3 = new(big.Int).Exp(big.NewInt(3), big.NewInt(int64(e3)), nil)
+	var pp1 = new(big.Int).Add(f.p, big.NewInt(1))
+
+	bitlen3 := uint(order3.BitLen() - 1)
+	if (e2+7)/8 > maxSecretBytes || (bitlen3+7)/8 > maxSecretBytes {
+		return nil, errors.New("secret keys too big")
+	}
+	if msgLen > maxMsgBytes {
+		return nil, errors.New("message too big")
+	}
+
+	fp2s := func(x fp2) fp2Str {
+		return fp2Str{A: f.fp(x.a, true), B: f.fp(x.b, true)}
+	}
+	var A, B domain
+	xP, xQ, xR := f.torsionBasis(2, e2)
+	A.P, A.Q, A.R = fp2s(xP), fp2s(xQ), fp2s(xR)
+	A.STRATEGY = formatStrategy(optimalStrategy(int(e2/2), 2*costDbl, costEval4))
+	A.BITLEN, A.BYTELEN = e2, (e2+7)/8
+	A.CPK_SIZE = 2*bytelen + 3*((order3.BitLen()+7)/8) + 1
+
+	xP, xQ, xR = f.torsionBasis(3, e3)
+	B.P, B.Q, B.R = fp2s(xP), fp2s(xQ), fp2s(xR)
+	B.STRATEGY = formatStrategy(optimalStrategy(int(e3), costTpl, costEval3))
+	B.BITLEN, B.BYTELEN = bitlen3, (bitlen3+7)/8
+	B.CPK_SIZE = 2*bytelen + 3*int((e2+7)/8) + 1
+
+	prime := fmt.Sprintf("2^%d*3^%d - 1", e2, e3)
+	if cofactor.Cmp(big.NewInt(1)) != 0 {
+		prime = cofactor.String() + "*" + prime
+	}
+	half := new(big.Int).ModInverse(big.NewInt(2), f.p)
+	return map[string]interface{}{
+		"PRIME":   prime,
+		"BITLEN":  bitlen,
+		"BYTELEN": bytelen,
+		"WORDS":   f.words,
+		"RBITS":   64 * f.words,
+		"P":       f.fp(f.p, false),
+		"PX2":     f.fp(new(big.Int).Lsh(f.p, 1), false),
+		"PP1":     f.fp(pp1, false),
+		"R2":      f.fp(new(big.Int).Lsh(big.NewInt(1), uint(64*f.words)), true),
+		"HALF":    f.fp(half, true),
+		"ONE":     f.fp(big.NewInt(1), true),
+		"SIX":     f.fp(big.NewInt(6), true),
+		"P1ZEROS": e2 / 64,
+		"PK_SIZE": 3 * 2 * bytelen,
+		"SS_SIZE": 2 * bytelen,
+		"MSG_LEN": msgLen,
+		"A":       A,
+		"B":       B,
+	}, nil
+}
+
+// -----------------------------------------------------------------------------
+// Generation
+//
+
 // Generates an 'fileNameBase.go' from 'fileNameBase.gotemp' file
 // for a given finite 'field'. Maps placeholders to 'values'.
 func gen(field, fileNameBase string, values interface{}) {
-	// name of the output .go file
-	outFileName := fileNameBase + ".go"
-	out, err := os.Create(outFileName)
-	if err != nil {
-		panic("Cannot open file")
-	}
+	var buf bytes.Buffer
 
 	// Template files are located in ../templates and have
 	// extension .gotemp
@@ -60,16 +465,81 @@ func gen(field, fileNameBase string, values interface{}) {
 	if err != nil {
 		panic(fmt.Sprintf("Cannot open template file %s", templateFile))
 	}
+	if err = t.Execute(&buf, values); err != nil {
+		panic(fmt.Sprintf("Cannot execute template file %s: %v", templateFile, err))
+	}
 
-	t.Execute(out, values)
-	err = out.Close()
+	out := buf.Bytes()
+	if fileNameBase == "params" {
+		// Values are not aligned by the template
+		if out, err = format.Source(out); err != nil {
+			panic(fmt.Sprintf("Cannot format %s: %v", fileNameBase, err))
+		}
+	}
+
+	// name of the output .go file
+	outFileName := fileNameBase + ".go"
+	file, err := os.Create(outFileName)
+	if err != nil {
+		panic("Cannot open file")
+	}
+	if _, err = file.Write(out); err != nil {
+		panic("Cannot write file")
+	}
+	err = file.Close()
 	if err != nil {
 		panic("Cant close generated file")
 	}
 }
 
+func fail(format string, a ...interface{}) {
+	fmt.Fprintf(os.Stderr, "gen: "+format+"\n", a...)
+	os.Exit(1)
+}
+
 func main() {
-	field := os.Args[1]
+	e2 := flag.Uint("e2", 0, "exponent of 2 in p+1 (custom fields)")
+	e3 := flag.Uint("e3", 0, "exponent of 3 in p+1 (custom fields)")
+	cof := flag.String("f", "1", "cofactor of p+1 coprime to 6 (custom fields)")
+	id := flag.Uint("id", 0, "id under which custom field is registered")
+	msgLen := flag.Int("msglen", 16, "byte size of SIKE message and shared secret (custom fields)")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		fail("usage: gen.go [-e2 n -e3 n [-f n] -id n [-msglen n]] FIELD")
+	}
+
+	field := flag.Arg(0)
+	cofactor, ok := new(big.Int).SetString(*cof, 10)
+	if !ok || cofactor.Sign() <= 0 || new(big.Int).GCD(nil, nil, cofactor, big.NewInt(6)).Int64() != 1 {
+		fail("cofactor must be a positive integer coprime to 6")
+	}
+
+	k, isKnown := known[field]
+	custom := *e2 != 0 || *e3 != 0
+	if isKnown == custom {
+		fail("field %s must be either supported by the library or described by -e2 and -e3", field)
+	}
+	if custom {
+		k.e2, k.e3 = *e2, *e3
+		// Montgomery reduction uses p+1 instead of -1/p mod 2^64, hence
+		// it requires p = -1 mod 2^64.
+		if k.e2 < 64 || k.e3 < 2 || *id < 4 || *id > 0xFF {
+			fail("e2 must be at least 64, e3 at least 2 and id in range [4, 255]")
+		}
+	}
+
+	p := new(big.Int).Lsh(cofactor, k.e2)
+	p.Mul(p, new(big.Int).Exp(big.NewInt(3), big.NewInt(int64(k.e3)), nil))
+	p.Sub(p, big.NewInt(1))
+	if !p.ProbablyPrime(32) {
+		fail("%s = %v*2^%d*3^%d - 1 is not a prime", field, cofactor, k.e2, k.e3)
+	}
+	f := &gfp2{p: p, words: (p.BitLen() + 63) / 64}
+	// Lazy reduction requires 4p < 2^(64*words)
+	if f.words > maxWords || p.BitLen() > 64*f.words-2 {
+		fail("prime of %d bits is not supported", p.BitLen())
+	}
+	pow, mul, initial := p34Chain(p)
 
 	s := struct {
 		FIELD            string
@@ -78,26 +548,48 @@ func main() {
 		P34_MUL_STRATEGY string
 		P34_INITIAL_MUL  int
 		OPT_ARM          bool
+		ODD_E2           bool
+		HAS_ASM          bool
 	}{
 		FIELD:            field,
 		PACKAGE:          strings.ToLower(field),
-		P34_POW_STRATEGY: p34[field].pow_strategy,
-		P34_MUL_STRATEGY: p34[field].mul_strategy,
-		P34_INITIAL_MUL:  p34[field].mul_initial,
-		OPT_ARM:          opt_arm[field],
+		P34_POW_STRATEGY: goSlice(pow),
+		P34_MUL_STRATEGY: goSlice(mul),
+		P34_INITIAL_MUL:  int(initial),
+		OPT_ARM:          k.optArm,
+		ODD_E2:           k.e2%2 == 1,
+		HAS_ASM:          !custom,
 	}
 
 	targets := map[string]interface{}{
-		"arith_decl":    s,
 		"arith_generic": s,
 		"curve":         s,
 		"fp2":           s,
 		"core":          s,
+		"compress":      s,
+		"exports":       s,
 
 		// tests
-		"arith_test": s,
-		"fp2_test":   s,
-		"curve_test": s,
+		"arith_test":    s,
+		"fp2_test":      s,
+		"compress_test": s,
+	}
+
+	if custom {
+		v, err := f.params(k.e2, k.e3, cofactor, *msgLen)
+		if err != nil {
+			fail("%v", err)
+		}
+		v["FIELD"], v["PACKAGE"], v["ODD_E2"] = s.FIELD, s.PACKAGE, s.ODD_E2
+		v["ID"] = *id
+		v["ARGS"] = fmt.Sprintf("-e2 %d -e3 %d -f %v -id %d -msglen %d %s",
+			k.e2, k.e3, cofactor, *id, *msgLen, field)
+		targets["params"] = v
+		targets["doc"] = v
+		targets["params_test"] = s
+	} else {
+		targets["arith_decl"] = s
+		targets["curve_test"] = s
 	}
 
 	for v, s := range targets {
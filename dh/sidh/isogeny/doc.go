@@ -0,0 +1,13 @@
+// Package isogeny exposes arithmetic used by SIDH: operations in GF(p^2),
+// on Montgomery curves over GF(p^2) and isogenies of degree 2, 3 and 4
+// between them, as well as chains of such isogenies traversed with
+// a strategy. Fields for all SIDH primes are supported.
+//
+// Package is meant for experimenting with isogeny-based constructions.
+// Functions don't validate inputs unless stated otherwise.
+//
+// References:
+// - [SIDH] https://eprint.iacr.org/2011/506
+// - [SIKE] http://www.sike.org/files/SIDH-spec.pdf
+//
+package isogeny
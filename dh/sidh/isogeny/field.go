@@ -0,0 +1,322 @@
+package isogeny
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/henrydcase/nobs/dh/sidh/common"
+	"github.com/henrydcase/nobs/dh/sidh/internal/p434"
+	"github.com/henrydcase/nobs/dh/sidh/internal/p503"
+	"github.com/henrydcase/nobs/dh/sidh/internal/p610"
+	"github.com/henrydcase/nobs/dh/sidh/internal/p751"
+)
+
+type (
+	// Fp2 is an element of GF(p^2) in Montgomery domain.
+	Fp2 = common.Fp2
+	// ProjectivePoint is a point (X:Z) on the Kummer line of a curve.
+	ProjectivePoint = common.ProjectivePoint
+	// Point is a point (X:Y:Z) on a curve. Point at infinity has Z = 0.
+	Point = common.Point
+	// Curve is a Montgomery curve Cy^2 = x^3 + Ax^2 + Cx given by (A:C).
+	Curve = common.ProjectiveCurveParameters
+)
+
+// Id's of supported fields correspond to bitlength of the prime.
+const (
+	Fp434 = common.Fp434
+	Fp503 = common.Fp503
+	Fp610 = common.Fp610
+	Fp751 = common.Fp751
+)
+
+// Errors returned by the package
+var (
+	// ErrUnsupportedField is returned when field id is not known.
+	ErrUnsupportedField = errors.New("isogeny: unsupported field")
+	// ErrEncoding is returned when encoded element has wrong length
+	// or it is not smaller than p.
+	ErrEncoding = errors.New("isogeny: invalid encoding of field element")
+	// ErrDegree is returned when isogeny of unsupported degree is requested.
+	ErrDegree = errors.New("isogeny: unsupported degree")
+	// ErrStrategy is returned when strategy doesn't match length of the chain.
+	ErrStrategy = errors.New("isogeny: wrong size of strategy")
+)
+
+// Field provides arithmetic in GF(p^2), on Montgomery curves over GF(p^2)
+// and isogenies between them for one of the SIDH primes. Elements are kept
+// in Montgomery domain. Unless stated otherwise functions are constant
+// time. Functions working with points with both coordinates take variable
+// time.
+type Field struct {
+	params *common.SidhParams
+
+	add, sub, mul   func(dest, lhs, rhs *Fp2)
+	sqr, inv, sqrt  func(dest, x *Fp2)
+	toMont, fromMon func(dest, x *Fp2)
+	isZero, isSq    func(x *Fp2) bool
+
+	jInv      func(c *Curve, j *Fp2)
+	recoverA  func(c *Curve, xp, xq, xr *Fp2)
+	equiv3    func(c *Curve) common.CurveCoefficientsEquiv
+	equiv4    func(c *Curve) common.CurveCoefficientsEquiv
+	recover3  func(c *Curve, eq *common.CurveCoefficientsEquiv)
+	recover4  func(c *Curve, eq *common.CurveCoefficientsEquiv)
+	pow2k     func(p *ProjectivePoint, eq *common.CurveCoefficientsEquiv, k uint32)
+	pow3k     func(p *ProjectivePoint, eq *common.CurveCoefficientsEquiv, k uint32)
+	xDblAdd   func(P, Q, QmP *ProjectivePoint, a24 *Fp2) (ProjectivePoint, ProjectivePoint)
+	ladder3Pt func(c *Curve, P, Q, PmQ *ProjectivePoint, nbits uint, scalar []uint8) ProjectivePoint
+
+	toAffine       func(P *Point)
+	addPts, subPts func(R, P, Q *Point, a *Fp2)
+	liftX          func(P *Point, x, a *Fp2) bool
+	recoverPt      func(R, P *Point, xQ, xQP *ProjectivePoint, a *Fp2)
+	scalarMul      func(R, P *Point, a *Fp2, k *big.Int)
+
+	newIsogeny [5]func() common.Isogeny
+}
+
+var fields = map[uint8]Field{
+	Fp434: {
+		add: p434.Fp2Add, sub: p434.Fp2Sub, mul: p434.Fp2Mul,
+		sqr: p434.Fp2Sqr, inv: p434.Fp2Inv, sqrt: p434.Sqrt,
+		toMont: p434.ToMontgomery, fromMon: p434.FromMontgomery,
+		isZero: p434.Fp2IsZero, isSq: p434.IsSquare,
+		jInv: p434.Jinvariant, recoverA: p434.RecoverCoordinateA,
+		equiv3: p434.CalcCurveParamsEquiv3, equiv4: p434.CalcCurveParamsEquiv4,
+		recover3: p434.RecoverCurveCoefficients3, recover4: p434.RecoverCurveCoefficients4,
+		pow2k: p434.Pow2k, pow3k: p434.Pow3k,
+		xDblAdd: p434.XDblAdd, ladder3Pt: p434.ScalarMul3Pt,
+		toAffine: p434.ToAffine, addPts: p434.AddPoints, subPts: p434.SubPoints, liftX: p434.LiftX,
+		recoverPt: p434.RecoverPoint, scalarMul: p434.ScalarMulPoint,
+		newIsogeny: [5]func() common.Isogeny{
+			2: p434.NewIsogeny2, 3: p434.NewIsogeny3, 4: p434.NewIsogeny4},
+	},
+	Fp503: {
+		add: p503.Fp2Add, sub: p503.Fp2Sub, mul: p503.Fp2Mul,
+		sqr: p503.Fp2Sqr, inv: p503.Fp2Inv, sqrt: p503.Sqrt,
+		toMont: p503.ToMontgomery, fromMon: p503.FromMontgomery,
+		isZero: p503.Fp2IsZero, isSq: p503.IsSquare,
+		jInv: p503.Jinvariant, recoverA: p503.RecoverCoordinateA,
+		equiv3: p503.CalcCurveParamsEquiv3, equiv4: p503.CalcCurveParamsEquiv4,
+		recover3: p503.RecoverCurveCoefficients3, recover4: p503.RecoverCurveCoefficients4,
+		pow2k: p503.Pow2k, pow3k: p503.Pow3k,
+		xDblAdd: p503.XDblAdd, ladder3Pt: p503.ScalarMul3Pt,
+		toAffine: p503.ToAffine, addPts: p503.AddPoints, subPts: p503.SubPoints, liftX: p503.LiftX,
+		recoverPt: p503.RecoverPoint, scalarMul: p503.ScalarMulPoint,
+		newIsogeny: [5]func() common.Isogeny{
+			2: p503.NewIsogeny2, 3: p503.NewIsogeny3, 4: p503.NewIsogeny4},
+	},
+	Fp610: {
+		add: p610.Fp2Add, sub: p610.Fp2Sub, mul: p610.Fp2Mul,
+		sqr: p610.Fp2Sqr, inv: p610.Fp2Inv, sqrt: p610.Sqrt,
+		toMont: p610.ToMontgomery, fromMon: p610.FromMontgomery,
+		isZero: p610.Fp2IsZero, isSq: p610.IsSquare,
+		jInv: p610.Jinvariant, recoverA: p610.RecoverCoordinateA,
+		equiv3: p610.CalcCurveParamsEquiv3, equiv4: p610.CalcCurveParamsEquiv4,
+		recover3: p610.RecoverCurveCoefficients3, recover4: p610.RecoverCurveCoefficients4,
+		pow2k: p610.Pow2k, pow3k: p610.Pow3k,
+		xDblAdd: p610.XDblAdd, ladder3Pt: p610.ScalarMul3Pt,
+		toAffine: p610.ToAffine, addPts: p610.AddPoints, subPts: p610.SubPoints, liftX: p610.LiftX,
+		recoverPt: p610.RecoverPoint, scalarMul: p610.ScalarMulPoint,
+		newIsogeny: [5]func() common.Isogeny{
+			2: p610.NewIsogeny2, 3: p610.NewIsogeny3, 4: p610.NewIsogeny4},
+	},
+	Fp751: {
+		add: p751.Fp2Add, sub: p751.Fp2Sub, mul: p751.Fp2Mul,
+		sqr: p751.Fp2Sqr, inv: p751.Fp2Inv, sqrt: p751.Sqrt,
+		toMont: p751.ToMontgomery, fromMon: p751.FromMontgomery,
+		isZero: p751.Fp2IsZero, isSq: p751.IsSquare,
+		jInv: p751.Jinvariant, recoverA: p751.RecoverCoordinateA,
+		equiv3: p751.CalcCurveParamsEquiv3, equiv4: p751.CalcCurveParamsEquiv4,
+		recover3: p751.RecoverCurveCoefficients3, recover4: p751.RecoverCurveCoefficients4,
+		pow2k: p751.Pow2k, pow3k: p751.Pow3k,
+		xDblAdd: p751.XDblAdd, ladder3Pt: p751.ScalarMul3Pt,
+		toAffine: p751.ToAffine, addPts: p751.AddPoints, subPts: p751.SubPoints, liftX: p751.LiftX,
+		recoverPt: p751.RecoverPoint, scalarMul: p751.ScalarMulPoint,
+		newIsogeny: [5]func() common.Isogeny{
+			2: p751.NewIsogeny2, 3: p751.NewIsogeny3, 4: p751.NewIsogeny4},
+	},
+}
+
+// NewField returns arithmetic for a field identified by id, one of
+// Fp434, Fp503, Fp610 or Fp751.
+func NewField(id uint8) (*Field, error) {
+	f, ok := fields[id]
+	if !ok {
+		return nil, ErrUnsupportedField
+	}
+	f.params = common.Params(id)
+	return &f, nil
+}
+
+// ID returns id of the field.
+func (f *Field) ID() uint8 { return f.params.ID }
+
+// Bytelen returns byte length of p. Encoded element of GF(p^2) has
+// 2*Bytelen() bytes.
+func (f *Field) Bytelen() int { return f.params.Bytelen }
+
+// Exponents returns e2 and e3 such that p = 2^e2*3^e3 - 1.
+func (f *Field) Exponents() (e2, e3 uint) {
+	// SecretBitLen of B is floor(log_2(3^e3)), hence it can't be used.
+	e3 = uint(len(f.params.B.IsogenyStrategy) + 1)
+	return f.params.A.SecretBitLen, e3
+}
+
+// -----------------------------------------------------------------------------
+// Arithmetic in GF(p^2)
+//
+
+// One returns 1.
+func (f *Field) One() Fp2 { return f.params.OneFp2 }
+
+// SetBytes decodes x = a + b*i from little-endian encoding a || b, where a
+// and b are Bytelen() bytes long. Returns error if encoding has wrong length
+// or a, b are not smaller than p.
+func (f *Field) SetBytes(x *Fp2, in []byte) error {
+	var t Fp2
+	var enc = make([]byte, 2*f.params.Bytelen)
+
+	if len(in) != len(enc) {
+		return ErrEncoding
+	}
+	common.BytesToFp2(&t, in, f.params.Bytelen)
+	f.toMont(x, &t)
+	// Encoding is canonical only if it is reduced mod p
+	f.Bytes(enc, x)
+	for i := range enc {
+		if enc[i] != in[i] {
+			*x = Fp2{}
+			return ErrEncoding
+		}
+	}
+	return nil
+}
+
+// Bytes encodes x as a || b, where a, b are little-endian numbers in
+// [0, p), each Bytelen() bytes long. out must be 2*Bytelen() bytes long.
+func (f *Field) Bytes(out []byte, x *Fp2) {
+	var t Fp2
+	f.fromMon(&t, x)
+	common.Fp2ToBytes(out, &t, f.params.Bytelen)
+}
+
+// Add sets dest = lhs + rhs.
+func (f *Field) Add(dest, lhs, rhs *Fp2) { f.add(dest, lhs, rhs) }
+
+// Sub sets dest = lhs - rhs.
+func (f *Field) Sub(dest, lhs, rhs *Fp2) { f.sub(dest, lhs, rhs) }
+
+// Mul sets dest = lhs * rhs.
+func (f *Field) Mul(dest, lhs, rhs *Fp2) { f.mul(dest, lhs, rhs) }
+
+// Sqr sets dest = x^2.
+func (f *Field) Sqr(dest, x *Fp2) { f.sqr(dest, x) }
+
+// Inv sets dest = 1/x. Result is 0 if x = 0.
+func (f *Field) Inv(dest, x *Fp2) { f.inv(dest, x) }
+
+// IsZero returns true if x = 0. Takes variable time.
+func (f *Field) IsZero(x *Fp2) bool { return f.isZero(x) }
+
+// Equal returns true if x = y. Takes variable time.
+func (f *Field) Equal(x, y *Fp2) bool {
+	var t Fp2
+	f.sub(&t, x, y)
+	return f.isZero(&t)
+}
+
+// IsSquare returns true if x is a square in GF(p^2). Takes variable time.
+func (f *Field) IsSquare(x *Fp2) bool { return f.isSq(x) }
+
+// Sqrt sets dest to a square root of x. Result is undefined if x is not
+// a square. Takes variable time.
+func (f *Field) Sqrt(dest, x *Fp2) { f.sqrt(dest, x) }
+
+// -----------------------------------------------------------------------------
+// Montgomery curves
+//
+
+// StartingCurve returns curve y^2 = x^3 + 6x^2 + x used by SIDH.
+func (f *Field) StartingCurve() Curve { return f.params.InitCurve }
+
+// JInvariant returns j-invariant of the curve.
+func (f *Field) JInvariant(c *Curve) Fp2 {
+	var j Fp2
+	f.jInv(c, &j)
+	return j
+}
+
+// RecoverCurve returns curve (A:1) such that xP, xQ and xPmQ are
+// x-coordinates of some points P, Q and P-Q on it.
+func (f *Field) RecoverCurve(xP, xQ, xPmQ *Fp2) Curve {
+	var c = f.params.InitCurve
+	f.recoverA(&c, xP, xQ, xPmQ)
+	return c
+}
+
+// AffineA returns A/C.
+func (f *Field) AffineA(c *Curve) Fp2 {
+	var a Fp2
+	f.inv(&a, &c.C)
+	f.mul(&a, &a, &c.A)
+	return a
+}
+
+// Pow2k sets P to x([2^k]P).
+func (f *Field) Pow2k(P *ProjectivePoint, c *Curve, k uint) {
+	eq := f.equiv4(c)
+	f.pow2k(P, &eq, uint32(k))
+}
+
+// Pow3k sets P to x([3^k]P).
+func (f *Field) Pow3k(P *ProjectivePoint, c *Curve, k uint) {
+	eq := f.equiv3(c)
+	f.pow3k(P, &eq, uint32(k))
+}
+
+// XDblAdd returns x(2P) and x(P+Q), given x(P), x(Q) and x(Q-P) on
+// a curve c.
+func (f *Field) XDblAdd(P, Q, QmP *ProjectivePoint, c *Curve) (dblP, PaQ ProjectivePoint) {
+	var a24, t Fp2
+	// a24 = (A+2C)/4C
+	eq := f.equiv4(c)
+	f.inv(&t, &eq.C)
+	f.mul(&a24, &eq.A, &t)
+	return f.xDblAdd(P, Q, QmP, &a24)
+}
+
+// Ladder3Pt returns x(P + [k]Q), given x(P), x(Q) and x(P-Q) on a curve c,
+// where k is a little-endian number consisting of nbits lowest bits of
+// scalar.
+func (f *Field) Ladder3Pt(c *Curve, P, Q, PmQ *ProjectivePoint, nbits uint, scalar []byte) ProjectivePoint {
+	return f.ladder3Pt(c, P, Q, PmQ, nbits, scalar)
+}
+
+// -----------------------------------------------------------------------------
+// Points with both coordinates. Curve is given by affine coefficient
+// a = A/C. Functions take variable time.
+//
+
+// LiftX sets P to an affine point with x-coordinate x. Returns false if
+// such a point is not defined over GF(p^2).
+func (f *Field) LiftX(P *Point, x, a *Fp2) bool { return f.liftX(P, x, a) }
+
+// ToAffine normalizes P, so that Z = 1, unless P is the point at infinity.
+func (f *Field) ToAffine(P *Point) { f.toAffine(P) }
+
+// AddPoints sets R = P + Q. P and Q must be affine or at infinity.
+func (f *Field) AddPoints(R, P, Q *Point, a *Fp2) { f.addPts(R, P, Q, a) }
+
+// SubPoints sets R = P - Q. P and Q must be affine or at infinity.
+func (f *Field) SubPoints(R, P, Q *Point, a *Fp2) { f.subPts(R, P, Q, a) }
+
+// ScalarMul sets R = [k]P. P must be affine or at infinity.
+func (f *Field) ScalarMul(R, P *Point, a *Fp2, k *big.Int) { f.scalarMul(R, P, a, k) }
+
+// RecoverPoint sets R to affine point Q, given affine P, x(Q) and x(Q+P).
+// Q and Q+P must not be points at infinity.
+func (f *Field) RecoverPoint(R, P *Point, xQ, xQP *ProjectivePoint, a *Fp2) {
+	f.recoverPt(R, P, xQ, xQP, a)
+}
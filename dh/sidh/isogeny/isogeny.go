@@ -0,0 +1,169 @@
+package isogeny
+
+import (
+	"github.com/henrydcase/nobs/dh/sidh/common"
+)
+
+// Isogeny of degree 2, 3 or 4 between Montgomery curves.
+type Isogeny struct {
+	f        *Field
+	degree   uint
+	phi      common.Isogeny
+	codomain Curve
+}
+
+// NewIsogeny constructs isogeny of given degree with kernel generated by
+// x-coordinate of a point. Degree must be 2, 3 or 4. Kernel of 4-isogeny
+// must not be a point above (0,0). Kernel point must have exact order
+// equal to degree, which is not checked.
+func (f *Field) NewIsogeny(degree uint, kernel *ProjectivePoint) (*Isogeny, error) {
+	if degree < 2 || degree > 4 {
+		return nil, ErrDegree
+	}
+
+	var c = f.params.InitCurve
+	var phi = f.newIsogeny[degree]()
+	var eq = phi.GenerateCurve(kernel)
+	if degree == 3 {
+		f.recover3(&c, &eq)
+	} else {
+		f.recover4(&c, &eq)
+	}
+	return &Isogeny{f: f, degree: degree, phi: phi, codomain: c}, nil
+}
+
+// Degree returns degree of the isogeny.
+func (i *Isogeny) Degree() uint { return i.degree }
+
+// Codomain returns the codomain curve.
+func (i *Isogeny) Codomain() Curve { return i.codomain }
+
+// Evaluate returns image of a point under the isogeny.
+func (i *Isogeny) Evaluate(p *ProjectivePoint) ProjectivePoint {
+	return i.phi.EvaluatePoint(p)
+}
+
+// Chain computes isogeny of degree l^e with kernel generated by x(R),
+// where R is a point of order l^e on curve c and l is 2 or 3. Returns the
+// codomain curve and replaces points in pts with their images. Isogeny of
+// degree 2^e is computed as composition of 4-isogenies, preceded by single
+// 2-isogeny if e is odd. In that case [2^(e-1)]R must not be (0,0).
+//
+// Order in which points are multiplied and pushed through isogenies is
+// given by a strategy with n-1 entries, where n is e for l=3 and floor(e/2)
+// for l=2 (see OptimalStrategy). If strategy is nil, simple strategy is
+// used which needs O(n^2) point multiplications.
+func (f *Field) Chain(c *Curve, R *ProjectivePoint, l uint, e uint, strategy []uint32, pts []ProjectivePoint) (Curve, error) {
+	var n, degree uint
+	var curve = *c
+	var xR = *R
+
+	switch l {
+	case 2:
+		n, degree = e/2, 4
+	case 3:
+		n, degree = e, 3
+	default:
+		return curve, ErrDegree
+	}
+	if n == 0 {
+		return curve, ErrStrategy
+	}
+	if strategy == nil {
+		strategy = make([]uint32, n-1)
+		for i := range strategy {
+			strategy[i] = 1
+		}
+	}
+	if uint(len(strategy)) != n-1 {
+		return curve, ErrStrategy
+	}
+
+	if l == 2 && e%2 == 1 {
+		var xT = xR
+		f.Pow2k(&xT, &curve, e-1)
+		phi, _ := f.NewIsogeny(2, &xT)
+		curve = phi.Codomain()
+		xR = phi.Evaluate(&xR)
+		for i := range pts {
+			pts[i] = phi.Evaluate(&pts[i])
+		}
+	}
+
+	var points = make([]ProjectivePoint, 0, 8)
+	var indices = make([]int, 0, 8)
+	var i, sIdx int
+	var stratSz = len(strategy)
+
+	for j := 1; j <= stratSz+1; j++ {
+		for i <= stratSz-j {
+			points = append(points, xR)
+			indices = append(indices, i)
+
+			k := uint(strategy[sIdx])
+			sIdx++
+			if l == 2 {
+				f.Pow2k(&xR, &curve, 2*k)
+			} else {
+				f.Pow3k(&xR, &curve, k)
+			}
+			i += int(k)
+		}
+
+		phi, _ := f.NewIsogeny(degree, &xR)
+		curve = phi.Codomain()
+		for k := range points {
+			points[k] = phi.Evaluate(&points[k])
+		}
+		for k := range pts {
+			pts[k] = phi.Evaluate(&pts[k])
+		}
+
+		if len(points) == 0 {
+			break
+		}
+		// pop xR from points
+		xR, points = points[len(points)-1], points[:len(points)-1]
+		i, indices = indices[len(indices)-1], indices[:len(indices)-1]
+	}
+	return curve, nil
+}
+
+// OptimalStrategy returns strategy for a chain of n isogenies, which
+// minimizes cost of the computation, given cost p of multiplication
+// of a point by the degree and cost q of isogeny evaluation (see
+// De Feo, Jao, Plut, "Towards quantum-resistant cryptosystems from
+// supersingular elliptic curve isogenies", ePrint #506, 2011).
+// Strategy has n-1 entries.
+func OptimalStrategy(n int, p, q uint64) []uint32 {
+	if n < 1 {
+		return nil
+	}
+
+	// cost[k] is minimal cost of a chain of length k, split[k] is
+	// the first step of corresponding strategy.
+	var cost = make([]uint64, n+1)
+	var split = make([]int, n+1)
+	for k := 2; k <= n; k++ {
+		cost[k] = ^uint64(0)
+		for i := 1; i < k; i++ {
+			c := cost[k-i] + cost[i] + uint64(i)*p + uint64(k-i)*q
+			if c < cost[k] {
+				cost[k], split[k] = c, i
+			}
+		}
+	}
+
+	var strat = make([]uint32, 0, n-1)
+	var build func(k int)
+	build = func(k int) {
+		if k < 2 {
+			return
+		}
+		strat = append(strat, uint32(split[k]))
+		build(k - split[k])
+		build(split[k])
+	}
+	build(n)
+	return strat
+}
@@ -0,0 +1,325 @@
+package isogeny
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/henrydcase/nobs/dh/sidh/internal/p434"
+	"github.com/henrydcase/nobs/dh/sidh/internal/p503"
+	"github.com/henrydcase/nobs/dh/sidh/internal/p610"
+	"github.com/henrydcase/nobs/dh/sidh/internal/p751"
+)
+
+var publicKeyGen = map[uint8][2]func(*[3]Fp2, []byte){
+	Fp434: {p434.PublicKeyGenA, p434.PublicKeyGenB},
+	Fp503: {p503.PublicKeyGenA, p503.PublicKeyGenB},
+	Fp610: {p610.PublicKeyGenA, p610.PublicKeyGenB},
+	Fp751: {p751.PublicKeyGenA, p751.PublicKeyGenB},
+}
+
+func newField(t testing.TB, id uint8) *Field {
+	f, err := NewField(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+// Returns random element of GF(p^2).
+func randomFp2(t testing.TB, f *Field) Fp2 {
+	var x Fp2
+	var buf = make([]byte, 2*f.Bytelen())
+	if _, err := rand.Read(buf); err != nil {
+		t.Fatal(err)
+	}
+	// Clear top bits, so that both halves are smaller than p
+	buf[f.Bytelen()-1] = 0
+	buf[2*f.Bytelen()-1] = 0
+	if err := f.SetBytes(&x, buf); err != nil {
+		t.Fatal(err)
+	}
+	return x
+}
+
+// Returns random scalar with bitlen bits.
+func randomScalar(t testing.TB, bitlen uint) []byte {
+	var k = make([]byte, (bitlen+7)/8)
+	if _, err := rand.Read(k); err != nil {
+		t.Fatal(err)
+	}
+	for i := bitlen; i < 8*uint(len(k)); i++ {
+		k[i/8] &^= 1 << (i % 8)
+	}
+	return k
+}
+
+func forEachField(t *testing.T, test func(*testing.T, *Field)) {
+	for name, id := range map[string]uint8{
+		"P434": Fp434, "P503": Fp503, "P610": Fp610, "P751": Fp751} {
+		f := newField(t, id)
+		t.Run(name, func(t *testing.T) { test(t, f) })
+	}
+}
+
+func TestUnsupportedField(t *testing.T) {
+	if _, err := NewField(0xFF); err != ErrUnsupportedField {
+		t.Error("NewField must fail for unknown id")
+	}
+}
+
+func TestFieldArithmetic(t *testing.T) {
+	forEachField(t, func(t *testing.T, f *Field) {
+		var one = f.One()
+		var z, t1, t2 Fp2
+		var buf = make([]byte, 2*f.Bytelen())
+
+		for i := 0; i < 20; i++ {
+			x, y := randomFp2(t, f), randomFp2(t, f)
+
+			// Encoding round trip
+			f.Bytes(buf, &x)
+			if err := f.SetBytes(&z, buf); err != nil || !f.Equal(&x, &z) {
+				t.Fatal("Encoding round trip failed")
+			}
+
+			// (x+y)(x-y) = x^2 - y^2
+			f.Add(&t1, &x, &y)
+			f.Sub(&t2, &x, &y)
+			f.Mul(&t1, &t1, &t2)
+			f.Sqr(&t2, &x)
+			f.Sqr(&z, &y)
+			f.Sub(&t2, &t2, &z)
+			if !f.Equal(&t1, &t2) {
+				t.Fatal("(x+y)(x-y) != x^2-y^2")
+			}
+
+			// x * 1/x = 1
+			f.Inv(&t1, &x)
+			f.Mul(&t1, &t1, &x)
+			if !f.Equal(&t1, &one) {
+				t.Fatal("x * 1/x != 1")
+			}
+
+			// sqrt(x^2)^2 = x^2
+			f.Sqr(&t1, &x)
+			if !f.IsSquare(&t1) {
+				t.Fatal("x^2 is not a square")
+			}
+			f.Sqrt(&t2, &t1)
+			f.Sqr(&t2, &t2)
+			if !f.Equal(&t1, &t2) {
+				t.Fatal("sqrt(x^2)^2 != x^2")
+			}
+		}
+
+		// Non-canonical encodings
+		for i := range buf {
+			buf[i] = 0xFF
+		}
+		if err := f.SetBytes(&z, buf); err != ErrEncoding {
+			t.Error("Decoding of element bigger than p must fail")
+		}
+		if err := f.SetBytes(&z, buf[1:]); err != ErrEncoding {
+			t.Error("Decoding of element with wrong length must fail")
+		}
+	})
+}
+
+func TestJInvariant(t *testing.T) {
+	forEachField(t, func(t *testing.T, f *Field) {
+		var exp = make([]byte, 2*f.Bytelen())
+		var buf = make([]byte, 2*f.Bytelen())
+
+		// j(E_6) = 287496
+		exp[0], exp[1], exp[2] = 0x08, 0x63, 0x04
+		c := f.StartingCurve()
+		j := f.JInvariant(&c)
+		f.Bytes(buf, &j)
+		if !bytes.Equal(buf, exp) {
+			t.Errorf("Wrong j-invariant of the starting curve")
+		}
+
+		// Curve recovered from x-coordinates of P, Q and P-Q
+		P, Q, R := f.params.A.AffineP, f.params.A.AffineQ, f.params.A.AffineR
+		c = f.RecoverCurve(&P, &Q, &R)
+		a, a0 := f.AffineA(&c), f.params.InitCurve.A
+		if !f.Equal(&a, &a0) {
+			t.Errorf("Wrong curve recovered")
+		}
+	})
+}
+
+// Computes public key with Chain and compares it with the one computed
+// by internal implementation.
+func TestChain(t *testing.T) {
+	forEachField(t, func(t *testing.T, f *Field) {
+		var one = f.One()
+		e2, e3 := f.Exponents()
+
+		for _, isA := range []bool{true, false} {
+			var exp, pub [3]Fp2
+			var l, e, nbits = uint(3), e3, f.params.B.SecretBitLen
+			var prvDom, peerDom = &f.params.B, &f.params.A
+			var gen = publicKeyGen[f.ID()][1]
+			if isA {
+				l, e, nbits = 2, e2, f.params.A.SecretBitLen
+				prvDom, peerDom = peerDom, prvDom
+				gen = publicKeyGen[f.ID()][0]
+			}
+
+			prv := randomScalar(t, nbits)
+			gen(&exp, prv)
+
+			c := f.StartingCurve()
+			P := ProjectivePoint{X: prvDom.AffineP, Z: one}
+			Q := ProjectivePoint{X: prvDom.AffineQ, Z: one}
+			R := ProjectivePoint{X: prvDom.AffineR, Z: one}
+			xR := f.Ladder3Pt(&c, &P, &Q, &R, nbits, prv)
+
+			for _, strat := range [][]uint32{prvDom.IsogenyStrategy, nil} {
+				pts := []ProjectivePoint{
+					{X: peerDom.AffineP, Z: one},
+					{X: peerDom.AffineQ, Z: one},
+					{X: peerDom.AffineR, Z: one}}
+				if _, err := f.Chain(&c, &xR, l, e, strat, pts); err != nil {
+					t.Fatal(err)
+				}
+				for i := range pts {
+					f.Inv(&pub[i], &pts[i].Z)
+					f.Mul(&pub[i], &pub[i], &pts[i].X)
+					if !f.Equal(&pub[i], &exp[i]) {
+						t.Fatalf("l=%d: public key differs", l)
+					}
+				}
+			}
+		}
+
+		c := f.StartingCurve()
+		K := ProjectivePoint{X: one, Z: one}
+		if _, err := f.Chain(&c, &K, 5, 1, nil, nil); err != ErrDegree {
+			t.Error("Chain of 5-isogenies must fail")
+		}
+		if _, err := f.Chain(&c, &K, 3, 3, []uint32{1}, nil); err != ErrStrategy {
+			t.Error("Chain with wrong size of strategy must fail")
+		}
+	})
+}
+
+// Checks that points of the kernel are mapped to infinity.
+func TestIsogenyKernel(t *testing.T) {
+	forEachField(t, func(t *testing.T, f *Field) {
+		var one = f.One()
+		e2, e3 := f.Exponents()
+		c := f.StartingCurve()
+
+		// Point of order 3
+		K := ProjectivePoint{X: f.params.B.AffineP, Z: one}
+		f.Pow3k(&K, &c, e3-1)
+		phi, err := f.NewIsogeny(3, &K)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if phi.Degree() != 3 {
+			t.Error("Wrong degree")
+		}
+		if img := phi.Evaluate(&K); !f.IsZero(&img.Z) {
+			t.Error("Kernel of 3-isogeny not mapped to infinity")
+		}
+
+		// Codomain of phi contains image of point of order 2^e2
+		a := phi.Codomain()
+		P := ProjectivePoint{X: f.params.A.AffineP, Z: one}
+		P = phi.Evaluate(&P)
+		f.Pow2k(&P, &a, e2-1)
+		if f.IsZero(&P.Z) {
+			t.Error("Image of P has wrong order")
+		}
+		f.Pow2k(&P, &a, 1)
+		if !f.IsZero(&P.Z) {
+			t.Error("Image of P has wrong order")
+		}
+
+		if _, err := f.NewIsogeny(5, &K); err != ErrDegree {
+			t.Error("NewIsogeny of degree 5 must fail")
+		}
+	})
+}
+
+func TestPoints(t *testing.T) {
+	forEachField(t, func(t *testing.T, f *Field) {
+		var P, Q, PQ, T Point
+		var one = f.One()
+		c := f.StartingCurve()
+		a := f.AffineA(&c)
+
+		if !f.LiftX(&P, &f.params.B.AffineP, &a) {
+			t.Fatal("Can't lift x(P)")
+		}
+		if !f.LiftX(&Q, &f.params.B.AffineQ, &a) {
+			t.Fatal("Can't lift x(Q)")
+		}
+		f.AddPoints(&PQ, &P, &Q, &a)
+		f.SubPoints(&T, &PQ, &Q, &a)
+		if !f.Equal(&T.X, &P.X) || !f.Equal(&T.Y, &P.Y) {
+			t.Error("(P+Q)-Q != P")
+		}
+
+		// P + [k]Q agrees with three point ladder
+		k := randomScalar(t, 64)
+		xP := ProjectivePoint{X: P.X, Z: one}
+		xQ := ProjectivePoint{X: Q.X, Z: one}
+		f.SubPoints(&T, &P, &Q, &a)
+		xPmQ := ProjectivePoint{X: T.X, Z: one}
+		xR := f.Ladder3Pt(&c, &xP, &xQ, &xPmQ, 64, k)
+
+		for i, j := 0, len(k)-1; i < j; i, j = i+1, j-1 {
+			k[i], k[j] = k[j], k[i]
+		}
+		f.ScalarMul(&T, &Q, &a, new(big.Int).SetBytes(k))
+		f.AddPoints(&T, &T, &P, &a)
+		f.Mul(&xR.Z, &xR.Z, &T.X)
+		if !f.Equal(&xR.X, &xR.Z) {
+			t.Error("Scalar multiplication disagrees with the ladder")
+		}
+
+		// Recover Q from x(Q) and x(Q+P)
+		xPQ := ProjectivePoint{X: PQ.X, Z: one}
+		f.RecoverPoint(&T, &P, &xQ, &xPQ, &a)
+		f.ToAffine(&T)
+		if !f.Equal(&T.X, &Q.X) || !f.Equal(&T.Y, &Q.Y) {
+			t.Error("Wrong point recovered")
+		}
+	})
+}
+
+func TestOptimalStrategy(t *testing.T) {
+	// Every leaf of the tree must be visited exactly once,
+	// which is the case iff S(n) = [i] ++ S(n-i) ++ S(i).
+	var check func(s []uint32, n int) []uint32
+	check = func(s []uint32, n int) []uint32 {
+		if n < 2 || s == nil {
+			return s
+		}
+		if len(s) == 0 || int(s[0]) >= n || s[0] == 0 {
+			return nil
+		}
+		i := int(s[0])
+		return check(check(s[1:], n-i), i)
+	}
+
+	for _, n := range []int{1, 2, 3, 10, 108, 239} {
+		s := OptimalStrategy(n, 5, 3)
+		if len(s) != n-1 {
+			t.Fatalf("n=%d: wrong length of strategy", n)
+		}
+		if rest := check(s, n); rest == nil || len(rest) != 0 {
+			t.Errorf("n=%d: strategy is malformed", n)
+		}
+	}
+
+	if s := OptimalStrategy(0, 1, 1); s != nil {
+		t.Error("Strategy for empty chain must be nil")
+	}
+}
@@ -1,13 +1,16 @@
 package sidh
 
 import (
-	"errors"
+	"crypto/subtle"
 	"io"
 
 	"github.com/henrydcase/nobs/dh/sidh/common"
 	"github.com/henrydcase/nobs/dh/sidh/internal/p434"
 	"github.com/henrydcase/nobs/dh/sidh/internal/p503"
+	"github.com/henrydcase/nobs/dh/sidh/internal/p610"
 	"github.com/henrydcase/nobs/dh/sidh/internal/p751"
+	"github.com/henrydcase/nobs/hash/sha3"
+	"github.com/henrydcase/nobs/utils"
 )
 
 // I keep it bool in order to be able to apply logical NOT.
@@ -27,6 +30,8 @@ type PublicKey struct {
 	Key
 	// x-coordinates of P,Q,P-Q in this exact order
 	affine3Pt [3]common.Fp2
+	// Set by Import if some coordinate wasn't reduced mod p, see Validate
+	nonCanonical bool
 }
 
 // Defines operations on private key
@@ -36,6 +41,8 @@ type PrivateKey struct {
 	Scalar []byte
 	// Used only by KEM
 	S []byte
+	// Memory backing Scalar and S, nil if those were set by the caller
+	secbuf *utils.SecureBuffer
 }
 
 // Id's correspond to bitlength of the prime field characteristic
@@ -43,6 +50,7 @@ type PrivateKey struct {
 const (
 	Fp434 = common.Fp434
 	Fp503 = common.Fp503
+	Fp610 = common.Fp610
 	Fp751 = common.Fp751
 )
 
@@ -58,6 +66,10 @@ const (
 	KeyVariantSike = 1<<2 | KeyVariantSidhB
 )
 
+// MinSeedSize is the minimal size of a seed used for deterministic
+// generation of keys and ciphertexts.
+const MinSeedSize = 32
+
 // Accessor to key variant.
 func (key *Key) Variant() KeyVariant {
 	return key.KeyVariant
@@ -65,18 +77,26 @@ func (key *Key) Variant() KeyVariant {
 
 // NewPublicKey initializes public key.
 // Usage of this function guarantees that the object is correctly initialized.
+// Function panics if field 'id' isn't registered.
 func NewPublicKey(id uint8, v KeyVariant) *PublicKey {
 	return &PublicKey{Key: Key{Params: common.Params(id), KeyVariant: v}}
 }
 
 // Import clears content of the public key currently stored in the structure
-// and imports key stored in the byte string. Returns error in case byte string
-// size is wrong. Doesn't perform any validation.
+// and imports key stored in the byte string. Returns ErrBufferSize in case
+// byte string size is wrong or ErrUnsupportedField if key uses unknown field.
+// Doesn't perform any validation, keys coming from untrusted source should
+// be checked with Validate.
 func (pub *PublicKey) Import(input []byte) error {
 	if len(input) != pub.Size() {
-		return errors.New("sidh: input to short")
+		return ErrBufferSize
+	}
+	if !isSupported(pub.Params.ID) {
+		return ErrUnsupportedField
 	}
 	ssSz := pub.Params.SharedSecretSize
+	// BytesToFp2 doesn't overwrite, it ORs bytes into the destination
+	pub.affine3Pt = [3]common.Fp2{}
 	common.BytesToFp2(&pub.affine3Pt[0], input[0:ssSz], pub.Params.Bytelen)
 	common.BytesToFp2(&pub.affine3Pt[1], input[ssSz:2*ssSz], pub.Params.Bytelen)
 	common.BytesToFp2(&pub.affine3Pt[2], input[2*ssSz:3*ssSz], pub.Params.Bytelen)
@@ -89,21 +109,33 @@ func (pub *PublicKey) Import(input []byte) error {
 		p503.ToMontgomery(&pub.affine3Pt[0], &pub.affine3Pt[0])
 		p503.ToMontgomery(&pub.affine3Pt[1], &pub.affine3Pt[1])
 		p503.ToMontgomery(&pub.affine3Pt[2], &pub.affine3Pt[2])
+	case Fp610:
+		p610.ToMontgomery(&pub.affine3Pt[0], &pub.affine3Pt[0])
+		p610.ToMontgomery(&pub.affine3Pt[1], &pub.affine3Pt[1])
+		p610.ToMontgomery(&pub.affine3Pt[2], &pub.affine3Pt[2])
 	case Fp751:
 		p751.ToMontgomery(&pub.affine3Pt[0], &pub.affine3Pt[0])
 		p751.ToMontgomery(&pub.affine3Pt[1], &pub.affine3Pt[1])
 		p751.ToMontgomery(&pub.affine3Pt[2], &pub.affine3Pt[2])
-	default:
-		panic("Unsupported key")
 	}
+
+	// Export encodes reduced coordinates, hence it gives back the input
+	// only if encoding was canonical.
+	var enc [3 * common.MaxSharedSecretBsz]byte
+	_ = pub.Export(enc[:])
+	pub.nonCanonical = subtle.ConstantTimeCompare(enc[:len(input)], input) != 1
 	return nil
 }
 
 // Exports currently stored key. In case structure hasn't been filled with key data
-// returned byte string is filled with zeros.
-func (pub *PublicKey) Export(out []byte) {
+// returned byte string is filled with zeros. Returns ErrBufferSize if 'out' is
+// shorter than Size() or ErrUnsupportedField if key uses unknown field.
+func (pub *PublicKey) Export(out []byte) error {
 	var feTmp [3]common.Fp2
 	ssSz := pub.Params.SharedSecretSize
+	if len(out) < pub.Size() {
+		return ErrBufferSize
+	}
 	switch pub.Params.ID {
 	case Fp434:
 		p434.FromMontgomery(&feTmp[0], &pub.affine3Pt[0])
@@ -113,16 +145,21 @@ func (pub *PublicKey) Export(out []byte) {
 		p503.FromMontgomery(&feTmp[0], &pub.affine3Pt[0])
 		p503.FromMontgomery(&feTmp[1], &pub.affine3Pt[1])
 		p503.FromMontgomery(&feTmp[2], &pub.affine3Pt[2])
+	case Fp610:
+		p610.FromMontgomery(&feTmp[0], &pub.affine3Pt[0])
+		p610.FromMontgomery(&feTmp[1], &pub.affine3Pt[1])
+		p610.FromMontgomery(&feTmp[2], &pub.affine3Pt[2])
 	case Fp751:
 		p751.FromMontgomery(&feTmp[0], &pub.affine3Pt[0])
 		p751.FromMontgomery(&feTmp[1], &pub.affine3Pt[1])
 		p751.FromMontgomery(&feTmp[2], &pub.affine3Pt[2])
 	default:
-		panic("Unsupported key")
+		return ErrUnsupportedField
 	}
 	common.Fp2ToBytes(out[0:ssSz], &feTmp[0], pub.Params.Bytelen)
 	common.Fp2ToBytes(out[ssSz:2*ssSz], &feTmp[1], pub.Params.Bytelen)
 	common.Fp2ToBytes(out[2*ssSz:3*ssSz], &feTmp[2], pub.Params.Bytelen)
+	return nil
 }
 
 // Size returns size of the public key in bytes.
@@ -130,26 +167,158 @@ func (pub *PublicKey) Size() int {
 	return pub.Params.PublicKeySize
 }
 
+// compressedSize returns size of the compressed public key in bytes.
+func (pub *PublicKey) compressedSize() int {
+	if (pub.KeyVariant & KeyVariantSidhA) == KeyVariantSidhA {
+		return pub.Params.A.CompressedPublicKeySize
+	}
+	return pub.Params.B.CompressedPublicKeySize
+}
+
+// exportCompressed exports currently stored key in compressed form. Size of
+// the output is compressedSize(). Returns ErrBufferSize in case out is too
+// short, ErrUnsupportedField if key uses unknown field or error if key can't
+// be compressed, which happens only if key is malformed.
+// Compression isn't constant time, it must be used with public data only.
+func (pub *PublicKey) exportCompressed(out []byte) error {
+	var err error
+	var isA = (pub.KeyVariant & KeyVariantSidhA) == KeyVariantSidhA
+
+	if len(out) < pub.compressedSize() {
+		return ErrBufferSize
+	}
+
+	switch pub.Params.ID {
+	case Fp434:
+		if isA {
+			err = p434.CompressPublicKeyA(out, &pub.affine3Pt)
+		} else {
+			err = p434.CompressPublicKeyB(out, &pub.affine3Pt)
+		}
+	case Fp503:
+		if isA {
+			err = p503.CompressPublicKeyA(out, &pub.affine3Pt)
+		} else {
+			err = p503.CompressPublicKeyB(out, &pub.affine3Pt)
+		}
+	case Fp610:
+		if isA {
+			err = p610.CompressPublicKeyA(out, &pub.affine3Pt)
+		} else {
+			err = p610.CompressPublicKeyB(out, &pub.affine3Pt)
+		}
+	case Fp751:
+		if isA {
+			err = p751.CompressPublicKeyA(out, &pub.affine3Pt)
+		} else {
+			err = p751.CompressPublicKeyB(out, &pub.affine3Pt)
+		}
+	default:
+		return ErrUnsupportedField
+	}
+	return err
+}
+
+// importCompressed clears content of the public key currently stored in the
+// structure and imports key compressed with exportCompressed. Imported key
+// consists of different points than the key which was compressed, but both
+// keys produce the same shared secrets. Returns ErrBufferSize in case byte
+// string size is wrong, ErrUnsupportedField if key uses unknown field or
+// error if input doesn't encode valid compressed key.
+func (pub *PublicKey) importCompressed(input []byte) error {
+	var err error
+	var isA = (pub.KeyVariant & KeyVariantSidhA) == KeyVariantSidhA
+
+	if len(input) != pub.compressedSize() {
+		return ErrBufferSize
+	}
+
+	switch pub.Params.ID {
+	case Fp434:
+		if isA {
+			err = p434.DecompressPublicKeyA(&pub.affine3Pt, input)
+		} else {
+			err = p434.DecompressPublicKeyB(&pub.affine3Pt, input)
+		}
+	case Fp503:
+		if isA {
+			err = p503.DecompressPublicKeyA(&pub.affine3Pt, input)
+		} else {
+			err = p503.DecompressPublicKeyB(&pub.affine3Pt, input)
+		}
+	case Fp610:
+		if isA {
+			err = p610.DecompressPublicKeyA(&pub.affine3Pt, input)
+		} else {
+			err = p610.DecompressPublicKeyB(&pub.affine3Pt, input)
+		}
+	case Fp751:
+		if isA {
+			err = p751.DecompressPublicKeyA(&pub.affine3Pt, input)
+		} else {
+			err = p751.DecompressPublicKeyB(&pub.affine3Pt, input)
+		}
+	default:
+		return ErrUnsupportedField
+	}
+	pub.nonCanonical = false
+	return err
+}
+
 // NewPrivateKey initializes private key.
 // Usage of this function guarantees that the object is correctly initialized.
+// Secret values are stored in memory allocated with utils.SecureBuffer,
+// caller should call Destroy when key is not needed anymore. Function panics
+// if field 'id' isn't registered.
 func NewPrivateKey(id uint8, v KeyVariant) *PrivateKey {
 	prv := &PrivateKey{Key: Key{Params: common.Params(id), KeyVariant: v}}
-	if (v & KeyVariantSidhA) == KeyVariantSidhA {
-		prv.Scalar = make([]byte, prv.Params.A.SecretByteLen)
+	prv.allocSecrets()
+	return prv
+}
+
+// allocSecrets allocates Scalar and S (SIKE only) in a single
+// utils.SecureBuffer. Params and KeyVariant must be set.
+func (prv *PrivateKey) allocSecrets() {
+	var scalarLen, sLen int
+
+	if (prv.KeyVariant & KeyVariantSidhA) == KeyVariantSidhA {
+		scalarLen = int(prv.Params.A.SecretByteLen)
 	} else {
-		prv.Scalar = make([]byte, prv.Params.B.SecretByteLen)
+		scalarLen = int(prv.Params.B.SecretByteLen)
 	}
-	if v == KeyVariantSike {
-		prv.S = make([]byte, prv.Params.MsgLen)
+	if prv.KeyVariant == KeyVariantSike {
+		sLen = prv.Params.MsgLen
 	}
-	return prv
+
+	prv.secbuf = utils.NewSecureBuffer(sLen + scalarLen)
+	buf := prv.secbuf.Bytes()
+	if prv.KeyVariant == KeyVariantSike {
+		prv.S = buf[:sLen:sLen]
+	}
+	prv.Scalar = buf[sLen:]
+}
+
+// Destroy zeroizes secret values and releases memory allocated for them.
+// Key must not be used after call to this function.
+func (prv *PrivateKey) Destroy() {
+	utils.Zeroize(prv.Scalar)
+	utils.Zeroize(prv.S)
+	if prv.secbuf != nil {
+		prv.secbuf.Destroy()
+	}
+	prv.Scalar, prv.S, prv.secbuf = nil, nil, nil
 }
 
 // Exports currently stored key. In case structure hasn't been filled with key data
-// returned byte string is filled with zeros.
-func (prv *PrivateKey) Export(out []byte) {
+// returned byte string is filled with zeros. Returns ErrBufferSize if 'out' is
+// shorter than Size().
+func (prv *PrivateKey) Export(out []byte) error {
+	if len(out) < prv.Size() {
+		return ErrBufferSize
+	}
 	copy(out, prv.S)
 	copy(out[len(prv.S):], prv.Scalar)
+	return nil
 }
 
 // Size returns size of the private key in bytes.
@@ -169,10 +338,11 @@ func (prv *PrivateKey) SharedSecretSize() int {
 // Import clears content of the private key currently stored in the structure
 // and imports key from octet string. In case of SIKE, the random value 'S'
 // must be prepended to the value of actual private key (see SIKE spec for details).
-// Function doesn't import public key value to PrivateKey object.
+// Function doesn't import public key value to PrivateKey object. Returns
+// ErrBufferSize if size of the input is wrong.
 func (prv *PrivateKey) Import(input []byte) error {
 	if len(input) != prv.Size() {
-		return errors.New("sidh: input to short")
+		return ErrBufferSize
 	}
 	copy(prv.S, input[:len(prv.S)])
 	copy(prv.Scalar, input[len(prv.S):])
@@ -210,23 +380,55 @@ func (prv *PrivateKey) Generate(rand io.Reader) error {
 		return err
 	}
 
-	prv.Scalar[len(prv.Scalar)-1] &= (1 << (dp.SecretBitLen % 8)) - 1
+	// Number of bits used in the last byte. It is 8 when SecretBitLen is
+	// a multiple of 8 and 0 when key-space has an extra byte (p434).
+	msb := dp.SecretBitLen - 8*(dp.SecretByteLen-1)
+	prv.Scalar[len(prv.Scalar)-1] &= (1 << msb) - 1
 	// Make sure scalar is SecretBitLen long. SIKE spec says that key
 	// space starts from 0, but I'm not comfortable with having low
 	// value scalars used for private keys. It is still secrure as per
 	// table 5.1 in [SIKE].
-	prv.Scalar[len(prv.Scalar)-1] |= 1 << ((dp.SecretBitLen % 8) - 1)
+	prv.Scalar[len(prv.Scalar)-1] |= 1 << (msb - 1)
 
 	return nil
 }
 
-// Generates public key.
-func (prv *PrivateKey) GeneratePublicKey(pub *PublicKey) {
+// DeriveKeyPair deterministically generates private key and corresponding
+// public key from a seed. Seed is expanded with SHAKE256 and the output
+// is consumed by Generate as if it was read from random source, so it
+// follows the seed-based pattern of the NIST KAT generator. The same seed
+// always gives the same key pair. Seed must be secret, uniformly random
+// and at least MinSeedSize bytes long, otherwise ErrBufferSize is returned.
+// Other errors are the same as for GeneratePublicKey.
+func (prv *PrivateKey) DeriveKeyPair(pub *PublicKey, seed []byte) error {
+	if len(seed) < MinSeedSize {
+		return ErrBufferSize
+	}
+	h := sha3.NewShake256()
+	_, _ = h.Write(seed)
+	if err := prv.Generate(h); err != nil {
+		return err
+	}
+	return prv.GeneratePublicKey(pub)
+}
+
+// Generates public key. Returns ErrWrongVariant if 'pub' has different
+// variant than 'prv', ErrParamMismatch if keys use different fields,
+// ErrBufferSize if scalar of 'prv' has wrong size or ErrUnsupportedField
+// if key uses unknown field.
+func (prv *PrivateKey) GeneratePublicKey(pub *PublicKey) error {
 	var isA = (prv.KeyVariant & KeyVariantSidhA) == KeyVariantSidhA
 
-	if (pub.KeyVariant != prv.KeyVariant) || (pub.Params.ID != prv.Params.ID) {
-		panic("sidh: incompatbile public key")
+	if pub.KeyVariant != prv.KeyVariant {
+		return ErrWrongVariant
+	}
+	if pub.Params.ID != prv.Params.ID {
+		return ErrParamMismatch
+	}
+	if len(prv.Scalar) != prv.scalarSize() {
+		return ErrBufferSize
 	}
+	pub.nonCanonical = false
 
 	switch prv.Params.ID {
 	case Fp434:
@@ -241,6 +443,12 @@ func (prv *PrivateKey) GeneratePublicKey(pub *PublicKey) {
 		} else {
 			p503.PublicKeyGenB(&pub.affine3Pt, prv.Scalar)
 		}
+	case Fp610:
+		if isA {
+			p610.PublicKeyGenA(&pub.affine3Pt, prv.Scalar)
+		} else {
+			p610.PublicKeyGenB(&pub.affine3Pt, prv.Scalar)
+		}
 	case Fp751:
 		if isA {
 			p751.PublicKeyGenA(&pub.affine3Pt, prv.Scalar)
@@ -248,20 +456,31 @@ func (prv *PrivateKey) GeneratePublicKey(pub *PublicKey) {
 			p751.PublicKeyGenB(&pub.affine3Pt, prv.Scalar)
 		}
 	default:
-		panic("Field not supported")
+		return ErrUnsupportedField
 	}
+	return nil
 }
 
-// Computes a SIDH shared secret. Function requires that pub has different
-// KeyVariant than prv. Length of returned output is 2*ceil(log_2 P)/8),
-// where P is a prime defining finite field.
+// Computes a SIDH shared secret. Function requires that pub and prv
+// correspond to different torsion groups, otherwise ErrWrongVariant is
+// returned. Length of returned output is 2*ceil(log_2 P)/8), where P is a
+// prime defining finite field. Returns ErrParamMismatch if keys use
+// different fields, ErrBufferSize if 'ss' is shorter than SharedSecretSize()
+// or scalar of 'prv' has wrong size, or ErrUnsupportedField if key uses
+// unknown field.
 //
 // Caller must make sure key SIDH key pair is not used more than once.
-func (prv *PrivateKey) DeriveSecret(ss []byte, pub *PublicKey) {
+func (prv *PrivateKey) DeriveSecret(ss []byte, pub *PublicKey) error {
 	var isA = (prv.KeyVariant & KeyVariantSidhA) == KeyVariantSidhA
 
-	if (pub.KeyVariant == prv.KeyVariant) || (pub.Params.ID != prv.Params.ID) {
-		panic("sidh: public and private are incompatbile")
+	if isA == ((pub.KeyVariant & KeyVariantSidhA) == KeyVariantSidhA) {
+		return ErrWrongVariant
+	}
+	if pub.Params.ID != prv.Params.ID {
+		return ErrParamMismatch
+	}
+	if len(ss) < prv.SharedSecretSize() || len(prv.Scalar) != prv.scalarSize() {
+		return ErrBufferSize
 	}
 
 	switch prv.Params.ID {
@@ -277,6 +496,12 @@ func (prv *PrivateKey) DeriveSecret(ss []byte, pub *PublicKey) {
 		} else {
 			p503.DeriveSecretB(ss, prv.Scalar, &pub.affine3Pt)
 		}
+	case Fp610:
+		if isA {
+			p610.DeriveSecretA(ss, prv.Scalar, &pub.affine3Pt)
+		} else {
+			p610.DeriveSecretB(ss, prv.Scalar, &pub.affine3Pt)
+		}
 	case Fp751:
 		if isA {
 			p751.DeriveSecretA(ss, prv.Scalar, &pub.affine3Pt)
@@ -284,8 +509,22 @@ func (prv *PrivateKey) DeriveSecret(ss []byte, pub *PublicKey) {
 			p751.DeriveSecretB(ss, prv.Scalar, &pub.affine3Pt)
 		}
 	default:
-		panic("Field not supported")
+		return ErrUnsupportedField
 	}
+	return nil
+}
+
+// scalarSize returns size of the private scalar in bytes.
+func (prv *PrivateKey) scalarSize() int {
+	if (prv.KeyVariant & KeyVariantSidhA) == KeyVariantSidhA {
+		return int(prv.Params.A.SecretByteLen)
+	}
+	return int(prv.Params.B.SecretByteLen)
+}
+
+// isSupported returns true if field 'id' is implemented by the package.
+func isSupported(id uint8) bool {
+	return id == Fp434 || id == Fp503 || id == Fp610 || id == Fp751
 }
 
 // Alternative API
@@ -298,26 +537,28 @@ func (c *Key) Init(fieldID uint8, v KeyVariant) {
 	c.KeyVariant = v
 }
 
+// Init initializes private key. Secret values are stored in memory
+// allocated with utils.SecureBuffer, caller should call Destroy when key
+// is not needed anymore. Memory held by previously initialized key is
+// released.
 func (c *PrivateKey) Init(fieldId uint8, v KeyVariant) {
+	c.Destroy()
 	c.Key.Init(fieldId, v)
-	if (v & KeyVariantSidhA) == KeyVariantSidhA {
-		c.Scalar = make([]byte, c.Params.A.SecretByteLen)
-	} else {
-		c.Scalar = make([]byte, c.Params.B.SecretByteLen)
-	}
-	if v == KeyVariantSike {
-		c.S = make([]byte, c.Params.MsgLen)
-	}
+	c.allocSecrets()
+}
+
+func GeneratePrivateKey(prv *PrivateKey, rng io.Reader) error {
+	return prv.Generate(rng)
 }
 
-func GeneratePrivateKey(prv *PrivateKey, rng io.Reader) {
-	prv.Generate(rng)
+func GeneratePublicKey(pub *PublicKey, prv *PrivateKey) error {
+	return prv.GeneratePublicKey(pub)
 }
 
-func GeneratePublicKey(pub *PublicKey, prv *PrivateKey) {
-	prv.GeneratePublicKey(pub)
+func DeriveSecret(ss []byte, pub *PublicKey, prv *PrivateKey) error {
+	return prv.DeriveSecret(ss, pub)
 }
 
-func DeriveSecret(ss []byte, pub *PublicKey, prv *PrivateKey) {
-	prv.DeriveSecret(ss, pub)
+func DeriveKeyPair(prv *PrivateKey, pub *PublicKey, seed []byte) error {
+	return prv.DeriveKeyPair(pub, seed)
 }
@@ -6,9 +6,11 @@ import (
 	"encoding/hex"
 	"fmt"
 	"math/big"
+	"runtime"
 	"testing"
 
 	"github.com/henrydcase/nobs/dh/sidh/common"
+	"github.com/henrydcase/nobs/dh/sidh/isogeny"
 )
 
 /* -------------------------------------------------------------------------
@@ -91,6 +93,44 @@ var tdataSidh = map[uint8]sidhVec{
 			"21CAA429A1490AE1D6E0CA9D6BC4BCD14B1CFE694226D03E8731E9E0B3760877" +
 			"7D56630B31298CC05B6FF6C1A08935312D8E95B8056AD7831A22",
 	},
+	Fp610: {
+		id:   Fp610,
+		name: "P-610",
+		PrA: "F8E40EB4CA11F1155107AF3F21F529B21F0DB6A2A02E1A8AAE3C2F6290FFC4AA" +
+			"D8C42E94EA3101",
+		PrB: "A279CD51DE23D75596D7BD270ADAAD659F7BAB2BDD912C2F26AA69BAF20536E3" +
+			"8259ED71FE83",
+		PkA: "30B49DAB76C4725EE64B1FF820FF2EABAC3F22AEB71B515CCFE14C4E3C5CEA5E" +
+			"83054AC6FC37E9CB605B83A7C45D395D1234DDE7CB6D5814F87C1E74F8FA3B37" +
+			"9EFDF4875F44E084AFB761FF01E2A363D5689EF4A13FA84298778DAE36211AFD" +
+			"334094B9052BF40577CF6698C0EDF118268F848F64903C63DA98F074DF59DD59" +
+			"FBD5A50DA50BB7719DB61FB0BAE847B57551FF0907B80B242600552D8AB7CF7A" +
+			"15DB333C24CC94BEA0929EB9761F761B28D1266373D5A2BCDFA6278D656898C0" +
+			"A675E4DDB5F36BC4832D248F6480378885BACC484325274218B221D333F08E59" +
+			"ED5A5F4D1FD5015D95BCFB1C819E369AFFD5BF7C18C1C3E2B45295E470A9B273" +
+			"9FCA2417564587E72923FB3E78EC867610D22ED62371C84E0740B9FC482D8498" +
+			"C6E9DAD15D966399972A023C443F6593F66C6C0027B5DFAA5CFB5DC5B8CC078B" +
+			"52FE9DD8CBEE33A3ED06B80A67E3863D61EF3F03BE14269079D3FCB43C89CE0E" +
+			"4558716C1CE35D553D2583102C8D59A2E84C62FD0D7020268FCDCFFBAE19EA8B" +
+			"007A210ECFFE9C4C17EB21F1414F7B4CD2F97B9E231C09819CACD12FF5AFED17" +
+			"E3DC343C8AEC948C6366E3E1CDA89804E9205D67B84EF7CF073FE21610135D01" +
+			"CF292FF981507082E49339BE8100",
+		PkB: "49C102FB8D49C81544B1E1A035A1CAF17FEA2FB9266078DD3DC94DE28B434256" +
+			"5C59CEB8A955C30DEE49FE929495FD18590ADFBF52F2F6496F5850C77488DBFC" +
+			"A0C4498C24A623ADF2D155CF002C7966645D6E381035F2482402DB2E9B5D529E" +
+			"60F829896AB5F1C21C58F730CE23CD3144EE878BC793A9CD3F424A61A2CD0092" +
+			"74DE66CC7AC4D287AAA43A653AA40288C5F7A6493D142C894300E8E982DD8FD6" +
+			"AEB883813A59BCF16DB4D47853559046DB742F20B5971BBBC52936728DF8C349" +
+			"391D70BCECEC7154AA03B43518EDBAA2754B4E404394C29F4F2BD6508266FB7C" +
+			"BBF2DCAE8D2C01D90CD56409060B7674F790BA701C25E0DC20DBA5D5D8BD2704" +
+			"986A0CE016830DB233461593D87CD3EDD708BE56CF89C3F98068392369927C65" +
+			"9FE3B081DC053051D184F259678E30B505503D013D532BB0B79D588D307207BE" +
+			"1BC3B5DEA6EACCDB241D3D37DC3B10057BBB4E97AA2C23075273FFA558029D60" +
+			"0C9B1D1B7AE4BDBC3A2972A5E20739092CA14DC9419FAC6F9F7DD05572806814" +
+			"0041F7A4E3E2E3C683ACC40DEF6A445C53612AF242E40B3468124AE83E9E63E3" +
+			"1670D16A09AD107E80657234F5CB2521A19BB1D76FC1827388BFCB6A87306D91" +
+			"96FB678F8991E29774F3D2E60901",
+	},
 	Fp751: {
 		id:   Fp751,
 		name: "P-751",
@@ -302,6 +342,193 @@ func testImportExport(t *testing.T, v sidhVec) {
 	}
 }
 
+// Wrong variants, parameter sets and buffer sizes are reported with errors.
+func testErrors(t *testing.T, v sidhVec) {
+	var ss [common.MaxSharedSecretBsz]byte
+	var other = Fp434
+	if v.id == Fp434 {
+		other = Fp503
+	}
+
+	prvA := convToPrv(v.PrA, KeyVariantSidhA, v.id)
+	pubA := NewPublicKey(v.id, KeyVariantSidhA)
+	pubB := convToPub(v.PkB, KeyVariantSidhB, v.id)
+	checkErr(t, prvA.GeneratePublicKey(pubA), "public key generation failed")
+
+	if prvA.GeneratePublicKey(NewPublicKey(v.id, KeyVariantSidhB)) != ErrWrongVariant {
+		t.Error("GeneratePublicKey must fail for public key of other variant")
+	}
+	if prvA.GeneratePublicKey(NewPublicKey(other, KeyVariantSidhA)) != ErrParamMismatch {
+		t.Error("GeneratePublicKey must fail for public key of other field")
+	}
+	if prvA.DeriveSecret(ss[:], pubA) != ErrWrongVariant {
+		t.Error("DeriveSecret must fail for keys of the same variant")
+	}
+	if prvA.DeriveSecret(ss[:], NewPublicKey(other, KeyVariantSidhB)) != ErrParamMismatch {
+		t.Error("DeriveSecret must fail for keys of different fields")
+	}
+	if prvA.DeriveSecret(ss[:prvA.SharedSecretSize()-1], pubB) != ErrBufferSize {
+		t.Error("DeriveSecret must fail for too short output")
+	}
+
+	buf := make([]byte, pubA.Size())
+	if pubA.Export(buf[1:]) != ErrBufferSize || pubA.Import(buf[1:]) != ErrBufferSize {
+		t.Error("Export and Import must fail for wrong size of buffer")
+	}
+	if prvA.Export(buf[:prvA.Size()-1]) != ErrBufferSize || prvA.Import(buf[1:]) != ErrBufferSize {
+		t.Error("Export and Import of private key must fail for wrong size of buffer")
+	}
+
+	// Key using field unknown to the package
+	unknown := *pubA
+	params := *pubA.Params
+	params.ID = 0xFF
+	unknown.Params = &params
+	if unknown.Export(buf) != ErrUnsupportedField || unknown.Import(buf) != ErrUnsupportedField {
+		t.Error("Export and Import must fail for unknown field")
+	}
+}
+
+func testValidate(t *testing.T, v sidhVec) {
+	pubA := convToPub(v.PkA, KeyVariantSidhA, v.id)
+	pubB := convToPub(v.PkB, KeyVariantSidhB, v.id)
+	checkErr(t, pubA.Validate(), "public key A must be valid")
+	checkErr(t, pubB.Validate(), "public key B must be valid")
+
+	prv := NewPrivateKey(v.id, KeyVariantSike)
+	pub := NewPublicKey(v.id, KeyVariantSike)
+	checkErr(t, prv.Generate(rand.Reader), "private key generation failed")
+	checkErr(t, prv.GeneratePublicKey(pub), "public key generation failed")
+	checkErr(t, pub.Validate(), "generated public key must be valid")
+
+	// Key of one variant is not valid for the other one
+	enc := make([]byte, pubA.Size())
+	checkErr(t, pubA.Export(enc), "export failed")
+	wrong := NewPublicKey(v.id, KeyVariantSidhB)
+	checkErr(t, wrong.Import(enc), "import failed")
+	if wrong.Validate() != ErrInvalidPublicKey {
+		t.Error("public key of other variant must be rejected")
+	}
+
+	invalid := func(msg string, enc []byte) {
+		t.Helper()
+		key := NewPublicKey(v.id, KeyVariantSidhA)
+		checkErr(t, key.Import(enc), "import failed")
+		if key.Validate() != ErrInvalidPublicKey {
+			t.Error(msg)
+		}
+	}
+	ssSz := pubA.Params.SharedSecretSize
+	bad := make([]byte, len(enc))
+
+	// Coordinate not reduced mod p
+	copy(bad, enc)
+	for i := 0; i < pubA.Params.Bytelen; i++ {
+		bad[i] = 0xFF
+	}
+	invalid("non-canonical encoding must be rejected", bad)
+
+	// x(P) = 0
+	copy(bad, enc)
+	for i := 0; i < ssSz; i++ {
+		bad[i] = 0
+	}
+	invalid("zero coordinate must be rejected", bad)
+
+	// P = Q, hence P-Q is the point at infinity and P, Q are dependent
+	copy(bad, enc)
+	copy(bad[ssSz:2*ssSz], enc[:ssSz])
+	invalid("dependent points must be rejected", bad)
+
+	// Random coordinates
+	copy(bad, enc)
+	_, _ = rand.Read(bad[2*ssSz+1 : 2*ssSz+8])
+	invalid("random coordinates must be rejected", bad)
+
+	// Supersingular curves with (p+1)^2 points are accepted: curves of
+	// the public keys, y^2 = x^3 + 6x^2 + x and y^2 = x^3 + x. Ordinary
+	// curves y^2 = x^3 + Ax^2 + x with A = 3, 5, 7 are rejected.
+	f, err := isogeny.NewField(v.id)
+	checkErr(t, err, "unsupported field")
+	for _, k := range []*PublicKey{pubA, pubB} {
+		c := f.RecoverCurve(&k.affine3Pt[0], &k.affine3Pt[1], &k.affine3Pt[2])
+		a := f.AffineA(&c)
+		ok, err := isSupersingular(f, &c, &a, rand.Reader)
+		checkErr(t, err, "random point sampling failed")
+		if !ok {
+			t.Error("curve of the public key must be supersingular")
+		}
+	}
+	for _, k := range []struct {
+		a             byte
+		supersingular bool
+	}{{6, true}, {0, true}, {3, false}, {5, false}, {7, false}} {
+		var a isogeny.Fp2
+		enc := make([]byte, 2*f.Bytelen())
+		enc[0] = k.a
+		checkErr(t, f.SetBytes(&a, enc), "encoding of A failed")
+		c := isogeny.Curve{A: a, C: f.One()}
+		ok, err := isSupersingular(f, &c, &a, rand.Reader)
+		checkErr(t, err, "random point sampling failed")
+		if ok != k.supersingular {
+			t.Errorf("supersingularity of curve with A=%d: got %v", k.a, ok)
+		}
+	}
+
+	// Importing valid key after invalid one resets the state
+	key := NewPublicKey(v.id, KeyVariantSidhA)
+	for i := 0; i < pubA.Params.Bytelen; i++ {
+		bad[i] = 0xFF
+	}
+	_ = key.Import(bad)
+	checkErr(t, key.Import(enc), "import failed")
+	checkErr(t, key.Validate(), "public key A must be valid after reimport")
+}
+
+func testImportExportCompressed(t *testing.T, v sidhVec) {
+	var s1, s2 [common.MaxSharedSecretBsz]byte
+	for _, k := range []struct {
+		pubVariant, prvVariant KeyVariant
+		pk, prv                string
+	}{
+		{KeyVariantSidhA, KeyVariantSidhB, v.PkA, v.PrB},
+		{KeyVariantSidhB, KeyVariantSidhA, v.PkB, v.PrA},
+	} {
+		pub := convToPub(k.pk, k.pubVariant, v.id)
+		prv := convToPrv(k.prv, k.prvVariant, v.id)
+		dec := NewPublicKey(v.id, k.pubVariant)
+
+		buf := make([]byte, pub.compressedSize())
+		checkErr(t, pub.exportCompressed(buf), "compression failed")
+		checkErr(t, dec.importCompressed(buf), "decompression failed")
+
+		// Compressed form is canonical
+		buf2 := make([]byte, dec.compressedSize())
+		checkErr(t, dec.exportCompressed(buf2), "compression failed")
+		if !bytes.Equal(buf, buf2) {
+			t.Fatalf("compression of decompressed key differs")
+		}
+
+		// Decompressed key produces the same shared secret
+		prv.DeriveSecret(s1[:], pub)
+		prv.DeriveSecret(s2[:], dec)
+		if !bytes.Equal(s1[:], s2[:]) {
+			t.Fatalf("shared secrets computed with decompressed key differ")
+		}
+
+		if dec.importCompressed(buf[1:]) == nil {
+			t.Fatalf("import of too short key must fail")
+		}
+		if pub.exportCompressed(buf[1:]) == nil {
+			t.Fatalf("export to too short buffer must fail")
+		}
+		buf[len(buf)-1] = 2
+		if dec.importCompressed(buf) == nil {
+			t.Fatalf("import of malformed key must fail")
+		}
+	}
+}
+
 func testPrivateKeyBelowMax(t *testing.T, vec sidhVec) {
 	for variant, keySz := range map[KeyVariant]*common.DomainParams{
 		KeyVariantSidhA: &common.Params(vec.id).A,
@@ -364,6 +591,48 @@ func TestKeyAgreementP751_AliceEvenNumber(t *testing.T) {
 	testKeyAgreement(t, v)
 }
 
+func testDestroy(t *testing.T, vec sidhVec) {
+	for _, v := range []KeyVariant{KeyVariantSidhA, KeyVariantSidhB, KeyVariantSike} {
+		var initPrv PrivateKey
+		initPrv.Init(vec.id, v)
+		for _, prv := range []*PrivateKey{NewPrivateKey(vec.id, v), &initPrv} {
+			checkErr(t, prv.Generate(rand.Reader), "Private key generation")
+			if prv.secbuf == nil {
+				t.Error("Secret values not allocated with SecureBuffer")
+			}
+			scalar, s := prv.Scalar, prv.S
+			prv.Destroy()
+			if prv.Scalar != nil || prv.S != nil {
+				t.Error("Private key not released")
+			}
+			if !bytes.Equal(scalar, make([]byte, len(scalar))) || !bytes.Equal(s, make([]byte, len(s))) {
+				t.Error("Secret values not zeroized")
+			}
+			// Must be safe
+			prv.Destroy()
+		}
+	}
+}
+
+// Secret values must stay valid as long as they are referenced, even if
+// the key itself has been garbage collected.
+func testSecretsOutliveKey(t *testing.T, vec sidhVec) {
+	var scalars, exp [][]byte
+	for _, v := range []KeyVariant{KeyVariantSidhA, KeyVariantSidhB, KeyVariantSike} {
+		prv := NewPrivateKey(vec.id, v)
+		checkErr(t, prv.Generate(rand.Reader), "Private key generation")
+		scalars = append(scalars, prv.Scalar, prv.S)
+		exp = append(exp, append([]byte(nil), prv.Scalar...), append([]byte(nil), prv.S...))
+	}
+	runtime.GC()
+	runtime.GC()
+	for i := range scalars {
+		if !bytes.Equal(scalars[i], exp[i]) {
+			t.Error("Secret value modified after key has been collected")
+		}
+	}
+}
+
 /* -------------------------------------------------------------------------
    Wrappers for 'testing' SIDH
    -------------------------------------------------------------------------*/
@@ -378,6 +647,13 @@ func TestRoundtrip(t *testing.T)          { testSidhVec(t, &tdataSidh, testRound
 func TestImportExport(t *testing.T)       { testSidhVec(t, &tdataSidh, testImportExport) }
 func TestKeyAgreement(t *testing.T)       { testSidhVec(t, &tdataSidh, testKeyAgreement) }
 func TestPrivateKeyBelowMax(t *testing.T) { testSidhVec(t, &tdataSidh, testPrivateKeyBelowMax) }
+func TestDestroy(t *testing.T)            { testSidhVec(t, &tdataSidh, testDestroy) }
+func TestSecretsOutliveKey(t *testing.T)  { testSidhVec(t, &tdataSidh, testSecretsOutliveKey) }
+func TestErrors(t *testing.T)             { testSidhVec(t, &tdataSidh, testErrors) }
+func TestValidate(t *testing.T)           { testSidhVec(t, &tdataSidh, testValidate) }
+func TestImportExportCompressed(t *testing.T) {
+	testSidhVec(t, &tdataSidh, testImportExportCompressed)
+}
 
 /* -------------------------------------------------------------------------
    Benchmarking
@@ -2,21 +2,50 @@ package sidh
 
 import (
 	"crypto/subtle"
-	"errors"
 	"io"
+	"sync"
 
 	"github.com/henrydcase/nobs/dh/sidh/common"
 	"github.com/henrydcase/nobs/hash/sha3"
+	"github.com/henrydcase/nobs/utils"
 )
 
-// SIKE KEM interface.
+// SIKE KEM interface. KEM doesn't keep any state between calls, hence
+// single KEM can be used by many goroutines at the same time, provided
+// that its rng is safe for concurrent use.
 type KEM struct {
-	allocated   bool
-	rng         io.Reader
-	msg         []byte
-	secretBytes []byte
-	params      *common.SidhParams
-	shake       sha3.ShakeHash
+	allocated bool
+	rng       io.Reader
+	params    *common.SidhParams
+	// Public keys in ciphertexts are compressed
+	compressed bool
+}
+
+// kemCtx holds scratch space of a single KEM operation. Contexts are
+// taken from kemCtxPool, so that concurrent calls don't share memory and
+// steady-state operations don't allocate.
+type kemCtx struct {
+	msg    [common.MaxMsgBsz]byte
+	secret [common.MaxSidhPrivateKeyBsz]byte
+	shake  sha3.ShakeHash
+}
+
+var kemCtxPool = sync.Pool{
+	New: func() interface{} {
+		return &kemCtx{shake: sha3.NewShake256()}
+	},
+}
+
+func getKemCtx() *kemCtx {
+	return kemCtxPool.Get().(*kemCtx)
+}
+
+// putKemCtx zeroizes secret values and returns context to the pool.
+func putKemCtx(ctx *kemCtx) {
+	utils.Zeroize(ctx.msg[:])
+	utils.Zeroize(ctx.secret[:])
+	ctx.shake.Reset()
+	kemCtxPool.Put(ctx)
 }
 
 // NewSike434 instantiates SIKE/p434 KEM.
@@ -33,6 +62,13 @@ func NewSike503(rng io.Reader) *KEM {
 	return &c
 }
 
+// NewSike610 instantiates SIKE/p610 KEM.
+func NewSike610(rng io.Reader) *KEM {
+	var c KEM
+	c.Allocate(Fp610, rng)
+	return &c
+}
+
 // NewSike751 instantiates SIKE/p751 KEM.
 func NewSike751(rng io.Reader) *KEM {
 	var c KEM
@@ -45,35 +81,72 @@ func NewSike751(rng io.Reader) *KEM {
 func (c *KEM) Allocate(id uint8, rng io.Reader) {
 	c.rng = rng
 	c.params = common.Params(id)
-	c.msg = make([]byte, c.params.MsgLen)
-	c.secretBytes = make([]byte, c.params.A.SecretByteLen)
-	c.shake = sha3.NewShake256()
+	c.compressed = false
 	c.allocated = true
 }
 
+// allocateCompressed allocates KEM object for multiple SIKE operations
+// with compressed public keys. Ciphertext produced by such KEM contains
+// compressed ephemeral public key and public keys are hashed in compressed
+// form, hence the KEM isn't interoperable with one created by Allocate.
+// Public key of the recipient should be exchanged with exportCompressed
+// and importCompressed. Compression isn't constant time.
+//
+// Compressed format is specific to this package, it isn't compatible with
+// the round-3 SIKEp*_compressed submission. For that reason it isn't
+// exported until it can be checked against the round-3 KATs.
+func (c *KEM) allocateCompressed(id uint8, rng io.Reader) {
+	c.Allocate(id, rng)
+	c.compressed = true
+}
+
 // Encapsulate receives the public key and generates SIKE ciphertext and shared secret.
 // The generated ciphertext is used for authentication.
-// Error is returned in case PRNG fails. Function panics in case wrongly formated
-// input was provided.
+// Error is returned in case PRNG fails. ErrUnallocated is returned if KEM wasn't
+// allocated, ErrWrongVariant if 'pub' isn't a SIKE key, ErrParamMismatch if it
+// uses different field than KEM and ErrBufferSize if output buffers are too short.
 func (c *KEM) Encapsulate(ciphertext, secret []byte, pub *PublicKey) error {
+	return c.encapsulate(ciphertext, secret, pub, c.rng)
+}
+
+// EncapsulateDeterministic works as Encapsulate, but the ephemeral message
+// is taken from SHAKE256 output on 'seed' instead of KEM's rng. The same
+// seed and public key always give the same ciphertext and shared secret.
+// Seed must be secret, uniformly random, used only once and at least
+// MinSeedSize bytes long, otherwise ErrBufferSize is returned.
+func (c *KEM) EncapsulateDeterministic(ciphertext, secret []byte, pub *PublicKey, seed []byte) error {
+	if len(seed) < MinSeedSize {
+		return ErrBufferSize
+	}
+	h := sha3.NewShake256()
+	_, _ = h.Write(seed)
+	return c.encapsulate(ciphertext, secret, pub, h)
+}
+
+// encapsulate implements Encapsulate, ephemeral message is read from 'rng'.
+func (c *KEM) encapsulate(ciphertext, secret []byte, pub *PublicKey, rng io.Reader) error {
 	if !c.allocated {
-		panic("KEM unallocated")
+		return ErrUnallocated
 	}
 
 	if KeyVariantSike != pub.KeyVariant {
-		panic("Wrong type of public key")
+		return ErrWrongVariant
 	}
 
-	if len(secret) < c.SharedSecretSize() {
-		panic("shared secret buffer to small")
+	if pub.Params.ID != c.params.ID {
+		return ErrParamMismatch
 	}
 
-	if len(ciphertext) < c.CiphertextSize() {
-		panic("ciphertext buffer to small")
+	if len(secret) < c.SharedSecretSize() || len(ciphertext) < c.CiphertextSize() {
+		return ErrBufferSize
 	}
 
+	var ctx = getKemCtx()
+	defer putKemCtx(ctx)
+	var msg = ctx.msg[:c.params.MsgLen]
+
 	// Generate ephemeral value
-	_, err := io.ReadFull(c.rng, c.msg[:])
+	_, err := io.ReadFull(rng, msg)
 	if err != nil {
 		return err
 	}
@@ -83,82 +156,91 @@ func (c *KEM) Encapsulate(ciphertext, secret []byte, pub *PublicKey) error {
 		Key: Key{
 			Params:     c.params,
 			KeyVariant: KeyVariantSidhA},
-		Scalar: c.secretBytes}
+		Scalar: ctx.secret[:c.params.A.SecretByteLen]}
 	var pkA = NewPublicKey(c.params.ID, KeyVariantSidhA)
 
-	pub.Export(buf[:])
-	c.shake.Reset()
-	_, _ = c.shake.Write(c.msg)
-	_, _ = c.shake.Write(buf[:3*c.params.SharedSecretSize])
-	_, _ = c.shake.Read(skA.Scalar)
+	pubLen, err := c.exportPublicKey(buf[:], pub)
+	if err != nil {
+		return err
+	}
+	ctx.shake.Reset()
+	_, _ = ctx.shake.Write(msg)
+	_, _ = ctx.shake.Write(buf[:pubLen])
+	_, _ = ctx.shake.Read(skA.Scalar)
 
 	// Ensure bitlength is not bigger then to 2^e2-1
 	skA.Scalar[len(skA.Scalar)-1] &= (1 << (c.params.A.SecretBitLen % 8)) - 1
-	skA.GeneratePublicKey(pkA)
-	c.generateCiphertext(ciphertext, &skA, pkA, pub, c.msg[:])
+	if err = skA.GeneratePublicKey(pkA); err != nil {
+		return err
+	}
+	err = c.generateCiphertext(ctx.shake, ciphertext, &skA, pkA, pub, msg)
+	if err != nil {
+		return err
+	}
 
 	// K = H(msg||(c0||c1))
-	c.shake.Reset()
-	_, _ = c.shake.Write(c.msg)
-	_, _ = c.shake.Write(ciphertext)
-	_, _ = c.shake.Read(secret[:c.SharedSecretSize()])
+	ctx.shake.Reset()
+	_, _ = ctx.shake.Write(msg)
+	_, _ = ctx.shake.Write(ciphertext)
+	_, _ = ctx.shake.Read(secret[:c.SharedSecretSize()])
 	return nil
 }
 
 // Decapsulate given the keypair and ciphertext as inputs, Decapsulate outputs a shared
 // secret if plaintext verifies correctly, otherwise function outputs random value.
-// Decapsulation may panic in case input is wrongly formated, in particular, size of
-// the 'ciphertext' must be exactly equal to c.CiphertextSize().
+// ErrUnallocated is returned if KEM wasn't allocated, ErrWrongVariant if keys aren't
+// SIKE keys, ErrParamMismatch if keys use different field than KEM and ErrBufferSize
+// if 'secret' is too short or size of the 'ciphertext' isn't exactly c.CiphertextSize().
 func (c *KEM) Decapsulate(secret []byte, prv *PrivateKey, pub *PublicKey, ciphertext []byte) error {
 	if !c.allocated {
-		panic("KEM unallocated")
+		return ErrUnallocated
 	}
 
-	if KeyVariantSike != pub.KeyVariant {
-		panic("Wrong type of public key")
+	if KeyVariantSike != pub.KeyVariant || pub.KeyVariant != prv.KeyVariant {
+		return ErrWrongVariant
 	}
 
-	if pub.KeyVariant != prv.KeyVariant {
-		panic("Public and private key are of different type")
+	if pub.Params.ID != c.params.ID || prv.Params.ID != c.params.ID {
+		return ErrParamMismatch
 	}
 
-	if len(secret) < c.SharedSecretSize() {
-		panic("shared secret buffer to small")
+	if len(secret) < c.SharedSecretSize() || len(ciphertext) != c.CiphertextSize() {
+		return ErrBufferSize
 	}
 
-	if len(ciphertext) != c.CiphertextSize() {
-		panic("ciphertext buffer to small")
-	}
-
-	var m [common.MaxMsgBsz]byte
-	var r [common.MaxSidhPrivateKeyBsz]byte
+	var ctx = getKemCtx()
+	defer putKemCtx(ctx)
+	var m = ctx.msg[:]
 	var pkBytes [3 * common.MaxSharedSecretBsz]byte
 	var skA = PrivateKey{
 		Key: Key{
 			Params:     c.params,
 			KeyVariant: KeyVariantSidhA},
-		Scalar: c.secretBytes}
+		Scalar: ctx.secret[:c.params.A.SecretByteLen]}
 	var pkA = NewPublicKey(c.params.ID, KeyVariantSidhA)
-	c1Len, err := c.decrypt(m[:], prv, ciphertext)
+	c1Len, err := c.decryptWith(ctx.shake, m, prv, ciphertext)
 	if err != nil {
 		return err
 	}
 
 	// r' = G(m'||pub)
-	pub.Export(pkBytes[:])
-	c.shake.Reset()
-	_, _ = c.shake.Write(m[:c1Len])
-	_, _ = c.shake.Write(pkBytes[:3*c.params.SharedSecretSize])
-	_, _ = c.shake.Read(r[:c.params.A.SecretByteLen])
+	pubLen, err := c.exportPublicKey(pkBytes[:], pub)
+	if err != nil {
+		return err
+	}
+	ctx.shake.Reset()
+	_, _ = ctx.shake.Write(m[:c1Len])
+	_, _ = ctx.shake.Write(pkBytes[:pubLen])
+	_, _ = ctx.shake.Read(skA.Scalar)
 	// Ensure bitlength is not bigger than 2^e2-1
-	r[c.params.A.SecretByteLen-1] &= (1 << (c.params.A.SecretBitLen % 8)) - 1
-
-	err = skA.Import(r[:c.params.A.SecretByteLen])
+	skA.Scalar[len(skA.Scalar)-1] &= (1 << (c.params.A.SecretBitLen % 8)) - 1
+	if err = skA.GeneratePublicKey(pkA); err != nil {
+		return err
+	}
+	pkALen, err := c.exportPublicKey(pkBytes[:], pkA)
 	if err != nil {
 		return err
 	}
-	skA.GeneratePublicKey(pkA)
-	pkA.Export(pkBytes[:])
 
 	// S is chosen at random when generating a key and unknown to other party. It is
 	// important that S is unpredictable to the other party.  Without this check, would
@@ -166,30 +248,25 @@ func (c *KEM) Decapsulate(secret []byte, prv *PrivateKey, pub *PublicKey, cipher
 	//
 	// See more details in "On the security of supersingular isogeny cryptosystems"
 	// (S. Galbraith, et al., 2016, ePrint #859).
-	mask := subtle.ConstantTimeCompare(pkBytes[:c.params.PublicKeySize], ciphertext[:pub.Params.PublicKeySize])
+	mask := subtle.ConstantTimeCompare(pkBytes[:pkALen], ciphertext[:pkALen])
 	common.Cpick(mask, m[:c1Len], m[:c1Len], prv.S)
-	c.shake.Reset()
-	_, _ = c.shake.Write(m[:c1Len])
-	_, _ = c.shake.Write(ciphertext)
-	_, _ = c.shake.Read(secret[:c.SharedSecretSize()])
+	ctx.shake.Reset()
+	_, _ = ctx.shake.Write(m[:c1Len])
+	_, _ = ctx.shake.Write(ciphertext)
+	_, _ = ctx.shake.Read(secret[:c.SharedSecretSize()])
 	return nil
 }
 
-// Resets internal state of KEM. Function should be used
-// after Allocate and between subsequent calls to Encapsulate
-// and/or Decapsulate.
-func (c *KEM) Reset() {
-	for i := range c.msg {
-		c.msg[i] = 0
-	}
-
-	for i := range c.secretBytes {
-		c.secretBytes[i] = 0
-	}
-}
+// Reset does nothing. KEM doesn't keep state between calls and scratch
+// space used by Encapsulate and Decapsulate is zeroized before they
+// return. Kept for backward compatibility.
+func (c *KEM) Reset() {}
 
 // Returns size of resulting ciphertext.
 func (c *KEM) CiphertextSize() int {
+	if c.compressed {
+		return c.params.CompressedCiphertextSize
+	}
 	return c.params.CiphertextSize
 }
 
@@ -198,21 +275,37 @@ func (c *KEM) SharedSecretSize() int {
 	return c.params.KemSize
 }
 
-func (c *KEM) generateCiphertext(ctext []byte, skA *PrivateKey, pkA, pkB *PublicKey, ptext []byte) {
+// Exports public key in a form used by KEM. Returns size of exported key.
+func (c *KEM) exportPublicKey(out []byte, pub *PublicKey) (int, error) {
+	if c.compressed {
+		return pub.compressedSize(), pub.exportCompressed(out)
+	}
+	return pub.Size(), pub.Export(out)
+}
+
+// generateCiphertext encrypts 'ptext' with ephemeral key pair (skA, pkA)
+// for 'pkB'. 'h' is used as a scratch hash state.
+func (c *KEM) generateCiphertext(h sha3.ShakeHash, ctext []byte, skA *PrivateKey, pkA, pkB *PublicKey, ptext []byte) error {
 	var n [common.MaxMsgBsz]byte
 	var j [common.MaxSharedSecretBsz]byte
 	var ptextLen = skA.Params.MsgLen
 
-	skA.DeriveSecret(j[:], pkB)
-	c.shake.Reset()
-	_, _ = c.shake.Write(j[:skA.Params.SharedSecretSize])
-	_, _ = c.shake.Read(n[:ptextLen])
+	if err := skA.DeriveSecret(j[:], pkB); err != nil {
+		return err
+	}
+	h.Reset()
+	_, _ = h.Write(j[:skA.Params.SharedSecretSize])
+	_, _ = h.Read(n[:ptextLen])
 	for i := range ptext {
 		n[i] ^= ptext[i]
 	}
 
-	pkA.Export(ctext)
-	copy(ctext[pkA.Size():], n[:ptextLen])
+	pkLen, err := c.exportPublicKey(ctext, pkA)
+	if err != nil {
+		return err
+	}
+	copy(ctext[pkLen:], n[:ptextLen])
+	return nil
 }
 
 // encrypt uses SIKE public key to encrypt plaintext. Requires cryptographically secure
@@ -222,7 +315,7 @@ func (c *KEM) encrypt(ctext []byte, rng io.Reader, pub *PublicKey, ptext []byte)
 	var ptextLen = len(ptext)
 	// c1 must be security level + 64 bits (see [SIKE] 1.4 and 4.3.3)
 	if ptextLen != pub.Params.KemSize {
-		return errors.New("unsupported message length")
+		return ErrBufferSize
 	}
 
 	skA := NewPrivateKey(pub.Params.ID, KeyVariantSidhA)
@@ -232,29 +325,46 @@ func (c *KEM) encrypt(ctext []byte, rng io.Reader, pub *PublicKey, ptext []byte)
 		return err
 	}
 
-	skA.GeneratePublicKey(pkA)
-	c.generateCiphertext(ctext, skA, pkA, pub, ptext)
-	return nil
+	if err = skA.GeneratePublicKey(pkA); err != nil {
+		return err
+	}
+	ctx := getKemCtx()
+	defer putKemCtx(ctx)
+	return c.generateCiphertext(ctx.shake, ctext, skA, pkA, pub, ptext)
 }
 
 // decrypt uses SIKE private key to decrypt ciphertext. Returns plaintext in case
 // decryption succeeds or error in case unexptected input was provided.
 // Constant time.
 func (c *KEM) decrypt(n []byte, prv *PrivateKey, ctext []byte) (int, error) {
+	ctx := getKemCtx()
+	defer putKemCtx(ctx)
+	return c.decryptWith(ctx.shake, n, prv, ctext)
+}
+
+// decryptWith works as decrypt, but uses 'h' as a scratch hash state.
+func (c *KEM) decryptWith(h sha3.ShakeHash, n []byte, prv *PrivateKey, ctext []byte) (int, error) {
 	var c1Len int
 	var j [common.MaxSharedSecretBsz]byte
-	var pkLen = prv.Params.PublicKeySize
+	var pkLen = c.CiphertextSize() - prv.Params.MsgLen
 
 	// ctext is a concatenation of (ciphertext = pubkey_A || c1)
 	// it must be security level + 64 bits (see [SIKE] 1.4 and 4.3.3)
 	// Lengths has been already checked by Decapsulate()
 	c1Len = len(ctext) - pkLen
+	var err error
 	c0 := NewPublicKey(prv.Params.ID, KeyVariantSidhA)
-	err := c0.Import(ctext[:pkLen])
-	prv.DeriveSecret(j[:], c0)
-	c.shake.Reset()
-	_, _ = c.shake.Write(j[:prv.Params.SharedSecretSize])
-	_, _ = c.shake.Read(n[:c1Len])
+	if c.compressed {
+		err = c0.importCompressed(ctext[:pkLen])
+	} else {
+		err = c0.Import(ctext[:pkLen])
+	}
+	if e := prv.DeriveSecret(j[:], c0); err == nil {
+		err = e
+	}
+	h.Reset()
+	_, _ = h.Write(j[:prv.Params.SharedSecretSize])
+	_, _ = h.Read(n[:c1Len])
 	for i := range n[:c1Len] {
 		n[i] ^= ctext[pkLen+i]
 	}
@@ -5,14 +5,16 @@ import (
 	"bytes"
 	"crypto/rand"
 	"encoding/hex"
-	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/henrydcase/nobs/dh/sidh/common"
+	"github.com/henrydcase/nobs/hash/sha3"
 )
 
 type sikeVec struct {
@@ -31,18 +33,6 @@ func Ok(t testing.TB, err error, msg string) {
 	}
 }
 
-// testPanic returns true if call to function 'f' caused panic.
-func testPanic(f func()) error {
-	var hasPanicked = errors.New("no panic detected")
-	defer func() {
-		if r := recover(); r != nil {
-			hasPanicked = nil
-		}
-	}()
-	f()
-	return hasPanicked
-}
-
 var tdataSike = map[uint8]sikeVec{
 	Fp434: {
 		Fp434, "P-434", NewSike434(rand.Reader),
@@ -77,6 +67,29 @@ var tdataSike = map[uint8]sikeVec{
 			"A9C4724D414B35AF69D6ECB21BFDA23BFF6B66C22C2451DC8E1C",
 		"7BF6938C975658AEB8B4D37CFFBDE25D97E561F36C219A0E8FE645816DBBC7ED7B57" +
 			"7700AE8DC3138E97A0C3F6F002065C92A0B1B8180208"},
+	Fp610: {
+		Fp610, "P-610", NewSike610(rand.Reader),
+		// Official KAT of SIKEp610 is not available. Keys below have
+		// been produced by this implementation, they are used only by
+		// round trip tests.
+		"",
+		"9C8E8A3272C3FB87014544E1A0E202E11B891BEFD4208F268F76A9B43622CC20" +
+			"FAD8FFDBFEDC075C1D38F76E3881C4007CF96BAC54F7346BE17F2E2A03540A26" +
+			"D6A7CA10FCD670D3E283CA4E02CB070DBD95C961D2FADF3619908101C051CBFF" +
+			"DDBA32AE756DA93B06BB09DBF06CB9A417665036792A4CC241BF72BA7551FDA0" +
+			"ABCD9E9CFDB32D8229A8D94B8EF1755FF8FEC4F76FB513F39401EAC4D3833E06" +
+			"63BB83266BAB38A8FD7E4113E166F2CAD7C33F7C1C28AD30BF387C381B489A45" +
+			"7B1BDEF438E081136A9DD493D84573CA0CE2EA843DEEC2A638FA2578B2072FF2" +
+			"FB71536A93CA005FA75A2718AD646AF6AEE31215752734667AB8AB5B988AC4A6" +
+			"0D56BBC937E888E60FEC587A5B5455A68E361929F969F168DC03C7A6F66766A0" +
+			"9DDD106BF3D96CBFC83674030FD3AD4EA6BA6601B0A20A4C19414433A4FFB36E" +
+			"582C1293B66BBE805F5CD22683DD256279D43CDC47B75B7B4BE5A0B3D44E0D58" +
+			"E9BF22A85AB938FB52FA79EF6596914E5A50C39BCEC5B6085104C889747F9294" +
+			"01493E4A8F4EEEE435F7B6D12AC92C23CEDFE5A67C337823589177C9E2A24DE7" +
+			"1144FB8A51A9099EADA00ED36FEF7E69F11464BC2D4AA8442CA817490AC65F76" +
+			"7D11B16B994407092773F2609F00",
+		"D60B93492A1D8C1C7BA6FC0B733137F3406CEE8110A93F17003271531CF27285" +
+			"B8721ED5CB46853043B346A66CBA6CF765F1B0EAA40BF6723F327918FF97"},
 	Fp751: {
 		Fp751, "P-751", NewSike751(rand.Reader),
 		"testdata/PQCkemKAT_644.rsp",
@@ -239,6 +252,222 @@ func testKEMKeyGeneration(t *testing.T, v sikeVec) {
 	}
 }
 
+func testKEMCompressed(t *testing.T, v sikeVec) {
+	var ssE [common.MaxSharedSecretBsz]byte
+	var ssD [common.MaxSharedSecretBsz]byte
+	var kem KEM
+
+	kem.allocateCompressed(v.id, rand.Reader)
+	ct := make([]byte, kem.CiphertextSize())
+	ssBsz := kem.SharedSecretSize()
+	if len(ct) != kem.params.A.CompressedPublicKeySize+kem.params.MsgLen {
+		t.Fatal("wrong size of compressed ciphertext")
+	}
+
+	sk := NewPrivateKey(v.id, KeyVariantSike)
+	pk := NewPublicKey(v.id, KeyVariantSike)
+	Ok(t, sk.Generate(rand.Reader), "error: key generation")
+	sk.GeneratePublicKey(pk)
+
+	// Encapsulate to the key received in compressed form
+	pkBytes := make([]byte, pk.compressedSize())
+	Ok(t, pk.exportCompressed(pkBytes), "public key compression failed")
+	pkDec := NewPublicKey(v.id, KeyVariantSike)
+	Ok(t, pkDec.importCompressed(pkBytes), "public key decompression failed")
+
+	kem.Reset()
+	Ok(t, kem.Encapsulate(ct, ssE[:], pkDec), "encapsulation failed")
+	kem.Reset()
+	Ok(t, kem.Decapsulate(ssD[:ssBsz], sk, pk, ct), "decapsulation failed")
+	if !bytes.Equal(ssE[:], ssD[:]) {
+		t.Fatalf("KEM failed \n encapsulated: %X\n decapsulated: %X", ssE[:], ssD[:])
+	}
+
+	// Change c1, decapsulation must return different secret
+	ct[len(ct)-1]++
+	kem.Reset()
+	Ok(t, kem.Decapsulate(ssD[:ssBsz], sk, pk, ct), "decapsulation failed")
+	if bytes.Equal(ssE[:], ssD[:]) {
+		t.Fatal("decapsulation of modified ciphertext returned correct secret")
+	}
+}
+
+// Single KEM is shared by many goroutines.
+func testKEMConcurrent(t *testing.T, v sikeVec) {
+	var kem KEM
+	kem.Allocate(v.id, rand.Reader)
+
+	sk := NewPrivateKey(v.id, KeyVariantSike)
+	pk := NewPublicKey(v.id, KeyVariantSike)
+	Ok(t, sk.Generate(rand.Reader), "error: key generation")
+	sk.GeneratePublicKey(pk)
+
+	var wg sync.WaitGroup
+	var fail = make(chan string, 8)
+	for i := 0; i < cap(fail); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var ssE, ssD [common.MaxSharedSecretBsz]byte
+			var ct = make([]byte, kem.CiphertextSize())
+			if kem.Encapsulate(ct, ssE[:], pk) != nil {
+				fail <- "encapsulation failed"
+				return
+			}
+			if kem.Decapsulate(ssD[:kem.SharedSecretSize()], sk, pk, ct) != nil {
+				fail <- "decapsulation failed"
+				return
+			}
+			if ssE != ssD {
+				fail <- "shared secrets differ"
+			}
+		}()
+	}
+	wg.Wait()
+	close(fail)
+	for msg := range fail {
+		t.Error(msg)
+	}
+}
+
+func testKEMDeterministic(t *testing.T, v sikeVec) {
+	var seed [MinSeedSize]byte
+	var kem KEM
+	// KEM's rng must not be used
+	kem.Allocate(v.id, bytes.NewReader(nil))
+	_, _ = rand.Read(seed[:])
+
+	sk1 := NewPrivateKey(v.id, KeyVariantSike)
+	pk1 := NewPublicKey(v.id, KeyVariantSike)
+	sk2 := NewPrivateKey(v.id, KeyVariantSike)
+	pk2 := NewPublicKey(v.id, KeyVariantSike)
+	Ok(t, sk1.DeriveKeyPair(pk1, seed[:]), "error: key derivation")
+	Ok(t, DeriveKeyPair(sk2, pk2, seed[:]), "error: key derivation")
+
+	// Same as key generation with SHAKE256 output used as random source
+	h := sha3.NewShake256()
+	_, _ = h.Write(seed[:])
+	sk3 := NewPrivateKey(v.id, KeyVariantSike)
+	Ok(t, sk3.Generate(h), "error: key generation")
+
+	b1 := make([]byte, sk1.Size())
+	b2 := make([]byte, sk1.Size())
+	b3 := make([]byte, sk1.Size())
+	Ok(t, sk1.Export(b1), "error: export")
+	Ok(t, sk2.Export(b2), "error: export")
+	Ok(t, sk3.Export(b3), "error: export")
+	if !bytes.Equal(b1, b2) || !bytes.Equal(b1, b3) {
+		t.Error("private keys derived from the same seed differ")
+	}
+	p1 := make([]byte, pk1.Size())
+	p2 := make([]byte, pk1.Size())
+	Ok(t, pk1.Export(p1), "error: export")
+	Ok(t, pk2.Export(p2), "error: export")
+	if !bytes.Equal(p1, p2) {
+		t.Error("public keys derived from the same seed differ")
+	}
+
+	var ss1, ss2, ssD [common.MaxSharedSecretBsz]byte
+	ct1 := make([]byte, kem.CiphertextSize())
+	ct2 := make([]byte, kem.CiphertextSize())
+	Ok(t, kem.EncapsulateDeterministic(ct1, ss1[:], pk1, seed[:]), "error: encapsulation")
+	Ok(t, kem.EncapsulateDeterministic(ct2, ss2[:], pk1, seed[:]), "error: encapsulation")
+	if !bytes.Equal(ct1, ct2) || ss1 != ss2 {
+		t.Error("encapsulation with the same seed gives different result")
+	}
+	Ok(t, kem.Decapsulate(ssD[:kem.SharedSecretSize()], sk1, pk1, ct1), "error: decapsulation")
+	if ss1 != ssD {
+		t.Error("shared secrets differ")
+	}
+
+	seed[0] ^= 1
+	Ok(t, kem.EncapsulateDeterministic(ct2, ss2[:], pk1, seed[:]), "error: encapsulation")
+	if bytes.Equal(ct1, ct2) || ss1 == ss2 {
+		t.Error("encapsulation with different seeds gives the same result")
+	}
+	Ok(t, sk2.DeriveKeyPair(pk2, seed[:]), "error: key derivation")
+	Ok(t, pk2.Export(p2), "error: export")
+	if bytes.Equal(p1, p2) {
+		t.Error("public keys derived from different seeds are equal")
+	}
+
+	// Too short seed
+	if sk1.DeriveKeyPair(pk1, seed[1:]) != ErrBufferSize {
+		t.Error("DeriveKeyPair must fail for too short seed")
+	}
+	if kem.EncapsulateDeterministic(ct1, ss1[:], pk1, seed[1:]) != ErrBufferSize {
+		t.Error("EncapsulateDeterministic must fail for too short seed")
+	}
+}
+
+// Checks deterministic key generation and encapsulation against known
+// answers from testdata/sike_deterministic.txt.
+func testKEMDeterministicKAT(t *testing.T, v sikeVec) {
+	var vec map[string][]byte
+
+	data, err := ioutil.ReadFile("testdata/sike_deterministic.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, blk := range strings.Split(string(data), "\n\n") {
+		var m = make(map[string][]byte)
+		var name string
+		for _, line := range strings.Split(blk, "\n") {
+			if len(strings.TrimSpace(line)) == 0 || line[0] == '#' {
+				continue
+			}
+			kv := strings.SplitN(line, "=", 2)
+			if len(kv) != 2 {
+				t.Fatal("Wrong format of input file")
+			}
+			key, val := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+			if key == "name" {
+				name = val
+				continue
+			}
+			if m[key], err = hex.DecodeString(val); err != nil {
+				t.Fatal("Wrong format of input file")
+			}
+		}
+		if name == v.name {
+			vec = m
+		}
+	}
+	if vec == nil {
+		t.Fatal("no known answers for " + v.name)
+	}
+
+	var kem KEM
+	kem.Allocate(v.id, bytes.NewReader(nil))
+	sk := NewPrivateKey(v.id, KeyVariantSike)
+	pk := NewPublicKey(v.id, KeyVariantSike)
+	Ok(t, sk.DeriveKeyPair(pk, vec["seed"]), "error: key derivation")
+	skBytes := make([]byte, sk.Size())
+	pkBytes := make([]byte, pk.Size())
+	Ok(t, sk.Export(skBytes), "error: export")
+	Ok(t, pk.Export(pkBytes), "error: export")
+	if !bytes.Equal(skBytes, vec["sk"]) {
+		t.Error("private key differs from known answer")
+	}
+	if !bytes.Equal(pkBytes, vec["pk"]) {
+		t.Error("public key differs from known answer")
+	}
+
+	ct := make([]byte, kem.CiphertextSize())
+	ss := make([]byte, kem.SharedSecretSize())
+	Ok(t, kem.EncapsulateDeterministic(ct, ss, pk, vec["encseed"]), "error: encapsulation")
+	if !bytes.Equal(ct, vec["ct"]) {
+		t.Error("ciphertext differs from known answer")
+	}
+	if !bytes.Equal(ss, vec["ss"]) {
+		t.Error("shared secret differs from known answer")
+	}
+	Ok(t, kem.Decapsulate(ss, sk, pk, vec["ct"]), "error: decapsulation")
+	if !bytes.Equal(ss, vec["ss"]) {
+		t.Error("decapsulated shared secret differs from known answer")
+	}
+}
+
 func testNegativeKEM(t *testing.T, v sikeVec) {
 	var ssE [common.MaxSharedSecretBsz]byte
 	var ssD [common.MaxSharedSecretBsz]byte
@@ -257,18 +486,27 @@ func testNegativeKEM(t *testing.T, v sikeVec) {
 
 	// Try decapsulate too small ciphertext
 	v.kem.Reset()
-	Ok(
-		t,
-		testPanic(func() { _ = v.kem.Decapsulate(ssTmp[:ssBsz], sk, pk, ct[:len(ct)-2]) }),
-		"Decapsulation must panic if ciphertext is too small")
+	if v.kem.Decapsulate(ssTmp[:ssBsz], sk, pk, ct[:len(ct)-2]) != ErrBufferSize {
+		t.Error("Decapsulation must fail if ciphertext is too small")
+	}
 
 	ctTmp := make([]byte, len(ct)+1)
 	// Try decapsulate too big ciphertext
 	v.kem.Reset()
-	Ok(
-		t,
-		testPanic(func() { _ = v.kem.Decapsulate(ssTmp[:ssBsz], sk, pk, ctTmp) }),
-		"Decapsulation must panic if ciphertext is too big")
+	if v.kem.Decapsulate(ssTmp[:ssBsz], sk, pk, ctTmp) != ErrBufferSize {
+		t.Error("Decapsulation must fail if ciphertext is too big")
+	}
+
+	// Try too small buffers for shared secret and ciphertext
+	if v.kem.Decapsulate(ssTmp[:ssBsz-1], sk, pk, ct) != ErrBufferSize {
+		t.Error("Decapsulation must fail if shared secret buffer is too small")
+	}
+	if v.kem.Encapsulate(ct, ssTmp[:ssBsz-1], pk) != ErrBufferSize {
+		t.Error("Encapsulation must fail if shared secret buffer is too small")
+	}
+	if v.kem.Encapsulate(ct[:len(ct)-1], ssTmp[:], pk) != ErrBufferSize {
+		t.Error("Encapsulation must fail if ciphertext buffer is too small")
+	}
 
 	// Change ciphertext
 	ct[0] = ct[0] - 1
@@ -285,17 +523,34 @@ func testNegativeKEM(t *testing.T, v sikeVec) {
 	pkSidh := NewPublicKey(v.id, KeyVariantSidhB)
 	prSidh := NewPrivateKey(v.id, KeyVariantSidhB)
 	v.kem.Reset()
-	Ok(
-		t,
-		testPanic(func() { _ = v.kem.Encapsulate(ct, ssE[:], pkSidh) }),
-		"encapsulation accepts SIDH public key")
+	if v.kem.Encapsulate(ct, ssE[:], pkSidh) != ErrWrongVariant {
+		t.Error("encapsulation accepts SIDH public key")
+	}
 
 	// Try decapsulating with SIDH key
 	v.kem.Reset()
-	Ok(
-		t,
-		testPanic(func() { _ = v.kem.Decapsulate(ssD[:ssBsz], prSidh, pk, ct) }),
-		"encapsulation accepts SIDH public key")
+	if v.kem.Decapsulate(ssD[:ssBsz], prSidh, pk, ct) != ErrWrongVariant {
+		t.Error("decapsulation accepts SIDH private key")
+	}
+
+	// Try keys of other parameter set
+	var other = Fp434
+	if v.id == Fp434 {
+		other = Fp503
+	}
+	pkOther := NewPublicKey(other, KeyVariantSike)
+	if v.kem.Encapsulate(ct, ssE[:], pkOther) != ErrParamMismatch {
+		t.Error("encapsulation accepts key of other parameter set")
+	}
+
+	// Try unallocated KEM
+	var kem KEM
+	if kem.Encapsulate(ct, ssE[:], pk) != ErrUnallocated {
+		t.Error("unallocated KEM must fail")
+	}
+	if kem.Decapsulate(ssD[:ssBsz], sk, pk, ct) != ErrUnallocated {
+		t.Error("unallocated KEM must fail")
+	}
 }
 
 // In case invalid ciphertext is provided, SIKE's decapsulation must
@@ -348,6 +603,9 @@ func testNegativeKEMSameWrongResult(t *testing.T, v sikeVec) {
 }
 
 func testKAT(t *testing.T, v sikeVec) {
+	if v.KatFile == "" {
+		t.Skip("no KAT for " + v.name)
+	}
 	ssGot := make([]byte, v.kem.SharedSecretSize())
 	testDecapsulation := func(pk, sk, ct, ssExpected []byte) {
 		var pubKey = NewPublicKey(v.id, KeyVariantSike)
@@ -453,6 +711,14 @@ func TestNegativePKE(t *testing.T)      { testSike(t, &tdataSike, testNegativePK
 func TestKEMKeyGeneration(t *testing.T) { testSike(t, &tdataSike, testKEMKeyGeneration) }
 func TestNegativeKEM(t *testing.T)      { testSike(t, &tdataSike, testNegativeKEM) }
 func TestKAT(t *testing.T)              { testSike(t, &tdataSike, testKAT) }
+func TestKEMCompressed(t *testing.T)    { testSike(t, &tdataSike, testKEMCompressed) }
+func TestKEMConcurrent(t *testing.T)    { testSike(t, &tdataSike, testKEMConcurrent) }
+func TestKEMDeterministic(t *testing.T) {
+	testSike(t, &tdataSike, testKEMDeterministic)
+}
+func TestKEMDeterministicKAT(t *testing.T) {
+	testSike(t, &tdataSike, testKEMDeterministicKAT)
+}
 func TestNegativeKEMSameWrongResult(t *testing.T) {
 	testSike(t, &tdataSike, testNegativeKEMSameWrongResult)
 }
@@ -0,0 +1,149 @@
+package sidh
+
+import (
+	"crypto/rand"
+	"io"
+
+	"github.com/henrydcase/nobs/dh/sidh/isogeny"
+)
+
+// Validate checks that the public key is well formed. Key is accepted only
+// if it was imported from canonical encoding (all coordinates smaller than
+// p) and x(P), x(Q), x(P-Q) are x-coordinates of points on a supersingular
+// curve E_A, recovered from them, such that P, Q and P-Q have order 3^e3
+// for key of variant A or 2^e2 otherwise, and P, Q are linearly
+// independent, i.e. they generate the whole torsion subgroup. E_A is
+// checked to be supersingular with a random point, see isSupersingular.
+//
+// Returns ErrInvalidPublicKey if any of the checks fails,
+// ErrUnsupportedField if key uses unknown field or error returned by
+// crypto/rand if random point can't be sampled. Validation takes
+// variable time and is much slower than Import, hence it is not done
+// by default. It should be used for static keys coming from untrusted
+// source. Note that validation doesn't prevent adaptive attacks on
+// static SIDH keys, it only ensures that public key is well formed.
+func (pub *PublicKey) Validate() error {
+	f, err := isogeny.NewField(pub.Params.ID)
+	if err != nil {
+		return ErrUnsupportedField
+	}
+	if pub.nonCanonical {
+		return ErrInvalidPublicKey
+	}
+	for i := range pub.affine3Pt {
+		if f.IsZero(&pub.affine3Pt[i]) {
+			return ErrInvalidPublicKey
+		}
+	}
+
+	c := f.RecoverCurve(&pub.affine3Pt[0], &pub.affine3Pt[1], &pub.affine3Pt[2])
+	a := f.AffineA(&c)
+	if !isNonSingular(f, &a) {
+		return ErrInvalidPublicKey
+	}
+	for i := range pub.affine3Pt {
+		if !isOnCurve(f, &pub.affine3Pt[i], &a) {
+			return ErrInvalidPublicKey
+		}
+	}
+	if ok, err := isSupersingular(f, &c, &a, rand.Reader); err != nil {
+		return err
+	} else if !ok {
+		return ErrInvalidPublicKey
+	}
+
+	// Public key of variant A carries image of 3^e3-torsion basis, the
+	// one of variant B (and SIKE) image of 2^e2-torsion basis.
+	var mul func(P *isogeny.ProjectivePoint, c *isogeny.Curve, k uint)
+	var e2, e3 = f.Exponents()
+	var e uint
+	if (pub.KeyVariant & KeyVariantSidhA) == KeyVariantSidhA {
+		mul, e = f.Pow3k, e3
+	} else {
+		mul, e = f.Pow2k, e2
+	}
+
+	// Computes [l^(e-1)] of each point. Point has order l^e iff the result
+	// is non-zero and multiplying it once more by l gives zero.
+	var pts [3]isogeny.ProjectivePoint
+	for i := range pts {
+		pts[i] = isogeny.ProjectivePoint{X: pub.affine3Pt[i], Z: f.One()}
+		mul(&pts[i], &c, e-1)
+		if f.IsZero(&pts[i].Z) {
+			return ErrInvalidPublicKey
+		}
+		t := pts[i]
+		mul(&t, &c, 1)
+		if !f.IsZero(&t.Z) {
+			return ErrInvalidPublicKey
+		}
+	}
+
+	// P and Q are independent iff [l^(e-1)]P != ±[l^(e-1)]Q.
+	var xPzQ, xQzP isogeny.Fp2
+	f.Mul(&xPzQ, &pts[0].X, &pts[1].Z)
+	f.Mul(&xQzP, &pts[1].X, &pts[0].Z)
+	if f.Equal(&xPzQ, &xQzP) {
+		return ErrInvalidPublicKey
+	}
+	return nil
+}
+
+// isNonSingular returns true if A != ±2, that is, if y^2 = x^3 + Ax^2 + x
+// is an elliptic curve.
+func isNonSingular(f *isogeny.Field, a *isogeny.Fp2) bool {
+	var t, two isogeny.Fp2
+	one := f.One()
+	f.Add(&two, &one, &one)
+	f.Sub(&t, a, &two)
+	if f.IsZero(&t) {
+		return false
+	}
+	f.Add(&t, a, &two)
+	return !f.IsZero(&t)
+}
+
+// isOnCurve returns true if x is x-coordinate of a point on
+// y^2 = x^3 + Ax^2 + x.
+func isOnCurve(f *isogeny.Field, x, a *isogeny.Fp2) bool {
+	var t isogeny.Fp2
+	one := f.One()
+	f.Add(&t, x, a)
+	f.Mul(&t, &t, x)
+	f.Add(&t, &t, &one)
+	f.Mul(&t, &t, x)
+	return f.IsSquare(&t)
+}
+
+// isSupersingular returns true if curve c = (A:1), where A = a, has
+// (p+1)^2 points over GF(p^2), which holds for all curves isogenous to
+// the starting curve. Such curve is supersingular. The check is done by
+// computing [p+1]R = [2^e2][3^e3]R for a random point R on the curve.
+//
+// If the curve has N != (p+1)^2 points, R passes the check only if it
+// belongs to the subgroup of points of order dividing p+1. From the
+// Hasse bound it follows that this subgroup has at most about 4p
+// elements, out of N >= (p-1)^2, so the check fails with overwhelming
+// probability. Returns error if 'rng' fails.
+func isSupersingular(f *isogeny.Field, c *isogeny.Curve, a *isogeny.Fp2, rng io.Reader) (bool, error) {
+	var R = isogeny.ProjectivePoint{Z: f.One()}
+	var enc = make([]byte, 2*f.Bytelen())
+	var e2, e3 = f.Exponents()
+
+	// x-coordinate is a random 64-bit integer, hence always smaller than p
+	for {
+		if _, err := io.ReadFull(rng, enc[:8]); err != nil {
+			return false, err
+		}
+		if err := f.SetBytes(&R.X, enc); err != nil {
+			return false, err
+		}
+		if isOnCurve(f, &R.X, a) {
+			break
+		}
+	}
+
+	f.Pow2k(&R, c, e2)
+	f.Pow3k(&R, c, e3)
+	return f.IsZero(&R.Z), nil
+}
@@ -0,0 +1,120 @@
+// Package cmac implements AES-CMAC message authentication code
+// as specified in NIST SP 800-38B and RFC 4493.
+package cmac
+
+import (
+	"crypto/subtle"
+	"hash"
+
+	"github.com/henrydcase/nobs/drbg/internal/aes"
+	"github.com/henrydcase/nobs/utils"
+)
+
+// Size of the AES-CMAC tag in bytes.
+const Size = aes.BlockSize
+
+// Constant used for subkey generation (SP 800-38B, 5.3)
+const rb = 0x87
+
+type cmac struct {
+	c aes.IAES
+	// subkeys K1 and K2
+	k1, k2 [aes.BlockSize]byte
+	// chaining value
+	x [aes.BlockSize]byte
+	// buffered input, last block is processed by Sum
+	buf [aes.BlockSize]byte
+	// number of bytes in buf
+	n int
+}
+
+// New returns hash.Hash computing AES-CMAC with 'key'. Key must be
+// 16, 24 or 32 bytes long.
+func New(key []byte) (hash.Hash, error) {
+	var c cmac
+
+	if utils.X86.HasAES {
+		c.c = &aes.AESAsm{}
+	} else {
+		c.c = aes.NewCipher()
+	}
+	if err := c.c.SetKey(key); err != nil {
+		return nil, err
+	}
+
+	// Subkey generation (SP 800-38B, 6.1)
+	c.c.Encrypt(c.k1[:], c.k1[:])
+	shiftLeft(&c.k1, &c.k1)
+	shiftLeft(&c.k2, &c.k1)
+	return &c, nil
+}
+
+// shiftLeft sets out = in<<1 and reduces the result by the Rb
+// constant if MSB of 'in' was set. Constant time.
+func shiftLeft(out, in *[aes.BlockSize]byte) {
+	msb := in[0] >> 7
+	for i := 0; i < aes.BlockSize-1; i++ {
+		out[i] = in[i]<<1 | in[i+1]>>7
+	}
+	out[aes.BlockSize-1] = in[aes.BlockSize-1] << 1
+	out[aes.BlockSize-1] ^= byte(subtle.ConstantTimeSelect(int(msb), rb, 0))
+}
+
+func xorBlock(dst, src []byte) {
+	for i := 0; i < aes.BlockSize; i++ {
+		dst[i] ^= src[i]
+	}
+}
+
+// Write absorbs more data. Never fails.
+func (c *cmac) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		// Buffer is full and there is more data, so
+		// buffered block is not the last one.
+		if c.n == aes.BlockSize {
+			xorBlock(c.x[:], c.buf[:])
+			c.c.Encrypt(c.x[:], c.x[:])
+			c.n = 0
+		}
+		l := copy(c.buf[c.n:], p)
+		c.n += l
+		p = p[l:]
+	}
+	return n, nil
+}
+
+// Sum appends the MAC to 'in' and returns resulting slice. It
+// doesn't change the underlying state.
+func (c *cmac) Sum(in []byte) []byte {
+	var x, last [aes.BlockSize]byte
+
+	copy(last[:], c.buf[:c.n])
+	if c.n == aes.BlockSize {
+		xorBlock(last[:], c.k1[:])
+	} else {
+		// pad with 10^i
+		last[c.n] = 0x80
+		xorBlock(last[:], c.k2[:])
+	}
+
+	x = c.x
+	xorBlock(x[:], last[:])
+	c.c.Encrypt(x[:], x[:])
+	return append(in, x[:]...)
+}
+
+// Reset resets the state, subkeys are kept.
+func (c *cmac) Reset() {
+	for i := range c.x {
+		c.x[i] = 0
+		c.buf[i] = 0
+	}
+	c.n = 0
+}
+
+// Size returns the size of the MAC in bytes.
+func (c *cmac) Size() int { return Size }
+
+// BlockSize returns the block size of AES.
+func (c *cmac) BlockSize() int { return aes.BlockSize }
@@ -0,0 +1,81 @@
+package cmac
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func S2H(s string) []byte {
+	hex, e := hex.DecodeString(s)
+	if e != nil {
+		panic("Can't decode hex string")
+	}
+	return hex
+}
+
+var msg = S2H(
+	"6bc1bee22e409f96e93d7e117393172a" +
+		"ae2d8a571e03ac9c9eb76fac45af8e51" +
+		"30c81c46a35ce411e5fbc1191a0a52ef" +
+		"f69f2445df4f9b17ad2b417be66c3710")
+
+// Test vectors from NIST SP 800-38B, Appendix D and RFC 4493, 4
+var vectors = []struct {
+	key []byte
+	len int
+	tag []byte
+}{
+	// AES-128
+	{S2H("2b7e151628aed2a6abf7158809cf4f3c"), 0, S2H("bb1d6929e95937287fa37d129b756746")},
+	{S2H("2b7e151628aed2a6abf7158809cf4f3c"), 16, S2H("070a16b46b4d4144f79bdd9dd04a287c")},
+	{S2H("2b7e151628aed2a6abf7158809cf4f3c"), 40, S2H("dfa66747de9ae63030ca32611497c827")},
+	{S2H("2b7e151628aed2a6abf7158809cf4f3c"), 64, S2H("51f0bebf7e3b9d92fc49741779363cfe")},
+	// AES-192
+	{S2H("8e73b0f7da0e6452c810f32b809079e562f8ead2522c6b7b"), 0, S2H("d17ddf46adaacde531cac483de7a9367")},
+	{S2H("8e73b0f7da0e6452c810f32b809079e562f8ead2522c6b7b"), 16, S2H("9e99a7bf31e710900662f65e617c5184")},
+	{S2H("8e73b0f7da0e6452c810f32b809079e562f8ead2522c6b7b"), 40, S2H("8a1de5be2eb31aad089a82e6ee908b0e")},
+	{S2H("8e73b0f7da0e6452c810f32b809079e562f8ead2522c6b7b"), 64, S2H("a1d5df0eed790f794d77589659f39a11")},
+	// AES-256
+	{S2H("603deb1015ca71be2b73aef0857d77811f352c073b6108d72d9810a30914dff4"), 0, S2H("028962f61b7bf89efc6b551f4667d983")},
+	{S2H("603deb1015ca71be2b73aef0857d77811f352c073b6108d72d9810a30914dff4"), 16, S2H("28a7023f452e8f82bd4bf28d8c37c35c")},
+	{S2H("603deb1015ca71be2b73aef0857d77811f352c073b6108d72d9810a30914dff4"), 40, S2H("aaf3d8f1de5640c232f5b169b9c911e6")},
+	{S2H("603deb1015ca71be2b73aef0857d77811f352c073b6108d72d9810a30914dff4"), 64, S2H("e1992190549f6ed5696a2c056c315410")},
+}
+
+func TestCMAC(t *testing.T) {
+	for i, v := range vectors {
+		h, err := New(v.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.Write(msg[:v.len])
+		if tag := h.Sum(nil); !bytes.Equal(tag, v.tag) {
+			t.Errorf("[%d] wrong tag\ngot:  %X\nwant: %X", i, tag, v.tag)
+		}
+
+		// Feed data byte by byte
+		h.Reset()
+		for j := 0; j < v.len; j++ {
+			h.Write(msg[j : j+1])
+		}
+		if tag := h.Sum(nil); !bytes.Equal(tag, v.tag) {
+			t.Errorf("[%d] wrong tag after Reset\ngot:  %X\nwant: %X", i, tag, v.tag)
+		}
+	}
+
+	if _, err := New(make([]byte, 15)); err == nil {
+		t.Error("key of invalid size accepted")
+	}
+}
+
+func BenchmarkCMAC(b *testing.B) {
+	h, _ := New(vectors[0].key)
+	var buf [1024]byte
+	b.SetBytes(int64(len(buf)))
+	for i := 0; i < b.N; i++ {
+		h.Reset()
+		h.Write(buf[:])
+		h.Sum(nil)
+	}
+}
@@ -25,8 +25,10 @@ const (
 )
 
 type CtrDrbg struct {
-	v          [BlockLen]byte
-	key        [KeyLen]byte
+	// Internal state (V and Key), stored in secbuf
+	v          []byte
+	key        []byte
+	secbuf     *utils.SecureBuffer
 	counter    uint
 	strength   uint
 	resistance bool
@@ -34,11 +36,31 @@ type CtrDrbg struct {
 	tmpBlk     [3 * BlockLen]byte
 }
 
+// NewCtrDrbg returns new instance of CTR_DRBG. Internal state is
+// kept in memory allocated with utils.SecureBuffer, caller should
+// call Destroy when DRBG is not needed anymore.
 func NewCtrDrbg() *CtrDrbg {
+	c := &CtrDrbg{secbuf: utils.NewSecureBuffer(BlockLen + KeyLen)}
+	c.v = c.secbuf.Bytes()[:BlockLen:BlockLen]
+	c.key = c.secbuf.Bytes()[BlockLen:]
 	if utils.X86.HasAES {
-		return &CtrDrbg{blockEnc: &aes.AESAsm{}}
+		c.blockEnc = &aes.AESAsm{}
+	} else {
+		c.blockEnc = &aes.AES{}
 	}
-	return &CtrDrbg{blockEnc: &aes.AES{}}
+	return c
+}
+
+// Destroy zeroizes internal state of the DRBG and releases
+// memory allocated for it. DRBG must not be used after call
+// to this function.
+func (c *CtrDrbg) Destroy() {
+	var zero [KeyLen]byte
+	// overwrites key schedule
+	c.blockEnc.SetKey(zero[:])
+	utils.Zeroize(c.tmpBlk[:])
+	c.secbuf.Destroy()
+	c.v, c.key = nil, nil
 }
 
 func (c *CtrDrbg) inc() {
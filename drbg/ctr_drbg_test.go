@@ -100,3 +100,18 @@ func BenchmarkRead(b *testing.B) {
 		c.ReadWithAdditionalData(result[:], vectors[0].AdditionalInput1)
 	}
 }
+
+func TestDestroy(t *testing.T) {
+	var entropy [48]byte
+	c := NewCtrDrbg()
+	if !c.Init(entropy[:], nil) {
+		t.FailNow()
+	}
+	c.Destroy()
+	if c.key != nil || c.v != nil {
+		t.Error("state not released")
+	}
+	if !bytes.Equal(c.tmpBlk[:], make([]byte, len(c.tmpBlk))) {
+		t.Error("state not zeroized")
+	}
+}
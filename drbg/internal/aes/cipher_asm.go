@@ -23,20 +23,22 @@ func expandKeyAsm(nr int, key *byte, enc *uint32, dec *uint32)
 type AESAsm struct {
 	enc [32 + 28]uint32
 	dec [32 + 28]uint32
+	nr  int
 }
 
 func (c *AESAsm) SetKey(key []byte) error {
-	var rounds int
 	switch len(key) {
 	case 128 / 8:
-		rounds = 10
+		c.nr = 10
 	case 192 / 8:
-		rounds = 12
+		c.nr = 12
 	case 256 / 8:
-		rounds = 14
+		c.nr = 14
+	default:
+		return KeySizeError(len(key))
 	}
 
-	expandKeyAsm(rounds, &key[0], &c.enc[0], &c.dec[0])
+	expandKeyAsm(c.nr, &key[0], &c.enc[0], &c.dec[0])
 	return nil
 }
 
@@ -52,7 +54,7 @@ func (c *AESAsm) Encrypt(dst, src []byte) {
 	if InexactOverlap(dst[:BlockSize], src[:BlockSize]) {
 		panic("crypto/aes: invalid buffer overlap")
 	}
-	encryptBlockAsm(len(c.enc)/4-1, &c.enc[0], &dst[0], &src[0])
+	encryptBlockAsm(c.nr, &c.enc[0], &dst[0], &src[0])
 }
 
 func (c *AESAsm) Decrypt(dst, src []byte) {
@@ -65,7 +67,7 @@ func (c *AESAsm) Decrypt(dst, src []byte) {
 	if InexactOverlap(dst[:BlockSize], src[:BlockSize]) {
 		panic("crypto/aes: invalid buffer overlap")
 	}
-	decryptBlockAsm(len(c.dec)/4-1, &c.dec[0], &dst[0], &src[0])
+	decryptBlockAsm(c.nr, &c.dec[0], &dst[0], &src[0])
 }
 
 // expandKey is used by BenchmarkExpand to ensure that the asm implementation
@@ -0,0 +1,42 @@
+// +build amd64,!noasm
+
+package aes
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/henrydcase/nobs/utils"
+)
+
+// Test AES-NI implementation against FIPS 197 examples
+// for all supported key lengths.
+func TestCipherAsm(t *testing.T) {
+	if !utils.X86.HasAES {
+		t.Skip("AES-NI not supported")
+	}
+
+	for i, tt := range encryptTests {
+		var c AESAsm
+		if err := c.SetKey(tt.key); err != nil {
+			t.Errorf("SetKey(%d bytes) = %s", len(tt.key), err)
+			continue
+		}
+
+		out := make([]byte, len(tt.in))
+		c.Encrypt(out, tt.in)
+		if !bytes.Equal(out, tt.out) {
+			t.Errorf("AESAsm.Encrypt %d: got %X, want %X", i, out, tt.out)
+		}
+
+		c.Decrypt(out, tt.out)
+		if !bytes.Equal(out, tt.in) {
+			t.Errorf("AESAsm.Decrypt %d: got %X, want %X", i, out, tt.in)
+		}
+	}
+
+	var c AESAsm
+	if err := c.SetKey(make([]byte, 17)); err == nil {
+		t.Error("SetKey accepted key of invalid size")
+	}
+}
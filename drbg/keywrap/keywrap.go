@@ -0,0 +1,213 @@
+// Package keywrap implements AES Key Wrap (AES-KW) as specified in RFC 3394
+// and AES Key Wrap with Padding (AES-KWP) as specified in RFC 5649. Both
+// modes are also described in NIST SP 800-38F.
+//
+// Key wrapping is meant for protecting symmetric key material under
+// a key-encryption key (KEK), for example one derived from a KEM shared
+// secret. Supported KEK sizes are 16, 24 and 32 bytes.
+package keywrap
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+
+	"github.com/henrydcase/nobs/drbg/internal/aes"
+	"github.com/henrydcase/nobs/utils"
+)
+
+const (
+	// semiblock is a size of the 64-bit block the algorithm works on.
+	semiblock = aes.BlockSize / 2
+	// Maximal length of the plaintext that can be wrapped with AES-KWP.
+	maxPaddedInput = 1<<32 - 1
+)
+
+var (
+	// Default initial value (RFC 3394, 2.2.3.1)
+	defaultIV = [semiblock]byte{0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6}
+	// Alternative initial value prefix (RFC 5649, 3)
+	alternativeIV = [semiblock / 2]byte{0xA6, 0x59, 0x59, 0xA6}
+)
+
+var (
+	// ErrInputLength is returned when length of the input
+	// is not supported by the wrapping mode.
+	ErrInputLength = errors.New("keywrap: invalid input length")
+	// ErrIntegrity is returned when integrity check of the
+	// unwrapped key fails.
+	ErrIntegrity = errors.New("keywrap: integrity check failed")
+)
+
+// newCipher returns AES block cipher keyed with kek. AES-NI
+// implementation is used when supported by the CPU.
+func newCipher(kek []byte) (aes.IAES, error) {
+	var c aes.IAES
+	if utils.X86.HasAES {
+		c = &aes.AESAsm{}
+	} else {
+		c = aes.NewCipher()
+	}
+	return c, c.SetKey(kek)
+}
+
+// wrap implements wrapping process W from RFC 3394, 2.2.1 (index
+// based). On input 'buf' contains the initial value followed by the
+// plaintext, on output it contains the ciphertext.
+func wrap(c aes.IAES, buf []byte) {
+	var b [aes.BlockSize]byte
+	var n = len(buf)/semiblock - 1
+
+	copy(b[:semiblock], buf[:semiblock])
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			r := buf[i*semiblock : (i+1)*semiblock]
+			copy(b[semiblock:], r)
+			c.Encrypt(b[:], b[:])
+			copy(r, b[semiblock:])
+			t := binary.BigEndian.Uint64(b[:semiblock]) ^ uint64(n*j+i)
+			binary.BigEndian.PutUint64(b[:semiblock], t)
+		}
+	}
+	copy(buf[:semiblock], b[:semiblock])
+}
+
+// unwrap implements unwrapping process W^-1 from RFC 3394, 2.2.2
+// (index based). On input 'buf' contains the ciphertext, on output it
+// contains recovered initial value followed by the plaintext.
+func unwrap(c aes.IAES, buf []byte) {
+	var b [aes.BlockSize]byte
+	var n = len(buf)/semiblock - 1
+
+	copy(b[:semiblock], buf[:semiblock])
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			r := buf[i*semiblock : (i+1)*semiblock]
+			t := binary.BigEndian.Uint64(b[:semiblock]) ^ uint64(n*j+i)
+			binary.BigEndian.PutUint64(b[:semiblock], t)
+			copy(b[semiblock:], r)
+			c.Decrypt(b[:], b[:])
+			copy(r, b[semiblock:])
+		}
+	}
+	copy(buf[:semiblock], b[:semiblock])
+}
+
+// Wrap wraps 'key' under key-encryption key 'kek' with AES-KW (RFC 3394).
+// Length of the 'key' must be a multiple of 8 bytes and at least 16 bytes.
+// Returned ciphertext is 8 bytes longer than the 'key'.
+func Wrap(kek, key []byte) ([]byte, error) {
+	if len(key) < 2*semiblock || len(key)%semiblock != 0 {
+		return nil, ErrInputLength
+	}
+
+	c, err := newCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, len(key)+semiblock)
+	copy(out, defaultIV[:])
+	copy(out[semiblock:], key)
+	wrap(c, out)
+	return out, nil
+}
+
+// Unwrap unwraps ciphertext produced by Wrap. Returns ErrIntegrity
+// in case 'ct' hasn't been produced with the same 'kek'.
+func Unwrap(kek, ct []byte) ([]byte, error) {
+	if len(ct) < 3*semiblock || len(ct)%semiblock != 0 {
+		return nil, ErrInputLength
+	}
+
+	c, err := newCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, len(ct))
+	copy(buf, ct)
+	unwrap(c, buf)
+	if subtle.ConstantTimeCompare(buf[:semiblock], defaultIV[:]) != 1 {
+		zeroize(buf)
+		return nil, ErrIntegrity
+	}
+	return buf[semiblock:], nil
+}
+
+// WrapWithPadding wraps 'key' under key-encryption key 'kek' with AES-KWP
+// (RFC 5649). The 'key' can be of any non-zero length smaller than 2^32.
+// Returned ciphertext is padded to a multiple of 8 bytes and is one
+// semiblock longer than the padded 'key'.
+func WrapWithPadding(kek, key []byte) ([]byte, error) {
+	if len(key) == 0 || uint64(len(key)) > maxPaddedInput {
+		return nil, ErrInputLength
+	}
+
+	c, err := newCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	padLen := (semiblock - len(key)%semiblock) % semiblock
+	out := make([]byte, semiblock+len(key)+padLen)
+	copy(out, alternativeIV[:])
+	binary.BigEndian.PutUint32(out[len(alternativeIV):], uint32(len(key)))
+	copy(out[semiblock:], key)
+
+	if len(out) == aes.BlockSize {
+		// Single semiblock is encrypted with AES in ECB mode (RFC 5649, 4.1)
+		c.Encrypt(out, out)
+	} else {
+		wrap(c, out)
+	}
+	return out, nil
+}
+
+// UnwrapWithPadding unwraps ciphertext produced by WrapWithPadding. Returns
+// ErrIntegrity in case 'ct' hasn't been produced with the same 'kek'.
+func UnwrapWithPadding(kek, ct []byte) ([]byte, error) {
+	if len(ct) < 2*semiblock || len(ct)%semiblock != 0 {
+		return nil, ErrInputLength
+	}
+
+	c, err := newCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, len(ct))
+	copy(buf, ct)
+	if len(buf) == aes.BlockSize {
+		c.Decrypt(buf, buf)
+	} else {
+		unwrap(c, buf)
+	}
+
+	// Check the alternative initial value, the message length
+	// indicator and that padding is all zeros (RFC 5649, 3).
+	var padBytes byte
+	var n = len(buf) - semiblock
+	var mli = int(binary.BigEndian.Uint32(buf[len(alternativeIV):semiblock]))
+	ok := subtle.ConstantTimeCompare(buf[:len(alternativeIV)], alternativeIV[:])
+	if mli <= n-semiblock || mli > n {
+		ok = 0
+	} else {
+		for _, v := range buf[semiblock+mli:] {
+			padBytes |= v
+		}
+		ok &= subtle.ConstantTimeByteEq(padBytes, 0)
+	}
+
+	if ok != 1 {
+		zeroize(buf)
+		return nil, ErrIntegrity
+	}
+	return buf[semiblock : semiblock+mli], nil
+}
+
+func zeroize(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,197 @@
+package keywrap
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func S2H(s string) []byte {
+	hex, e := hex.DecodeString(s)
+	if e != nil {
+		panic("Can't decode hex string")
+	}
+	return hex
+}
+
+// Test vectors from RFC 3394, 4
+var vectorsKW = []struct {
+	kek, key, ct []byte
+}{
+	{
+		S2H("000102030405060708090A0B0C0D0E0F"),
+		S2H("00112233445566778899AABBCCDDEEFF"),
+		S2H("1FA68B0A8112B447AEF34BD8FB5A7B829D3E862371D2CFE5"),
+	},
+	{
+		S2H("000102030405060708090A0B0C0D0E0F1011121314151617"),
+		S2H("00112233445566778899AABBCCDDEEFF"),
+		S2H("96778B25AE6CA435F92B5B97C050AED2468AB8A17AD84E5D"),
+	},
+	{
+		S2H("000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F"),
+		S2H("00112233445566778899AABBCCDDEEFF"),
+		S2H("64E8C3F9CE0F5BA263E9777905818A2A93C8191E7D6E8AE7"),
+	},
+	{
+		S2H("000102030405060708090A0B0C0D0E0F1011121314151617"),
+		S2H("00112233445566778899AABBCCDDEEFF0001020304050607"),
+		S2H("031D33264E15D33268F24EC260743EDCE1C6C7DDEE725A936BA814915C6762D2"),
+	},
+	{
+		S2H("000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F"),
+		S2H("00112233445566778899AABBCCDDEEFF0001020304050607"),
+		S2H("A8F9BC1612C68B3FF6E6F4FBE30E71E4769C8B80A32CB8958CD5D17D6B254DA1"),
+	},
+	{
+		S2H("000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F"),
+		S2H("00112233445566778899AABBCCDDEEFF000102030405060708090A0B0C0D0E0F"),
+		S2H("28C9F404C4B810F4CBCCB35CFB87F8263F5786E2D80ED326CBC7F0E71A99F43BFB988B9B7A02DD21"),
+	},
+}
+
+// Test vectors from RFC 5649, 6
+var vectorsKWP = []struct {
+	kek, key, ct []byte
+}{
+	{
+		S2H("5840df6e29b02af1ab493b705bf16ea1ae8338f4dcc176a8"),
+		S2H("c37b7e6492584340bed12207808941155068f738"),
+		S2H("138bdeaa9b8fa7fc61f97742e72248ee5ae6ae5360d1ae6a5f54f373fa543b6a"),
+	},
+	{
+		S2H("5840df6e29b02af1ab493b705bf16ea1ae8338f4dcc176a8"),
+		S2H("466f7250617369"),
+		S2H("afbeb0f07dfbf5419200f2ccb50bb24f"),
+	},
+}
+
+func TestWrapKAT(t *testing.T) {
+	for i, v := range vectorsKW {
+		ct, err := Wrap(v.kek, v.key)
+		if err != nil {
+			t.Fatalf("[%d] wrapping failed: %s", i, err)
+		}
+		if !bytes.Equal(ct, v.ct) {
+			t.Errorf("[%d] wrong ciphertext\ngot:  %X\nwant: %X", i, ct, v.ct)
+		}
+
+		key, err := Unwrap(v.kek, v.ct)
+		if err != nil {
+			t.Fatalf("[%d] unwrapping failed: %s", i, err)
+		}
+		if !bytes.Equal(key, v.key) {
+			t.Errorf("[%d] wrong key\ngot:  %X\nwant: %X", i, key, v.key)
+		}
+	}
+}
+
+func TestWrapWithPaddingKAT(t *testing.T) {
+	for i, v := range vectorsKWP {
+		ct, err := WrapWithPadding(v.kek, v.key)
+		if err != nil {
+			t.Fatalf("[%d] wrapping failed: %s", i, err)
+		}
+		if !bytes.Equal(ct, v.ct) {
+			t.Errorf("[%d] wrong ciphertext\ngot:  %X\nwant: %X", i, ct, v.ct)
+		}
+
+		key, err := UnwrapWithPadding(v.kek, v.ct)
+		if err != nil {
+			t.Fatalf("[%d] unwrapping failed: %s", i, err)
+		}
+		if !bytes.Equal(key, v.key) {
+			t.Errorf("[%d] wrong key\ngot:  %X\nwant: %X", i, key, v.key)
+		}
+	}
+}
+
+func TestWrapWithPaddingRoundTrip(t *testing.T) {
+	var kek [32]byte
+	var key [67]byte
+	for i := range key {
+		key[i] = byte(i)
+	}
+
+	for l := 1; l <= len(key); l++ {
+		ct, err := WrapWithPadding(kek[:], key[:l])
+		if err != nil {
+			t.Fatalf("wrapping %d bytes failed: %s", l, err)
+		}
+		if len(ct) != ((l+7)/8)*8+8 {
+			t.Errorf("wrong ciphertext length %d for %d bytes of input", len(ct), l)
+		}
+		out, err := UnwrapWithPadding(kek[:], ct)
+		if err != nil {
+			t.Fatalf("unwrapping %d bytes failed: %s", l, err)
+		}
+		if !bytes.Equal(out, key[:l]) {
+			t.Errorf("round trip failed for %d bytes", l)
+		}
+	}
+}
+
+func TestNegative(t *testing.T) {
+	v := vectorsKW[0]
+
+	// Modified ciphertext must be rejected
+	for i := range v.ct {
+		ct := append([]byte{}, v.ct...)
+		ct[i] ^= 0x01
+		if _, err := Unwrap(v.kek, ct); err != ErrIntegrity {
+			t.Errorf("modified byte %d: expected integrity failure, got %v", i, err)
+		}
+	}
+
+	// Ciphertext produced with different KEK must be rejected
+	if _, err := Unwrap(vectorsKWP[0].kek[:16], v.ct); err != ErrIntegrity {
+		t.Errorf("expected integrity failure, got %v", err)
+	}
+
+	// AES-KW and AES-KWP must not be interchangeable
+	if _, err := UnwrapWithPadding(v.kek, v.ct); err != ErrIntegrity {
+		t.Errorf("expected integrity failure, got %v", err)
+	}
+	if _, err := Unwrap(vectorsKWP[0].kek, vectorsKWP[0].ct); err != ErrIntegrity {
+		t.Errorf("expected integrity failure, got %v", err)
+	}
+
+	// Wrong lengths
+	for _, l := range []int{0, 8, 15, 17} {
+		if _, err := Wrap(v.kek, make([]byte, l)); err != ErrInputLength {
+			t.Errorf("Wrap: expected length error for %d bytes, got %v", l, err)
+		}
+	}
+	for _, l := range []int{0, 16, 23} {
+		if _, err := Unwrap(v.kek, make([]byte, l)); err != ErrInputLength {
+			t.Errorf("Unwrap: expected length error for %d bytes, got %v", l, err)
+		}
+	}
+	if _, err := WrapWithPadding(v.kek, nil); err != ErrInputLength {
+		t.Errorf("WrapWithPadding: expected length error, got %v", err)
+	}
+	for _, l := range []int{0, 8, 17} {
+		if _, err := UnwrapWithPadding(v.kek, make([]byte, l)); err != ErrInputLength {
+			t.Errorf("UnwrapWithPadding: expected length error for %d bytes, got %v", l, err)
+		}
+	}
+
+	// Wrong KEK size
+	if _, err := Wrap(make([]byte, 10), v.key); err == nil {
+		t.Error("KEK of invalid size accepted")
+	}
+}
+
+func BenchmarkWrap(b *testing.B) {
+	v := vectorsKW[5]
+	for i := 0; i < b.N; i++ {
+		_, _ = Wrap(v.kek, v.key)
+	}
+}
+
+func BenchmarkUnwrap(b *testing.B) {
+	v := vectorsKW[5]
+	for i := 0; i < b.N; i++ {
+		_, _ = Unwrap(v.kek, v.ct)
+	}
+}
@@ -1,5 +1,3 @@
 module github.com/henrydcase/nobs
 
 go 1.12
-
-require golang.org/x/sys v0.0.0-20191120155948-bd437916bb0e
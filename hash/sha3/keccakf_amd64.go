@@ -9,4 +9,4 @@ package sha3
 // This function is implemented in keccakf_amd64.s.
 
 //go:noescape
-func keccakF1600(a *[25]uint64)
+func keccakF1600(state *[25]uint64)
@@ -0,0 +1,94 @@
+// Copyright 2020 Kris Kwiatkowski. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sha3
+
+// KMAC128 and KMAC256 are keyed hash functions based on cSHAKE, as
+// specified in NIST-SP-800-185 [2], section 4.
+import (
+	"encoding/binary"
+	"hash"
+)
+
+// KMAC specific context
+type kmac struct {
+	ShakeHash // cSHAKE instance initialized with N="KMAC" and S
+	// Size of the output in bytes
+	outputLen int
+	// initBlock is bytepad(encode_string(K), rate). Used by Reset() to
+	// restore initial state.
+	initBlock []byte
+}
+
+// NewKMAC128 returns a new KMAC128 hash.Hash computing an 'outputLen'
+// bytes long MAC with key 'key'. S is an optional customization string.
+// Security strength of KMAC128 is 128 bits if the key is at least 16
+// bytes long.
+func NewKMAC128(key []byte, outputLen int, S []byte) hash.Hash {
+	return newKMAC(key, outputLen, S, SHAKE128)
+}
+
+// NewKMAC256 returns a new KMAC256 hash.Hash computing an 'outputLen'
+// bytes long MAC with key 'key'. S is an optional customization string.
+// Security strength of KMAC256 is 256 bits if the key is at least 32
+// bytes long.
+func NewKMAC256(key []byte, outputLen int, S []byte) hash.Hash {
+	return newKMAC(key, outputLen, S, SHAKE256)
+}
+
+func newKMAC(key []byte, outputLen int, S []byte, shaId uint8) hash.Hash {
+	c := kmac{
+		ShakeHash: newCShake([]byte("KMAC"), S, sfxCShake, shaId),
+		outputLen: outputLen,
+	}
+	c.initBlock = bytepad(encodeString(key), Sha3Desc[shaId].r)
+	c.Write(c.initBlock)
+	return &c
+}
+
+// rightEncode implements right_encode from NIST-SP-800-185, 2.3.1.
+func rightEncode(value uint64) []byte {
+	var b [9]byte
+	binary.BigEndian.PutUint64(b[:8], value)
+	// Trim all but last leading zero bytes
+	i := byte(0)
+	for i < 7 && b[i] == 0 {
+		i++
+	}
+	// Append number of encoded bytes
+	b[8] = 8 - i
+	return b[i:]
+}
+
+// encodeString implements encode_string from NIST-SP-800-185, 2.3.2.
+func encodeString(s []byte) []byte {
+	b := leftEncode(uint64(len(s)) * 8)
+	return append(b, s...)
+}
+
+// BlockSize returns rate of the underlying sponge function.
+func (c *kmac) BlockSize() int {
+	return c.ShakeHash.(*cshakeState).BlockSize()
+}
+
+// Size returns size of the MAC in bytes.
+func (c *kmac) Size() int {
+	return c.outputLen
+}
+
+// Reset restores the hash to the state right after absorbing the key.
+func (c *kmac) Reset() {
+	c.ShakeHash.Reset()
+	c.Write(c.initBlock)
+}
+
+// Sum appends the MAC to 'in' and returns resulting slice. It
+// doesn't change the underlying hash state.
+func (c *kmac) Sum(in []byte) []byte {
+	dup := c.ShakeHash.Clone()
+	dup.Write(rightEncode(uint64(c.outputLen) * 8))
+	out := make([]byte, c.outputLen)
+	dup.Read(out)
+	return append(in, out...)
+}
@@ -0,0 +1,68 @@
+package sha3
+
+import (
+	"bytes"
+	"hash"
+	"testing"
+)
+
+// Test vectors from NIST SP 800-185 examples
+// https://csrc.nist.gov/projects/cryptographic-standards-and-guidelines/example-values
+func TestKMAC(t *testing.T) {
+	key := decodeHex("404142434445464748494A4B4C4D4E4F505152535455565758595A5B5C5D5E5F")
+	msgLong := make([]byte, 200)
+	for i := range msgLong {
+		msgLong[i] = byte(i)
+	}
+
+	for i, v := range []struct {
+		new func(key []byte, outputLen int, S []byte) hash.Hash
+		msg []byte
+		S   []byte
+		out []byte
+	}{
+		{
+			NewKMAC128,
+			[]byte{0x00, 0x01, 0x02, 0x03},
+			nil,
+			decodeHex("E5780B0D3EA6F7D3A429C5706AA43A00FADBD7D49628839E3187243F456EE14E"),
+		},
+		{
+			NewKMAC128,
+			[]byte{0x00, 0x01, 0x02, 0x03},
+			[]byte("My Tagged Application"),
+			decodeHex("3B1FBA963CD8B0B59E8C1A6D71888B7143651AF8BA0A7070C0979E2811324AA5"),
+		},
+		{
+			NewKMAC128,
+			msgLong,
+			[]byte("My Tagged Application"),
+			decodeHex("1F5B4E6CCA02209E0DCB5CA635B89A15E271ECC760071DFD805FAA38F9729230"),
+		},
+		{
+			NewKMAC256,
+			[]byte{0x00, 0x01, 0x02, 0x03},
+			[]byte("My Tagged Application"),
+			decodeHex("20C570C31346F703C9AC36C61C03CB64C3970D0CFC787E9B79599D273A68D2F7F69D4CC3DE9D104A351689F27CF6F5951F0103F33F4F24871024D9C27773A8DD"),
+		},
+	} {
+		h := v.new(key, len(v.out), v.S)
+		h.Write(v.msg)
+		out := h.Sum(nil)
+		if !bytes.Equal(out, v.out) {
+			t.Errorf("[%d] wrong MAC\ngot:  %X\nwant: %X", i, out, v.out)
+		}
+
+		// Sum must not change the state
+		if !bytes.Equal(h.Sum(nil), out) {
+			t.Errorf("[%d] second call to Sum returned different value", i)
+		}
+
+		// Reset must restore keyed state
+		h.Reset()
+		h.Write(v.msg)
+		if !bytes.Equal(h.Sum(nil), out) {
+			t.Errorf("[%d] wrong MAC after Reset", i)
+		}
+	}
+}
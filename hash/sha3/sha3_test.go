@@ -462,7 +462,7 @@ func Example_mac() {
 	// Output: 78de2974bd2711d5549ffd32b753ef0f5fa80a0db2556db60f0987eb8a9218ff
 }
 
-func ExampleCShake256() {
+func ExampleNewCShake256() {
 	out := make([]byte, 32)
 	msg := []byte("The quick brown fox jumps over the lazy dog")
 
@@ -496,7 +496,7 @@ func ExampleCShake256() {
 	//85e73a72228d08b46515553ca3a29d47df3047e5d84b12d6c2c63e579f4fd1105716b7838e92e981863907f434bfd4443c9e56ea09da998d2f9b47db71988109
 }
 
-func ExampleSum256() {
+func ExampleNew256() {
 	d := generateData(32)
 	var data [32]byte
 	h := New256()
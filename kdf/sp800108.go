@@ -0,0 +1,313 @@
+// Package kdf implements key derivation functions in counter, feedback
+// and double-pipeline iteration modes, as specified in NIST SP 800-108.
+//
+// Functions are parameterized by a pseudorandom function, passed as a
+// hash.Hash already keyed with the key-derivation key. Suitable PRFs are
+// AES-CMAC (drbg/cmac), HMAC (crypto/hmac) over hash/sha3 or hash/sm3,
+// and KMAC (hash/sha3). The PRF is reset before each invocation.
+//
+// CounterMode, FeedbackMode and DoublePipelineMode encode the fixed input
+// data as
+//
+//	Label || 0x00 || Context || [L]_32
+//
+// where L is length of derived keying material in bits. The counter,
+// when used, is encoded as 32-bit big-endian integer starting from 1 and
+// precedes the fixed input data.
+//
+// Profiles which encode PRF input differently (for example HSM and
+// PKCS#11 implementations of SP 800-108) are supported by the *Raw
+// variants. They take fixed input data as is, and Options which select
+// location and width of the counter. FixedInput builds fixed input data
+// with L of a chosen width.
+package kdf
+
+import (
+	"errors"
+	"hash"
+)
+
+var (
+	// ErrOutputLength is returned when requested output is empty or
+	// too long to be derived.
+	ErrOutputLength = errors.New("kdf: invalid output length")
+	// ErrIVLength is returned when IV length doesn't match PRF output size.
+	ErrIVLength = errors.New("kdf: invalid IV length")
+	// ErrOptions is returned when Options are invalid or not supported
+	// by the mode.
+	ErrOptions = errors.New("kdf: invalid options")
+)
+
+// CounterLocation is position of the counter in PRF input.
+type CounterLocation int
+
+const (
+	// BeforeFixed places the counter directly before the fixed input
+	// data. In feedback and double-pipeline modes the counter follows
+	// the iteration variable (AFTER_ITER in NIST CAVP). Default.
+	BeforeFixed CounterLocation = iota
+	// AfterFixed places the counter after the fixed input data.
+	AfterFixed
+	// MiddleFixed places the counter inside the fixed input data, after
+	// the first Options.Offset bytes.
+	MiddleFixed
+	// BeforeIter places the counter before the iteration variable.
+	// Feedback and double-pipeline modes only.
+	BeforeIter
+	// NoCounter omits the counter. Feedback and double-pipeline modes
+	// only.
+	NoCounter
+)
+
+// Options select encoding of the counter in PRF input. Zero value
+// selects 32-bit counter placed before the fixed input data.
+type Options struct {
+	// Location of the counter.
+	Location CounterLocation
+	// Number of bytes of fixed input data preceding the counter. Used
+	// only with MiddleFixed.
+	Offset int
+	// Width of the counter in bits: 8, 16, 24 or 32. Zero means 32.
+	CounterBits int
+}
+
+// FixedInput returns fixed input data Label || 0x00 || Context || [L],
+// where L is 8*outLen encoded as big-endian integer on 'lBits' bits.
+// 'lBits' must be one of 8, 16, 24 and 32. It returns ErrOutputLength
+// if L can't be encoded on 'lBits' bits.
+func FixedInput(label, context []byte, outLen, lBits int) ([]byte, error) {
+	if lBits <= 0 || lBits > 32 || lBits%8 != 0 {
+		return nil, ErrOptions
+	}
+	bits := uint64(outLen) * 8
+	if outLen <= 0 || bits>>uint(lBits) != 0 {
+		return nil, ErrOutputLength
+	}
+
+	buf := make([]byte, 0, len(label)+len(context)+1+lBits/8)
+	buf = append(buf, label...)
+	buf = append(buf, 0x00)
+	buf = append(buf, context...)
+	return appendUint(buf, bits, lBits/8), nil
+}
+
+// appendUint appends 'n' byte long big-endian encoding of 'v' to 'b'.
+func appendUint(b []byte, v uint64, n int) []byte {
+	for i := n - 1; i >= 0; i-- {
+		b = append(b, byte(v>>(8*uint(i))))
+	}
+	return b
+}
+
+// kbkdf holds state shared by all modes.
+type kbkdf struct {
+	prf   hash.Hash
+	fixed []byte
+	loc   CounterLocation
+	off   int
+	ctr   []byte
+}
+
+// newKbkdf checks options and output length. 'iterative' is true for
+// feedback and double-pipeline modes.
+func newKbkdf(outLen int, prf hash.Hash, fixed []byte, o *Options, iterative bool) (*kbkdf, error) {
+	var opts Options
+	if o != nil {
+		opts = *o
+	}
+	if opts.CounterBits == 0 {
+		opts.CounterBits = 32
+	}
+	if opts.CounterBits < 0 || opts.CounterBits > 32 || opts.CounterBits%8 != 0 {
+		return nil, ErrOptions
+	}
+	switch opts.Location {
+	case BeforeFixed, AfterFixed:
+	case MiddleFixed:
+		if opts.Offset < 0 || opts.Offset > len(fixed) {
+			return nil, ErrOptions
+		}
+	case BeforeIter, NoCounter:
+		if !iterative {
+			return nil, ErrOptions
+		}
+	default:
+		return nil, ErrOptions
+	}
+
+	// Number of PRF invocations must fit into the counter, also when
+	// the counter is not used (SP 800-108, 5).
+	if outLen <= 0 {
+		return nil, ErrOutputLength
+	}
+	blocks := (uint64(outLen) + uint64(prf.Size()) - 1) / uint64(prf.Size())
+	maxBlocks := uint64(0xFFFFFFFF)
+	if opts.Location != NoCounter {
+		maxBlocks = 1<<uint(opts.CounterBits) - 1
+	}
+	if blocks > maxBlocks {
+		return nil, ErrOutputLength
+	}
+
+	return &kbkdf{
+		prf:   prf,
+		fixed: fixed,
+		loc:   opts.Location,
+		off:   opts.Offset,
+		ctr:   make([]byte, opts.CounterBits/8),
+	}, nil
+}
+
+// block computes PRF over 'iter' and the fixed input data with counter
+// 'i' placed at configured location. Result is appended to 'out'.
+func (k *kbkdf) block(out, iter []byte, i uint32) []byte {
+	for j := range k.ctr {
+		k.ctr[j] = byte(i >> (8 * uint(len(k.ctr)-1-j)))
+	}
+
+	k.prf.Reset()
+	switch k.loc {
+	case BeforeIter:
+		k.prf.Write(k.ctr)
+		k.prf.Write(iter)
+		k.prf.Write(k.fixed)
+	case BeforeFixed:
+		k.prf.Write(iter)
+		k.prf.Write(k.ctr)
+		k.prf.Write(k.fixed)
+	case MiddleFixed:
+		k.prf.Write(iter)
+		k.prf.Write(k.fixed[:k.off])
+		k.prf.Write(k.ctr)
+		k.prf.Write(k.fixed[k.off:])
+	case AfterFixed:
+		k.prf.Write(iter)
+		k.prf.Write(k.fixed)
+		k.prf.Write(k.ctr)
+	case NoCounter:
+		k.prf.Write(iter)
+		k.prf.Write(k.fixed)
+	}
+	return k.prf.Sum(out)
+}
+
+// CounterMode fills 'out' with keying material derived in counter mode
+// (SP 800-108, 5.1):
+//
+//	K(i) = PRF(K_I, [i]_32 || Label || 0x00 || Context || [L]_32)
+func CounterMode(out []byte, prf hash.Hash, label, context []byte) error {
+	fixed, err := FixedInput(label, context, len(out), 32)
+	if err != nil {
+		return err
+	}
+	return CounterModeRaw(out, prf, fixed, nil)
+}
+
+// CounterModeRaw fills 'out' with keying material derived in counter
+// mode from fixed input data 'fixed'. Counter is encoded as configured
+// by 'o' (defaults if nil). BeforeIter and NoCounter locations are not
+// supported.
+func CounterModeRaw(out []byte, prf hash.Hash, fixed []byte, o *Options) error {
+	k, err := newKbkdf(len(out), prf, fixed, o, false)
+	if err != nil {
+		return err
+	}
+
+	b := make([]byte, 0, prf.Size())
+	for i := uint32(1); len(out) > 0; i++ {
+		b = k.block(b[:0], nil, i)
+		out = out[copy(out, b):]
+	}
+	return nil
+}
+
+// FeedbackMode fills 'out' with keying material derived in feedback mode
+// (SP 800-108, 5.2):
+//
+//	K(0) = IV
+//	K(i) = PRF(K_I, K(i-1) {|| [i]_32} || Label || 0x00 || Context || [L]_32)
+//
+// Counter is included only if 'withCounter' is true. 'iv' must be either
+// empty or of the same length as PRF output.
+func FeedbackMode(out []byte, prf hash.Hash, iv, label, context []byte, withCounter bool) error {
+	fixed, err := FixedInput(label, context, len(out), 32)
+	if err != nil {
+		return err
+	}
+	var o Options
+	if !withCounter {
+		o.Location = NoCounter
+	}
+	return FeedbackModeRaw(out, prf, iv, fixed, &o)
+}
+
+// FeedbackModeRaw fills 'out' with keying material derived in feedback
+// mode from fixed input data 'fixed'. Counter is encoded as configured
+// by 'o' (defaults if nil). 'iv' must be either empty or of the same
+// length as PRF output.
+func FeedbackModeRaw(out []byte, prf hash.Hash, iv, fixed []byte, o *Options) error {
+	if len(iv) != 0 && len(iv) != prf.Size() {
+		return ErrIVLength
+	}
+	k, err := newKbkdf(len(out), prf, fixed, o, true)
+	if err != nil {
+		return err
+	}
+
+	prev := make([]byte, 0, prf.Size())
+	prev = append(prev, iv...)
+	b := make([]byte, 0, prf.Size())
+	for i := uint32(1); len(out) > 0; i++ {
+		b = k.block(b[:0], prev, i)
+		out = out[copy(out, b):]
+		prev, b = b, prev
+	}
+	return nil
+}
+
+// DoublePipelineMode fills 'out' with keying material derived in
+// double-pipeline iteration mode (SP 800-108, 5.3):
+//
+//	A(0) = Label || 0x00 || Context || [L]_32
+//	A(i) = PRF(K_I, A(i-1))
+//	K(i) = PRF(K_I, A(i) {|| [i]_32} || Label || 0x00 || Context || [L]_32)
+//
+// Counter is included only if 'withCounter' is true.
+func DoublePipelineMode(out []byte, prf hash.Hash, label, context []byte, withCounter bool) error {
+	fixed, err := FixedInput(label, context, len(out), 32)
+	if err != nil {
+		return err
+	}
+	var o Options
+	if !withCounter {
+		o.Location = NoCounter
+	}
+	return DoublePipelineModeRaw(out, prf, fixed, &o)
+}
+
+// DoublePipelineModeRaw fills 'out' with keying material derived in
+// double-pipeline iteration mode from fixed input data 'fixed'. A(0) is
+// equal to 'fixed'. Counter is encoded as configured by 'o' (defaults if
+// nil).
+func DoublePipelineModeRaw(out []byte, prf hash.Hash, fixed []byte, o *Options) error {
+	k, err := newKbkdf(len(out), prf, fixed, o, true)
+	if err != nil {
+		return err
+	}
+
+	a := make([]byte, 0, prf.Size())
+	b := make([]byte, 0, prf.Size())
+	for i := uint32(1); len(out) > 0; i++ {
+		prf.Reset()
+		if i == 1 {
+			prf.Write(fixed)
+		} else {
+			prf.Write(a)
+		}
+		a = prf.Sum(a[:0])
+
+		b = k.block(b[:0], a, i)
+		out = out[copy(out, b):]
+	}
+	return nil
+}
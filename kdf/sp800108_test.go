@@ -0,0 +1,298 @@
+package kdf
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+	"testing"
+
+	"github.com/henrydcase/nobs/drbg/cmac"
+	"github.com/henrydcase/nobs/hash/sha3"
+	"github.com/henrydcase/nobs/hash/sm3"
+)
+
+func S2H(s string) []byte {
+	hex, e := hex.DecodeString(s)
+	if e != nil {
+		panic("Can't decode hex string")
+	}
+	return hex
+}
+
+// KDF-HMAC-SHA2 test vectors from RFC 8009, Appendix A. The KDF
+// used there is SP 800-108 counter mode with empty context.
+func TestCounterModeRFC8009(t *testing.T) {
+	var vectors = []struct {
+		h     func() hash.Hash
+		key   []byte
+		label []byte
+		out   []byte
+	}{
+		{sha256.New, S2H("3705D96080C17728A0E800EAB6E0D23C"), S2H("0000000299"), S2H("B31A018A48F54776F403E9A396325DC3")},
+		{sha256.New, S2H("3705D96080C17728A0E800EAB6E0D23C"), S2H("00000002AA"), S2H("9B197DD1E8C5609D6E67C3E37C62C72E")},
+		{sha256.New, S2H("3705D96080C17728A0E800EAB6E0D23C"), S2H("0000000255"), S2H("9FDA0E56AB2D85E1569A688696C26A6C")},
+		{sha512.New384, S2H("6D404D37FAF79F9DF0D33568D320669800EB4836472EA8A026D16B7182460C52"),
+			S2H("0000000299"), S2H("EF5718BE86CC84963D8BBB5031E9F5C4BA41F28FAF69E73D")},
+	}
+
+	for i, v := range vectors {
+		out := make([]byte, len(v.out))
+		if err := CounterMode(out, hmac.New(v.h, v.key), v.label, nil); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(out, v.out) {
+			t.Errorf("[%d] wrong output\ngot:  %X\nwant: %X", i, out, v.out)
+		}
+	}
+}
+
+// Counter mode vectors from NIST CAVP KBKDF test files (KDFCTR_gen.rsp),
+// COUNT=0 of the given PRF, counter location and counter width.
+func TestCounterModeCAVP(t *testing.T) {
+	cm := func(k []byte) hash.Hash { h, _ := cmac.New(k); return h }
+	hm := func(h func() hash.Hash) func(k []byte) hash.Hash {
+		return func(k []byte) hash.Hash { return hmac.New(h, k) }
+	}
+	var vectors = []struct {
+		prf   func(k []byte) hash.Hash
+		opts  Options
+		ki    []byte
+		fixed []byte
+		ko    []byte
+	}{
+		// CMAC_AES128, BEFORE_FIXED, 8_BITS
+		{cm, Options{CounterBits: 8}, S2H("dff1e50ac0b69dc40f1051d46c2b069c"),
+			S2H("c16e6e02c5a3dcc8d78b9ac1306877761310455b4e41469951d9e6c2245a064b33fd8c3b01203a7824485bf0a64060c4648b707d2607935699316ea5"),
+			S2H("8be8f0869b3c0ba97b71863d1b9f7813")},
+		// CMAC_AES128, AFTER_FIXED, 8_BITS
+		{cm, Options{Location: AfterFixed, CounterBits: 8}, S2H("e61a51e1633e7d0de704dcebbd8f962f"),
+			S2H("5eef88f8cb188e63e08e23c957ee424a3345da88400c567548b57693931a847501f8e1bce1c37a09ef8c6e2ad553dd0f603b52cc6d4e4cbb76eb6c8f"),
+			S2H("63a5647d0fe69d21fc420b1a8ce34cc1")},
+		// HMAC_SHA1, BEFORE_FIXED, 8_BITS
+		{hm(sha1.New), Options{CounterBits: 8}, S2H("00a39bd547fb88b2d98727cf64c195c61e1cad6c"),
+			S2H("98132c1ffaf59ae5cbc0a3133d84c551bb97e0c75ecaddfc30056f6876f59803009bffc7d75c4ed46f40b8f80426750d15bc1ddb14ac5dcb69a68242"),
+			S2H("0611e1903609b47ad7a5fc2c82e47702")},
+		// HMAC_SHA256, BEFORE_FIXED, 8_BITS
+		{hm(sha256.New), Options{CounterBits: 8}, S2H("3edc6b5b8f7aadbd713732b482b8f979286e1ea3b8f8f99c30c884cfe3349b83"),
+			S2H("98e9988bb4cc8b34d7922e1c68ad692ba2a1d9ae15149571675f17a77ad49e80c8d2a85e831a26445b1f0ff44d7084a17206b4896c8112daad18605a"),
+			S2H("6c037652990674a07844732d0ad985f9")},
+		// HMAC_SHA256, BEFORE_FIXED, 32_BITS
+		{hm(sha256.New), Options{CounterBits: 32}, S2H("dd1d91b7d90b2bd3138533ce92b272fbf8a369316aefe242e659cc0ae238afe0"),
+			S2H("01322b96b30acd197979444e468e1c5c6859bf1b1cf951b7e725303e237e46b864a145fab25e517b08f8683d0315bb2911d80a0e8aba17f3b413faac"),
+			S2H("10621342bfb0fd40046c0e29f2cfdbf0")},
+	}
+
+	for i, v := range vectors {
+		out := make([]byte, len(v.ko))
+		if err := CounterModeRaw(out, v.prf(v.ki), v.fixed, &v.opts); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(out, v.ko) {
+			t.Errorf("[%d] wrong output\ngot:  %X\nwant: %X", i, out, v.ko)
+		}
+	}
+}
+
+// Feedback mode vectors computed with KBKDF of OpenSSL 3.0 (32-bit
+// counter after the iteration variable, salt used as Label, info as
+// Context). Second one disables L and the separator, so that fixed input
+// data is Label || Context.
+func TestFeedbackModeOpenSSL(t *testing.T) {
+	key := S2H("000102030405060708090a0b0c0d0e0f")
+	iv := make([]byte, sha256.Size)
+	iv[len(iv)-1] = 0xff
+
+	out := make([]byte, 40)
+	if err := FeedbackMode(out, hmac.New(sha256.New, key), iv, []byte("label"), []byte("context"), true); err != nil {
+		t.Fatal(err)
+	}
+	want := S2H("197311bfb8cf29ba156361905ab541d6a5fbd118b578655f89a1c84d8d68e59b113c5d7bcda9f562")
+	if !bytes.Equal(out, want) {
+		t.Errorf("HMAC: wrong output\ngot:  %X\nwant: %X", out, want)
+	}
+
+	prf, _ := cmac.New(key)
+	if err := FeedbackModeRaw(out, prf, S2H("0f0e0d0c0b0a09080706050403020100"), []byte("labelcontext"), nil); err != nil {
+		t.Fatal(err)
+	}
+	want = S2H("a69a934e7ec3b57ed86af9e54aa5ee017fecbb367806ca0976106fdacb8c2c6e852af469b06ce4c2")
+	if !bytes.Equal(out, want) {
+		t.Errorf("CMAC: wrong output\ngot:  %X\nwant: %X", out, want)
+	}
+}
+
+// Checks that counter is placed as configured in all modes, by
+// computing PRF input by hand.
+func TestCounterLocation(t *testing.T) {
+	key := S2H("000102030405060708090A0B0C0D0E0F")
+	fixed := []byte("fixed input data")
+	iv := make([]byte, sha256.Size)
+	prf := hmac.New(sha256.New, key)
+	sum := func(in ...[]byte) []byte {
+		prf.Reset()
+		for _, b := range in {
+			prf.Write(b)
+		}
+		return prf.Sum(nil)
+	}
+	ctr := []byte{0x00, 0x01}
+	a := sum(fixed)
+
+	var vectors = []struct {
+		opts Options
+		fb   []byte
+		dp   []byte
+	}{
+		{Options{Location: BeforeIter, CounterBits: 16}, sum(ctr, iv, fixed), sum(ctr, a, fixed)},
+		{Options{Location: BeforeFixed, CounterBits: 16}, sum(iv, ctr, fixed), sum(a, ctr, fixed)},
+		{Options{Location: MiddleFixed, Offset: 5, CounterBits: 16}, sum(iv, fixed[:5], ctr, fixed[5:]), sum(a, fixed[:5], ctr, fixed[5:])},
+		{Options{Location: AfterFixed, CounterBits: 16}, sum(iv, fixed, ctr), sum(a, fixed, ctr)},
+		{Options{Location: NoCounter}, sum(iv, fixed), sum(a, fixed)},
+	}
+
+	out := make([]byte, sha256.Size)
+	for i, v := range vectors {
+		if err := FeedbackModeRaw(out, prf, iv, fixed, &v.opts); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(out, v.fb) {
+			t.Errorf("[%d] feedback mode: wrong output", i)
+		}
+		if err := DoublePipelineModeRaw(out, prf, fixed, &v.opts); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(out, v.dp) {
+			t.Errorf("[%d] double-pipeline mode: wrong output", i)
+		}
+	}
+
+	// Label || 0x00 || Context || [L]_16
+	fixed, err := FixedInput([]byte("a"), []byte("b"), 32, 16)
+	if err != nil || !bytes.Equal(fixed, []byte{'a', 0, 'b', 0x01, 0x00}) {
+		t.Errorf("wrong fixed input data: %X", fixed)
+	}
+}
+
+// Checks that derivation with all PRFs works, output is a prefix
+// of longer output when L is the same and that modes differ.
+func TestModes(t *testing.T) {
+	key := S2H("000102030405060708090A0B0C0D0E0F")
+	label := []byte("label")
+	context := []byte("context")
+
+	cm, _ := cmac.New(key)
+	prfs := []hash.Hash{
+		cm,
+		hmac.New(sha3.New256, key),
+		hmac.New(sm3.New, key),
+		sha3.NewKMAC256(key, 32, nil),
+	}
+
+	for i, prf := range prfs {
+		var outs [][]byte
+		for mode := 0; mode < 5; mode++ {
+			out := make([]byte, 3*prf.Size()+1)
+			var err error
+			switch mode {
+			case 0:
+				err = CounterMode(out, prf, label, context)
+			case 1:
+				err = FeedbackMode(out, prf, nil, label, context, false)
+			case 2:
+				err = FeedbackMode(out, prf, make([]byte, prf.Size()), label, context, true)
+			case 3:
+				err = DoublePipelineMode(out, prf, label, context, false)
+			case 4:
+				err = DoublePipelineMode(out, prf, label, context, true)
+			}
+			if err != nil {
+				t.Fatalf("[%d,%d] %s", i, mode, err)
+			}
+
+			// Deterministic
+			again := make([]byte, len(out))
+			switch mode {
+			case 0:
+				CounterMode(again, prf, label, context)
+			case 1:
+				FeedbackMode(again, prf, nil, label, context, false)
+			case 2:
+				FeedbackMode(again, prf, make([]byte, prf.Size()), label, context, true)
+			case 3:
+				DoublePipelineMode(again, prf, label, context, false)
+			case 4:
+				DoublePipelineMode(again, prf, label, context, true)
+			}
+			if !bytes.Equal(out, again) {
+				t.Errorf("[%d,%d] output not deterministic", i, mode)
+			}
+
+			for _, o := range outs {
+				if bytes.Equal(o, out) {
+					t.Errorf("[%d,%d] modes produce same output", i, mode)
+				}
+			}
+			outs = append(outs, out)
+		}
+
+		// L is bound to the output
+		short := make([]byte, prf.Size())
+		CounterMode(short, prf, label, context)
+		if bytes.Equal(short, outs[0][:len(short)]) {
+			t.Errorf("[%d] output doesn't depend on L", i)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	prf := hmac.New(sha256.New, make([]byte, 32))
+	if err := CounterMode(nil, prf, nil, nil); err != ErrOutputLength {
+		t.Error("empty output accepted")
+	}
+	if err := FeedbackMode(make([]byte, 16), prf, make([]byte, 16), nil, nil, true); err != ErrIVLength {
+		t.Error("IV of invalid size accepted")
+	}
+	if err := DoublePipelineMode(nil, prf, nil, nil, true); err != ErrOutputLength {
+		t.Error("empty output accepted")
+	}
+
+	// 8-bit counter allows up to 255 blocks
+	out := make([]byte, 255*prf.Size()+1)
+	if err := CounterModeRaw(out[:len(out)-1], prf, nil, &Options{CounterBits: 8}); err != nil {
+		t.Error(err)
+	}
+	if err := CounterModeRaw(out, prf, nil, &Options{CounterBits: 8}); err != ErrOutputLength {
+		t.Error("counter overflow not detected")
+	}
+	if _, err := FixedInput(nil, nil, 32, 8); err != ErrOutputLength {
+		t.Error("L overflow not detected")
+	}
+
+	for _, o := range []Options{
+		{CounterBits: 12},
+		{CounterBits: 40},
+		{Location: BeforeIter},
+		{Location: NoCounter},
+		{Location: MiddleFixed, Offset: 2},
+		{Location: CounterLocation(-1)},
+	} {
+		if err := CounterModeRaw(out[:1], prf, []byte{1}, &o); err != ErrOptions {
+			t.Errorf("invalid options %+v accepted", o)
+		}
+	}
+	if _, err := FixedInput(nil, nil, 1, 0); err != ErrOptions {
+		t.Error("invalid width of L accepted")
+	}
+}
+
+func BenchmarkCounterModeCMAC(b *testing.B) {
+	prf, _ := cmac.New(make([]byte, 16))
+	var out [64]byte
+	for i := 0; i < b.N; i++ {
+		CounterMode(out[:], prf, []byte("label"), []byte("context"))
+	}
+}
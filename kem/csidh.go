@@ -0,0 +1,177 @@
+package kem
+
+import (
+	"crypto/rand"
+	"io"
+
+	"github.com/henrydcase/nobs/dh/csidh"
+	"github.com/henrydcase/nobs/hash/sha3"
+)
+
+// CSIDH schemes
+var (
+	CSIDH512 Scheme = &csidhScheme{name: "CSIDH-512", id: csidh.Csidh512}
+)
+
+const (
+	// Size of a seed used by DeriveKeyPair
+	csidhSeedSize = 32
+	// Size of the shared secret
+	csidhSharedKeySize = 32
+)
+
+// KEM built from cSIDH NIKE. Ciphertext is an ephemeral public key, shared
+// secret is derived by csidh.Nike from the ephemeral-static shared secret
+// and both public keys. Private key is encoded as sk || pk.
+//
+// Keys of package csidh keep working buffers, hence they are copied before
+// use, so that a key can be used by many goroutines at the same time.
+type csidhScheme struct {
+	name string
+	id   uint8
+}
+
+type csidhPublicKey struct {
+	scheme *csidhScheme
+	pk     *csidh.PublicKey
+}
+
+type csidhPrivateKey struct {
+	scheme *csidhScheme
+	sk     *csidh.PrivateKey
+	pk     *csidh.PublicKey
+}
+
+// Context string of the NIKE
+var csidhKemNike = csidh.Nike{Context: []byte("CSIDH-KEM")}
+
+func (s *csidhScheme) Name() string        { return s.name }
+func (s *csidhScheme) PublicKeySize() int  { return csidh.NewPublicKey(s.id).Size() }
+func (s *csidhScheme) CiphertextSize() int { return s.PublicKeySize() }
+func (s *csidhScheme) SharedKeySize() int  { return csidhSharedKeySize }
+func (s *csidhScheme) SeedSize() int       { return csidhSeedSize }
+
+func (s *csidhScheme) PrivateKeySize() int {
+	return csidh.NewPrivateKey(s.id).Size() + s.PublicKeySize()
+}
+
+// generate creates key pair. Private key is sampled with 'rng', public key
+// computation uses 'rngPub'. Resulting public key doesn't depend on the
+// latter.
+func (s *csidhScheme) generate(rng, rngPub io.Reader) (*csidh.PublicKey, *csidh.PrivateKey, error) {
+	sk := csidh.NewPrivateKey(s.id)
+	pk := csidh.NewPublicKey(s.id)
+	if err := csidh.GeneratePrivateKey(sk, rng); err != nil {
+		return nil, nil, err
+	}
+	if err := csidh.ComputePublicKey(pk, sk, rngPub); err != nil {
+		sk.Destroy()
+		return nil, nil, err
+	}
+	return pk, sk, nil
+}
+
+func (s *csidhScheme) GenerateKeyPair(rng io.Reader) (PublicKey, PrivateKey, error) {
+	pk, sk, err := s.generate(rng, rng)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &csidhPublicKey{s, pk}, &csidhPrivateKey{s, sk, pk}, nil
+}
+
+func (s *csidhScheme) DeriveKeyPair(seed []byte) (PublicKey, PrivateKey, error) {
+	if len(seed) != s.SeedSize() {
+		return nil, nil, ErrSeedSize
+	}
+	h := sha3.NewShake256()
+	_, _ = h.Write(seed)
+	pk, sk, err := s.generate(h, rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &csidhPublicKey{s, pk}, &csidhPrivateKey{s, sk, pk}, nil
+}
+
+func (s *csidhScheme) Encapsulate(pk PublicKey, rng io.Reader) (ct, ss []byte, err error) {
+	pub, ok := pk.(*csidhPublicKey)
+	if !ok || pub.scheme != s {
+		return nil, nil, ErrTypeMismatch
+	}
+	var peer = *pub.pk
+
+	pkE, skE, err := s.generate(rng, rng)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer skE.Destroy()
+
+	ss = make([]byte, s.SharedKeySize())
+	if err = csidhKemNike.DeriveKey(ss, pkE, &peer, skE, rng); err != nil {
+		return nil, nil, err
+	}
+	ct, _ = pkE.MarshalBinary()
+	return ct, ss, nil
+}
+
+func (s *csidhScheme) Decapsulate(sk PrivateKey, ct []byte) ([]byte, error) {
+	prv, ok := sk.(*csidhPrivateKey)
+	if !ok || prv.scheme != s {
+		return nil, ErrTypeMismatch
+	}
+	if len(ct) != s.CiphertextSize() {
+		return nil, ErrCiphertextSize
+	}
+	var own, key = *prv.pk, *prv.sk
+
+	pkE := csidh.NewPublicKey(s.id)
+	if err := pkE.UnmarshalBinary(ct); err != nil {
+		return nil, err
+	}
+	ss := make([]byte, s.SharedKeySize())
+	if err := csidhKemNike.DeriveKey(ss, &own, pkE, &key, rand.Reader); err != nil {
+		return nil, err
+	}
+	return ss, nil
+}
+
+func (s *csidhScheme) UnmarshalBinaryPublicKey(buf []byte) (PublicKey, error) {
+	if len(buf) != s.PublicKeySize() {
+		return nil, ErrPubKeySize
+	}
+	pk := csidh.NewPublicKey(s.id)
+	if err := pk.UnmarshalBinary(buf); err != nil {
+		return nil, err
+	}
+	return &csidhPublicKey{s, pk}, nil
+}
+
+func (s *csidhScheme) UnmarshalBinaryPrivateKey(buf []byte) (PrivateKey, error) {
+	if len(buf) != s.PrivateKeySize() {
+		return nil, ErrPrivKeySize
+	}
+	sk := csidh.NewPrivateKey(s.id)
+	pk := csidh.NewPublicKey(s.id)
+	if err := sk.UnmarshalBinary(buf[:sk.Size()]); err != nil {
+		return nil, err
+	}
+	if err := pk.UnmarshalBinary(buf[sk.Size():]); err != nil {
+		sk.Destroy()
+		return nil, err
+	}
+	return &csidhPrivateKey{s, sk, pk}, nil
+}
+
+func (k *csidhPublicKey) Scheme() Scheme                 { return k.scheme }
+func (k *csidhPublicKey) MarshalBinary() ([]byte, error) { return k.pk.MarshalBinary() }
+
+func (k *csidhPrivateKey) Scheme() Scheme    { return k.scheme }
+func (k *csidhPrivateKey) Public() PublicKey { return &csidhPublicKey{k.scheme, k.pk} }
+
+func (k *csidhPrivateKey) MarshalBinary() ([]byte, error) {
+	sk, err := k.sk.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	pk, _ := k.pk.MarshalBinary()
+	return append(sk, pk...), nil
+}
@@ -0,0 +1,161 @@
+// Package kem provides a common interface to key encapsulation mechanisms
+// implemented by the library and a registry, which allows to look schemes
+// up by name or object identifier.
+//
+// Schemes differ in the way they are instantiated and in the way they
+// report errors. Adapters in this package hide those differences: keys,
+// ciphertexts and shared secrets are byte strings of fixed size, given
+// by the size accessors of the Scheme, and functions never panic on
+// malformed input.
+package kem
+
+import (
+	"encoding/asn1"
+	"errors"
+	"io"
+	"sort"
+	"sync"
+)
+
+// Errors returned by the package
+var (
+	// ErrTypeMismatch is returned when key belongs to a different scheme.
+	ErrTypeMismatch = errors.New("kem: key belongs to a different scheme")
+	// ErrPubKeySize is returned when encoded public key has wrong length.
+	ErrPubKeySize = errors.New("kem: invalid public key length")
+	// ErrPrivKeySize is returned when encoded private key has wrong length.
+	ErrPrivKeySize = errors.New("kem: invalid private key length")
+	// ErrCiphertextSize is returned when ciphertext has wrong length.
+	ErrCiphertextSize = errors.New("kem: invalid ciphertext length")
+	// ErrSeedSize is returned when seed has wrong length.
+	ErrSeedSize = errors.New("kem: invalid seed length")
+)
+
+// PublicKey is a public key of a KEM.
+type PublicKey interface {
+	// Scheme returns the scheme to which the key belongs.
+	Scheme() Scheme
+	// MarshalBinary encodes the key. Encoding has Scheme().PublicKeySize()
+	// bytes.
+	MarshalBinary() ([]byte, error)
+}
+
+// PrivateKey is a private key of a KEM.
+type PrivateKey interface {
+	// Scheme returns the scheme to which the key belongs.
+	Scheme() Scheme
+	// MarshalBinary encodes the key. Encoding has Scheme().PrivateKeySize()
+	// bytes.
+	MarshalBinary() ([]byte, error)
+	// Public returns the public key corresponding to the private key.
+	Public() PublicKey
+}
+
+// Scheme is a key encapsulation mechanism. Implementations are safe for
+// concurrent use.
+type Scheme interface {
+	// Name of the scheme.
+	Name() string
+
+	// GenerateKeyPair generates random key pair, using 'rng' as a source
+	// of randomness. Returns an error if 'rng' fails.
+	GenerateKeyPair(rng io.Reader) (PublicKey, PrivateKey, error)
+	// DeriveKeyPair deterministically derives key pair from 'seed', which
+	// must have SeedSize() bytes. Returns ErrSeedSize otherwise.
+	DeriveKeyPair(seed []byte) (PublicKey, PrivateKey, error)
+
+	// Encapsulate generates a shared secret and its encapsulation for
+	// public key 'pk', using 'rng' as a source of randomness. Returns
+	// ErrTypeMismatch if 'pk' belongs to a different scheme or an error
+	// if 'rng' fails.
+	Encapsulate(pk PublicKey, rng io.Reader) (ct, ss []byte, err error)
+	// Decapsulate returns shared secret encapsulated in 'ct' with
+	// private key 'sk'. Returns ErrTypeMismatch if 'sk' belongs to a
+	// different scheme or ErrCiphertextSize if length of 'ct' differs
+	// from CiphertextSize().
+	Decapsulate(sk PrivateKey, ct []byte) ([]byte, error)
+
+	// UnmarshalBinaryPublicKey decodes public key. Returns ErrPubKeySize
+	// if length of the input differs from PublicKeySize().
+	UnmarshalBinaryPublicKey(buf []byte) (PublicKey, error)
+	// UnmarshalBinaryPrivateKey decodes private key. Returns ErrPrivKeySize
+	// if length of the input differs from PrivateKeySize().
+	UnmarshalBinaryPrivateKey(buf []byte) (PrivateKey, error)
+
+	// PublicKeySize returns size of encoded public key in bytes.
+	PublicKeySize() int
+	// PrivateKeySize returns size of encoded private key in bytes.
+	PrivateKeySize() int
+	// CiphertextSize returns size of ciphertext in bytes.
+	CiphertextSize() int
+	// SharedKeySize returns size of shared secret in bytes.
+	SharedKeySize() int
+	// SeedSize returns size of a seed used by DeriveKeyPair in bytes.
+	SeedSize() int
+}
+
+// Registry of schemes
+var (
+	mu     sync.RWMutex
+	byName = make(map[string]Scheme)
+	byOID  = make(map[string]Scheme)
+	oids   = make(map[string]asn1.ObjectIdentifier)
+)
+
+// Register makes scheme available through ByName and ByOID. It panics if
+// scheme with the same name or OID is already registered.
+func Register(s Scheme, oid asn1.ObjectIdentifier) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	name, key := s.Name(), oid.String()
+	if _, ok := byName[name]; ok {
+		panic("kem: scheme " + name + " registered twice")
+	}
+	if _, ok := byOID[key]; ok {
+		panic("kem: OID " + key + " registered twice")
+	}
+	byName[name] = s
+	byOID[key] = s
+	oids[name] = oid
+}
+
+// ByName returns scheme with given name or nil if there is no such scheme.
+func ByName(name string) Scheme {
+	mu.RLock()
+	defer mu.RUnlock()
+	return byName[name]
+}
+
+// ByOID returns scheme identified by 'oid' or nil if there is no such
+// scheme.
+func ByOID(oid asn1.ObjectIdentifier) Scheme {
+	mu.RLock()
+	defer mu.RUnlock()
+	return byOID[oid.String()]
+}
+
+// OID returns object identifier of a registered scheme or nil if scheme
+// isn't registered.
+func OID(s Scheme) asn1.ObjectIdentifier {
+	mu.RLock()
+	defer mu.RUnlock()
+	if byName[s.Name()] != s {
+		return nil
+	}
+	return oids[s.Name()]
+}
+
+// All returns all registered schemes, sorted by name.
+func All() []Scheme {
+	mu.RLock()
+	defer mu.RUnlock()
+	var all = make([]Scheme, 0, len(byName))
+	for _, s := range byName {
+		all = append(all, s)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Name() < all[j].Name()
+	})
+	return all
+}
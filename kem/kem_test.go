@@ -0,0 +1,191 @@
+package kem
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/asn1"
+	"sync"
+	"testing"
+)
+
+func checkErr(t testing.TB, err error, msg string) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s [%v]", msg, err)
+	}
+}
+
+func TestSchemes(t *testing.T) {
+	for _, s := range All() {
+		s := s
+		t.Run(s.Name(), func(t *testing.T) {
+			pk, sk, err := s.GenerateKeyPair(rand.Reader)
+			checkErr(t, err, "Key generation failed")
+
+			ct, ss, err := s.Encapsulate(pk, rand.Reader)
+			checkErr(t, err, "Encapsulation failed")
+			if len(ct) != s.CiphertextSize() || len(ss) != s.SharedKeySize() {
+				t.Fatal("Wrong size of ciphertext or shared secret")
+			}
+			ss2, err := s.Decapsulate(sk, ct)
+			checkErr(t, err, "Decapsulation failed")
+			if !bytes.Equal(ss, ss2) {
+				t.Fatal("Shared secrets differ")
+			}
+
+			// Key encoding round trip
+			pkb, err := pk.MarshalBinary()
+			checkErr(t, err, "Public key encoding failed")
+			skb, err := sk.MarshalBinary()
+			checkErr(t, err, "Private key encoding failed")
+			if len(pkb) != s.PublicKeySize() || len(skb) != s.PrivateKeySize() {
+				t.Fatal("Wrong size of encoded key")
+			}
+			pk2, err := s.UnmarshalBinaryPublicKey(pkb)
+			checkErr(t, err, "Public key decoding failed")
+			sk2, err := s.UnmarshalBinaryPrivateKey(skb)
+			checkErr(t, err, "Private key decoding failed")
+			pkb2, _ := sk2.Public().MarshalBinary()
+			if !bytes.Equal(pkb, pkb2) {
+				t.Fatal("Public key differs after decoding")
+			}
+
+			ct, ss, err = s.Encapsulate(pk2, rand.Reader)
+			checkErr(t, err, "Encapsulation failed")
+			ss2, err = s.Decapsulate(sk2, ct)
+			checkErr(t, err, "Decapsulation failed")
+			if !bytes.Equal(ss, ss2) {
+				t.Fatal("Shared secrets differ for decoded keys")
+			}
+
+			// Malformed input
+			if _, err = s.Decapsulate(sk, ct[1:]); err != ErrCiphertextSize {
+				t.Error("Expected ErrCiphertextSize")
+			}
+			if _, err = s.UnmarshalBinaryPublicKey(pkb[1:]); err != ErrPubKeySize {
+				t.Error("Expected ErrPubKeySize")
+			}
+			if _, err = s.UnmarshalBinaryPrivateKey(skb[1:]); err != ErrPrivKeySize {
+				t.Error("Expected ErrPrivKeySize")
+			}
+			if _, _, err = s.DeriveKeyPair(nil); err != ErrSeedSize {
+				t.Error("Expected ErrSeedSize")
+			}
+		})
+	}
+}
+
+func TestDeriveKeyPair(t *testing.T) {
+	for _, s := range All() {
+		s := s
+		t.Run(s.Name(), func(t *testing.T) {
+			var seed = make([]byte, s.SeedSize())
+			_, _ = rand.Read(seed)
+
+			pk1, sk1, err := s.DeriveKeyPair(seed)
+			checkErr(t, err, "Key derivation failed")
+			pk2, sk2, err := s.DeriveKeyPair(seed)
+			checkErr(t, err, "Key derivation failed")
+
+			b1, _ := pk1.MarshalBinary()
+			b2, _ := pk2.MarshalBinary()
+			if !bytes.Equal(b1, b2) {
+				t.Error("Public keys derived from the same seed differ")
+			}
+			b1, _ = sk1.MarshalBinary()
+			b2, _ = sk2.MarshalBinary()
+			if !bytes.Equal(b1, b2) {
+				t.Error("Private keys derived from the same seed differ")
+			}
+
+			seed[0] ^= 1
+			pk3, _, err := s.DeriveKeyPair(seed)
+			checkErr(t, err, "Key derivation failed")
+			b1, _ = pk1.MarshalBinary()
+			b2, _ = pk3.MarshalBinary()
+			if bytes.Equal(b1, b2) {
+				t.Error("Public keys derived from different seeds are equal")
+			}
+		})
+	}
+}
+
+func TestTypeMismatch(t *testing.T) {
+	pk, sk, err := SIKEp434.GenerateKeyPair(rand.Reader)
+	checkErr(t, err, "Key generation failed")
+
+	if _, _, err = SIKEp503.Encapsulate(pk, rand.Reader); err != ErrTypeMismatch {
+		t.Error("Expected ErrTypeMismatch")
+	}
+	if _, err = CSIDH512.Decapsulate(sk, make([]byte, CSIDH512.CiphertextSize())); err != ErrTypeMismatch {
+		t.Error("Expected ErrTypeMismatch")
+	}
+}
+
+func TestConcurrentUse(t *testing.T) {
+	for _, s := range []Scheme{SIKEp434, CSIDH512} {
+		pk, sk, err := s.GenerateKeyPair(rand.Reader)
+		checkErr(t, err, "Key generation failed")
+
+		var wg sync.WaitGroup
+		var errs = make(chan string, 4)
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ct, ss, err := s.Encapsulate(pk, rand.Reader)
+				if err != nil {
+					errs <- err.Error()
+					return
+				}
+				ss2, err := s.Decapsulate(sk, ct)
+				if err != nil || !bytes.Equal(ss, ss2) {
+					errs <- "shared secrets differ"
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for e := range errs {
+			t.Errorf("%s: %s", s.Name(), e)
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	for _, s := range []Scheme{SIKEp434, SIKEp503, SIKEp610, SIKEp751, CSIDH512} {
+		if ByName(s.Name()) != s {
+			t.Errorf("%s: lookup by name failed", s.Name())
+		}
+		oid := OID(s)
+		if oid == nil || ByOID(oid) != s {
+			t.Errorf("%s: lookup by OID failed", s.Name())
+		}
+	}
+
+	if ByName("unknown") != nil || ByOID(asn1.ObjectIdentifier{1, 2, 3}) != nil {
+		t.Error("Lookup of unknown scheme must fail")
+	}
+	if OID(&sikeScheme{name: "SIKEp434"}) != nil {
+		t.Error("Unregistered scheme must not have OID")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Registration of duplicated scheme must panic")
+		}
+	}()
+	Register(SIKEp434, asn1.ObjectIdentifier{1, 2, 3})
+}
+
+func BenchmarkEncapsulate(b *testing.B) {
+	for _, s := range All() {
+		pk, _, err := s.GenerateKeyPair(rand.Reader)
+		checkErr(b, err, "Key generation failed")
+		b.Run(s.Name(), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, _, _ = s.Encapsulate(pk, rand.Reader)
+			}
+		})
+	}
+}
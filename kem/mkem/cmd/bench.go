@@ -58,8 +58,10 @@ func init() {
 	testPKS_csidh = make([]csidh.PublicKey, len(mPKE.Cts))
 
 	for i, _ := range mPKE.Cts {
-		csidh.GeneratePrivateKey(&testSKS_csidh[i], mPKE.Rng)
-		csidh.GeneratePublicKey(&testPKS_csidh[i], &testSKS_csidh[i], mPKE.Rng)
+		if csidh.GeneratePrivateKey(&testSKS_csidh[i], mPKE.Rng) != nil ||
+			csidh.ComputePublicKey(&testPKS_csidh[i], &testSKS_csidh[i], mPKE.Rng) != nil {
+			panic("Can't generate CSIDH key pair")
+		}
 	}
 
 	// create public keys for SIKE
@@ -86,13 +88,13 @@ func init_sike(id uint8, pks *[]*sidh.PublicKey, kem *sidh.KEM) {
 
 func bench_CSIDH_PKE(n int) {
 	for i := 0; i < n*nRecipients; i++ {
-		_ = sPKE.Enc(&testPKS_csidh[i%nRecipients], &MessgaeTest)
+		_, _ = sPKE.Enc(&testPKS_csidh[i%nRecipients], &MessgaeTest)
 	}
 }
 
 func bench_CSIDH_mPKE(n int) {
 	for i := 0; i < n; i++ {
-		mPKE.Encrypt(testPKS_csidh[:], &MessgaeTest)
+		_ = mPKE.Encrypt(testPKS_csidh[:], &MessgaeTest)
 	}
 }
 
@@ -1,6 +1,8 @@
 package mkem
 
 import (
+	"fmt"
+
 	"github.com/henrydcase/nobs/dh/csidh"
 	"github.com/henrydcase/nobs/drbg"
 	"github.com/henrydcase/nobs/hash/sha3"
@@ -19,6 +21,23 @@ type ciphertext struct {
 	V [64]byte
 }
 
+// RecipientError is returned by MultiPKE.Encrypt in case shared secret
+// with one of the recipients can't be computed.
+type RecipientError struct {
+	// Index of the recipient's public key
+	Index int
+	// Error returned by csidh.ComputeSharedSecret
+	Err error
+}
+
+func (e *RecipientError) Error() string {
+	return fmt.Sprintf("mkem: recipient %d: %v", e.Index, e.Err)
+}
+
+func (e *RecipientError) Unwrap() error {
+	return e.Err
+}
+
 type PKE struct {
 	Rng *drbg.CtrDrbg
 	H   sha3.ShakeHash
@@ -46,14 +65,21 @@ func (c *MultiPKE) Allocate(recipients_nb uint, rng *drbg.CtrDrbg) {
 	c.Cts = make([][SharedSecretSz]byte, recipients_nb)
 }
 
-// PKE encryption
-func (c *PKE) Enc(pk *csidh.PublicKey, pt *[16]byte) (ct ciphertext) {
-	var ss [64]byte
+// PKE encryption. Returns an error in case 'rng' fails or shared
+// secret with 'pk' can't be computed, see csidh.ComputeSharedSecret.
+func (c *PKE) Enc(pk *csidh.PublicKey, pt *[16]byte) (ct ciphertext, err error) {
+	var ss [SharedSecretSz]byte
 	var pkA csidh.PublicKey
 	var skA csidh.PrivateKey
 
-	csidh.GeneratePrivateKey(&skA, c.Rng)
-	csidh.DeriveSecret(&ss, pk, &skA, c.Rng)
+	if err = csidh.GeneratePrivateKey(&skA, c.Rng); err != nil {
+		return
+	}
+	defer skA.Destroy()
+
+	if err = csidh.ComputeSharedSecret(ss[:], pk, &skA, c.Rng); err != nil {
+		return
+	}
 
 	c.H.Reset()
 	c.H.Write(ss[:])
@@ -62,18 +88,25 @@ func (c *PKE) Enc(pk *csidh.PublicKey, pt *[16]byte) (ct ciphertext) {
 		ct.V[i] = pt[i] ^ ss[i]
 	}
 
-	csidh.GeneratePublicKey(&pkA, &skA, c.Rng)
+	if err = csidh.ComputePublicKey(&pkA, &skA, c.Rng); err != nil {
+		return
+	}
 	pkA.Export(ct.U[:])
 	return
 }
 
-// PKE decryption
-func (c *PKE) Dec(sk *csidh.PrivateKey, ct *ciphertext) (pt [16]byte) {
-	var ss [64]byte
+// PKE decryption. Returns an error in case ephemeral public key from
+// 'ct' is invalid or 'rng' fails.
+func (c *PKE) Dec(sk *csidh.PrivateKey, ct *ciphertext) (pt [16]byte, err error) {
+	var ss [SharedSecretSz]byte
 	var pk csidh.PublicKey
 
-	pk.Import(ct.U[:])
-	csidh.DeriveSecret(&ss, &pk, sk, c.Rng)
+	if err = pk.UnmarshalBinary(ct.U[:]); err != nil {
+		return
+	}
+	if err = csidh.ComputeSharedSecret(ss[:], &pk, sk, c.Rng); err != nil {
+		return
+	}
 
 	c.H.Reset()
 	c.H.Write(ss[:])
@@ -84,17 +117,39 @@ func (c *PKE) Dec(sk *csidh.PrivateKey, ct *ciphertext) (pt [16]byte) {
 	return
 }
 
-// mPKE encryption
-func (c *MultiPKE) Encrypt(keys []csidh.PublicKey, pt *[16]byte) {
-	var ss [64]byte
+// mPKE encryption. Shared secrets with recipients are computed
+// concurrently on all available CPUs. Returns an error in case 'rng'
+// fails or *RecipientError for the first public key for which shared
+// secret can't be computed. In such case content of Ct0 and Cts is
+// undefined. Returns ErrBufferSize if number of keys exceeds number of
+// recipients given to Allocate.
+func (c *MultiPKE) Encrypt(keys []csidh.PublicKey, pt *[16]byte) error {
 	var pkA csidh.PublicKey
 	var skA csidh.PrivateKey
 
-	csidh.GeneratePrivateKey(&skA, c.Rng)
-	for i, pk := range keys {
-		csidh.DeriveSecret(&ss, &pk, &skA, c.Rng)
+	if len(keys) > len(c.Cts) {
+		return ErrBufferSize
+	}
+
+	pks := make([]*csidh.PublicKey, len(keys))
+	sss := make([][]byte, len(keys))
+	for i := range keys {
+		pks[i] = &keys[i]
+		sss[i] = make([]byte, SharedSecretSz)
+	}
 
-		c.H.Write(ss[:])
+	if err := csidh.GeneratePrivateKey(&skA, c.Rng); err != nil {
+		return err
+	}
+	defer skA.Destroy()
+
+	for i, err := range csidh.DeriveSecretBatch(sss, pks, &skA, 0, c.Rng) {
+		if err != nil {
+			return &RecipientError{Index: i, Err: err}
+		}
+	}
+	for i, ss := range sss {
+		c.H.Write(ss)
 		c.H.Read(ss[:16])
 		c.H.Reset()
 		for j := 0; j < 16; j++ {
@@ -102,7 +157,9 @@ func (c *MultiPKE) Encrypt(keys []csidh.PublicKey, pt *[16]byte) {
 		}
 	}
 
-	csidh.GeneratePublicKey(&pkA, &skA, c.Rng)
+	if err := csidh.ComputePublicKey(&pkA, &skA, c.Rng); err != nil {
+		return err
+	}
 	pkA.Export(c.Ct0[:])
-	return
+	return nil
 }
@@ -3,6 +3,7 @@ package mkem
 import (
 	"bytes"
 	"crypto/rand"
+	"errors"
 	"testing"
 
 	"github.com/henrydcase/nobs/dh/csidh"
@@ -40,8 +41,10 @@ func init() {
 	testPKS = make([]csidh.PublicKey, len(mPKE.Cts))
 
 	for i, _ := range mPKE.Cts {
-		csidh.GeneratePrivateKey(&testSKS[i], mPKE.Rng)
-		csidh.GeneratePublicKey(&testPKS[i], &testSKS[i], mPKE.Rng)
+		if csidh.GeneratePrivateKey(&testSKS[i], mPKE.Rng) != nil ||
+			csidh.ComputePublicKey(&testPKS[i], &testSKS[i], mPKE.Rng) != nil {
+			panic("Can't generate CSIDH key pair")
+		}
 	}
 
 }
@@ -55,22 +58,42 @@ func TestSinglePKE(t *testing.T) {
 	var pk csidh.PublicKey
 	var sk csidh.PrivateKey
 
-	csidh.GeneratePrivateKey(&sk, sPKE.Rng)
-	csidh.GeneratePublicKey(&pk, &sk, sPKE.Rng)
+	Ok(t, csidh.GeneratePrivateKey(&sk, sPKE.Rng) == nil, "Private key generation failed")
+	Ok(t, csidh.ComputePublicKey(&pk, &sk, sPKE.Rng) == nil, "Public key generation failed")
 
 	var msg [16]byte
-	ct := sPKE.Enc(&pk, &msg)
-	pt := sPKE.Dec(&sk, &ct)
-	Ok(t, bytes.Equal(pt[:], msg[:]), "Decryption failed")
+	ct, err := sPKE.Enc(&pk, &msg)
+	Ok(t, err == nil, "Encryption failed")
+	pt, err := sPKE.Dec(&sk, &ct)
+	Ok(t, err == nil && bytes.Equal(pt[:], msg[:]), "Decryption failed")
 
 	// Do it twice to ensure it works with same key pair
-	ct = sPKE.Enc(&pk, &msg)
-	pt = sPKE.Dec(&sk, &ct)
-	Ok(t, bytes.Equal(pt[:], msg[:]),
+	ct, err = sPKE.Enc(&pk, &msg)
+	Ok(t, err == nil, "Encryption failed")
+	pt, err = sPKE.Dec(&sk, &ct)
+	Ok(t, err == nil && bytes.Equal(pt[:], msg[:]),
 		"Decryption failed")
 
 }
 
+func TestSinglePKEInvalidKey(t *testing.T) {
+	var msg [16]byte
+	var invalid [PublicKeySz]byte
+	var pk csidh.PublicKey
+
+	for i := range invalid {
+		invalid[i] = 0xFF
+	}
+	Ok(t, pk.Import(invalid[:]), "Import failed")
+
+	_, err := sPKE.Enc(&pk, &msg)
+	Ok(t, errors.Is(err, csidh.ErrOutOfRange), "Expected ErrOutOfRange")
+
+	ct := ciphertext{U: invalid}
+	_, err = sPKE.Dec(&testSKS[0], &ct)
+	Ok(t, errors.Is(err, csidh.ErrOutOfRange), "Expected ErrOutOfRange")
+}
+
 func TestMultiPKE(t *testing.T) {
 	var msg [16]byte
 	var ct ciphertext
@@ -81,29 +104,46 @@ func TestMultiPKE(t *testing.T) {
 	//	mct.Cts = make([][SharedSecretSz]byte)
 
 	for i, _ := range mPKE.Cts {
-		csidh.GeneratePrivateKey(&sks[i], mPKE.Rng)
-		csidh.GeneratePublicKey(&pks[i], &sks[i], mPKE.Rng)
+		Ok(t, csidh.GeneratePrivateKey(&sks[i], mPKE.Rng) == nil, "Private key generation failed")
+		Ok(t, csidh.ComputePublicKey(&pks[i], &sks[i], mPKE.Rng) == nil, "Public key generation failed")
 	}
 
-	mPKE.Encrypt(pks[:], &msg)
+	Ok(t, mPKE.Encrypt(pks[:], &msg) == nil, "Multi encryption failed")
 	for i := 0; i < len(mPKE.Cts); i++ {
 		getCiphertext(&ct, &mPKE, i)
-		pt := sPKE.Dec(&sks[i], &ct)
-		Ok(t, bytes.Equal(pt[:], msg[:]),
+		pt, err := sPKE.Dec(&sks[i], &ct)
+		Ok(t, err == nil && bytes.Equal(pt[:], msg[:]),
 			"Multi decryption failed")
 	}
 }
 
+func TestMultiPKEInvalidKey(t *testing.T) {
+	var msg [16]byte
+	var invalid [PublicKeySz]byte
+
+	pks := make([]csidh.PublicKey, len(mPKE.Cts))
+	copy(pks, testPKS)
+	for i := range invalid {
+		invalid[i] = 0xFF
+	}
+	Ok(t, pks[3].Import(invalid[:]), "Import failed")
+
+	err := mPKE.Encrypt(pks, &msg)
+	re, ok := err.(*RecipientError)
+	Ok(t, ok && re.Index == 3, "Expected RecipientError for key 3")
+	Ok(t, errors.Is(err, csidh.ErrOutOfRange), "Expected ErrOutOfRange")
+}
+
 var MessgaeTest [16]byte
 
 func BenchmarkEncrypt_CSIDH_p512(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		_ = sPKE.Enc(&testPKS[0], &MessgaeTest)
+		_, _ = sPKE.Enc(&testPKS[0], &MessgaeTest)
 	}
 }
 
 func BenchmarkMultiEncrypt_CSIDH_100keys(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		mPKE.Encrypt(testPKS[:], &MessgaeTest)
+		_ = mPKE.Encrypt(testPKS[:], &MessgaeTest)
 	}
 }
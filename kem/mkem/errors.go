@@ -0,0 +1,20 @@
+package mkem
+
+import (
+	"github.com/henrydcase/nobs/dh/sidh"
+)
+
+// Errors returned by SIKE based KEM and mKEM. Those are the same values as
+// ones returned by package sidh, so that errors of both packages can be
+// handled alike.
+var (
+	// ErrWrongVariant is returned when key isn't a SIKE key.
+	ErrWrongVariant = sidh.ErrWrongVariant
+	// ErrParamMismatch is returned when keys and KEM use different
+	// parameter sets.
+	ErrParamMismatch = sidh.ErrParamMismatch
+	// ErrBufferSize is returned when input or output buffer has wrong size.
+	ErrBufferSize = sidh.ErrBufferSize
+	// ErrUnallocated is returned when KEM is used before Allocate.
+	ErrUnallocated = sidh.ErrUnallocated
+)
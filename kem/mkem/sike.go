@@ -2,7 +2,6 @@ package mkem
 
 import (
 	"crypto/subtle"
-	"errors"
 	"io"
 
 	"github.com/henrydcase/nobs/dh/sidh"
@@ -53,6 +52,13 @@ func NewSike503(rng io.Reader) *KEM {
 	return &c
 }
 
+// NewSike610 instantiates SIKE/p610 KEM.
+func NewSike610(rng io.Reader) *KEM {
+	var c KEM
+	c.Allocate(sidh.Fp610, rng)
+	return &c
+}
+
 // NewSike751 instantiates SIKE/p751 KEM.
 func NewSike751(rng io.Reader) *KEM {
 	var c KEM
@@ -97,23 +103,28 @@ func (c *KEM) PublicKeySize() int {
 
 // Encapsulate receives the public key and generates SIKE ciphertext and shared secret.
 // The generated ciphertext is used for authentication.
-// Error is returned in case PRNG fails. Function panics in case wrongly formated
-// input was provided.
+// Error is returned in case PRNG fails. ErrUnallocated, ErrWrongVariant,
+// ErrParamMismatch or ErrBufferSize is returned in case wrongly formated input
+// was provided.
 func (c *KEM) Encapsulate(ciphertext, secret []byte, pub *sidh.PublicKey) error {
 	if !c.allocated {
-		panic("KEM unallocated")
+		return ErrUnallocated
 	}
 
 	if sidh.KeyVariantSike != pub.KeyVariant {
-		panic("Wrong type of public key")
+		return ErrWrongVariant
+	}
+
+	if pub.Params.ID != c.params.ID {
+		return ErrParamMismatch
 	}
 
 	if len(secret) < c.SharedSecretSize() {
-		panic("shared secret buffer to small")
+		return ErrBufferSize
 	}
 
 	if len(ciphertext) < c.CiphertextSize() {
-		panic("ciphertext buffer to small")
+		return ErrBufferSize
 	}
 
 	// Generate ephemeral value
@@ -130,7 +141,9 @@ func (c *KEM) Encapsulate(ciphertext, secret []byte, pub *sidh.PublicKey) error
 		Scalar: c.secretBytes}
 	var pkA = sidh.NewPublicKey(c.params.ID, sidh.KeyVariantSidhA)
 
-	pub.Export(buf[:])
+	if err = pub.Export(buf[:]); err != nil {
+		return err
+	}
 	c.shake.Reset()
 	_, _ = c.shake.Write(c.msg)
 	_, _ = c.shake.Write(buf[:3*c.params.SharedSecretSize])
@@ -138,8 +151,12 @@ func (c *KEM) Encapsulate(ciphertext, secret []byte, pub *sidh.PublicKey) error
 
 	// Ensure bitlength is not bigger then to 2^e2-1
 	skA.Scalar[len(skA.Scalar)-1] &= (1 << (c.params.A.SecretBitLen % 8)) - 1
-	skA.GeneratePublicKey(pkA)
-	c.generateCiphertext(ciphertext, &skA, pkA, pub, c.msg[:])
+	if err = skA.GeneratePublicKey(pkA); err != nil {
+		return err
+	}
+	if err = c.generateCiphertext(ciphertext, &skA, pkA, pub, c.msg[:]); err != nil {
+		return err
+	}
 
 	// K = H(msg||(c0||c1))
 	c.shake.Reset()
@@ -151,27 +168,28 @@ func (c *KEM) Encapsulate(ciphertext, secret []byte, pub *sidh.PublicKey) error
 
 // Decapsulate given the keypair and ciphertext as inputs, Decapsulate outputs a shared
 // secret if plaintext verifies correctly, otherwise function outputs random value.
-// Decapsulation may panic in case input is wrongly formated, in particular, size of
-// the 'ciphertext' must be exactly equal to c.CiphertextSize().
+// ErrUnallocated, ErrWrongVariant, ErrParamMismatch or ErrBufferSize is returned in
+// case input is wrongly formated, in particular, size of the 'ciphertext' must be
+// exactly equal to c.CiphertextSize().
 func (c *KEM) Decapsulate(secret []byte, prv *sidh.PrivateKey, pub *sidh.PublicKey, ciphertext []byte) error {
 	if !c.allocated {
-		panic("KEM unallocated")
+		return ErrUnallocated
 	}
 
-	if sidh.KeyVariantSike != pub.KeyVariant {
-		panic("Wrong type of public key")
+	if sidh.KeyVariantSike != pub.KeyVariant || pub.KeyVariant != prv.KeyVariant {
+		return ErrWrongVariant
 	}
 
-	if pub.KeyVariant != prv.KeyVariant {
-		panic("Public and private key are of different type")
+	if pub.Params.ID != c.params.ID || prv.Params.ID != c.params.ID {
+		return ErrParamMismatch
 	}
 
 	if len(secret) < c.SharedSecretSize() {
-		panic("shared secret buffer to small")
+		return ErrBufferSize
 	}
 
 	if len(ciphertext) != c.CiphertextSize() {
-		panic("ciphertext buffer to small")
+		return ErrBufferSize
 	}
 
 	var m [common.MaxMsgBsz]byte
@@ -189,7 +207,9 @@ func (c *KEM) Decapsulate(secret []byte, prv *sidh.PrivateKey, pub *sidh.PublicK
 	}
 
 	// r' = G(m'||pub)
-	pub.Export(pkBytes[:])
+	if err = pub.Export(pkBytes[:]); err != nil {
+		return err
+	}
 	c.shake.Reset()
 	_, _ = c.shake.Write(m[:c1Len])
 	_, _ = c.shake.Write(pkBytes[:3*c.params.SharedSecretSize])
@@ -201,8 +221,12 @@ func (c *KEM) Decapsulate(secret []byte, prv *sidh.PrivateKey, pub *sidh.PublicK
 	if err != nil {
 		return err
 	}
-	skA.GeneratePublicKey(pkA)
-	pkA.Export(pkBytes[:])
+	if err = skA.GeneratePublicKey(pkA); err != nil {
+		return err
+	}
+	if err = pkA.Export(pkBytes[:]); err != nil {
+		return err
+	}
 
 	// S is chosen at random when generating a key and unknown to other party. It is
 	// important that S is unpredictable to the other party.  Without this check, would
@@ -222,15 +246,26 @@ func (c *KEM) Decapsulate(secret []byte, prv *sidh.PrivateKey, pub *sidh.PublicK
 // Encapsulate receives the public key and generates single shared secret
 // and multiple ciphertexts as described in mKEM paper. The ciphertexts
 // are stored in c.cts. Ephemeral public key is stored in ct0.
-// Error is returned in case PRNG fails. Function panics in case wrongly formated
-// input is provided.
+// Error is returned in case PRNG fails. ErrUnallocated, ErrWrongVariant,
+// ErrParamMismatch or ErrBufferSize is returned in case wrongly formated input
+// is provided, in particular, number of public keys must not exceed number of
+// recipients given to Allocate.
 func (c *MultiKEM) Encapsulate(secret []byte, pub []*sidh.PublicKey) error {
 	if !c.allocated {
-		panic("KEM unallocated")
+		return ErrUnallocated
 	}
 
-	if len(secret) < c.SharedSecretSize() {
-		panic("shared secret buffer to small")
+	if len(secret) < c.SharedSecretSize() || len(pub) > len(c.cts) {
+		return ErrBufferSize
+	}
+
+	for _, pkB := range pub {
+		if sidh.KeyVariantSike != pkB.KeyVariant {
+			return ErrWrongVariant
+		}
+		if pkB.Params.ID != c.params.ID {
+			return ErrParamMismatch
+		}
 	}
 
 	// Generate ephemeral value M
@@ -254,15 +289,18 @@ func (c *MultiKEM) Encapsulate(secret []byte, pub []*sidh.PublicKey) error {
 
 	// Ensure bitlength is not bigger then to 2^e2-1
 	skA.Scalar[len(skA.Scalar)-1] &= (1 << (c.params.A.SecretBitLen % 8)) - 1
-	skA.GeneratePublicKey(pkA)
+	if err = skA.GeneratePublicKey(pkA); err != nil {
+		return err
+	}
 
 	// pkA -> ct0
-	pkA.Export(c.ct0[:])
+	if err = pkA.Export(c.ct0[:]); err != nil {
+		return err
+	}
 	for ct_i, pkB := range pub {
-		if sidh.KeyVariantSike != pkB.KeyVariant {
-			panic("Wrong type of public key")
+		if err = skA.DeriveSecret(c.j[:], pkB); err != nil {
+			return err
 		}
-		skA.DeriveSecret(c.j[:], pkB)
 		// H(j)
 		c.shake.Reset()
 		_, _ = c.shake.Write(G2)
@@ -284,31 +322,32 @@ func (c *MultiKEM) Encapsulate(secret []byte, pub []*sidh.PublicKey) error {
 
 // mKEM Decapsulate - given the keypair and a ciphertext as inputs. Decapsulate outputs
 // a shared secret if plaintext verifies correctly, otherwise function outputs random value.
-// Decapsulation may panic in case input is wrongly formated, in particular, size of
-// the 'ciphertext' must be exactly equal to c.CiphertextSize().
+// ErrUnallocated, ErrWrongVariant, ErrParamMismatch or ErrBufferSize is returned in
+// case input is wrongly formated, in particular, size of the 'ciphertext' must be
+// exactly equal to c.SharedSecretSize().
 func (c *MultiKEM) Decapsulate(secret []byte, prv *sidh.PrivateKey, pub *sidh.PublicKey, ctext []byte) error {
 	var m [common.MaxMsgBsz]byte
 	var r [common.MaxPublicKeySz]byte
 	var cti [common.MaxMsgBsz]byte
 
 	if !c.allocated {
-		panic("KEM unallocated")
+		return ErrUnallocated
 	}
 
-	if sidh.KeyVariantSike != pub.KeyVariant {
-		panic("Wrong type of public key")
+	if sidh.KeyVariantSike != pub.KeyVariant || pub.KeyVariant != prv.KeyVariant {
+		return ErrWrongVariant
 	}
 
-	if pub.KeyVariant != prv.KeyVariant {
-		panic("Public and private key are of different type")
+	if pub.Params.ID != c.params.ID || prv.Params.ID != c.params.ID {
+		return ErrParamMismatch
 	}
 
 	if len(secret) < c.SharedSecretSize() {
-		panic("shared secret buffer to small")
+		return ErrBufferSize
 	}
 
 	if len(ctext) != c.SharedSecretSize() {
-		panic("ciphertext buffer to small")
+		return ErrBufferSize
 	}
 
 	//var pkBytes [3 * common.MaxSharedSecretBsz]byte
@@ -322,7 +361,9 @@ func (c *MultiKEM) Decapsulate(secret []byte, prv *sidh.PrivateKey, pub *sidh.Pu
 	if err != nil {
 		return err
 	}
-	prv.DeriveSecret(c.j[:], pkA)
+	if err = prv.DeriveSecret(c.j[:], pkA); err != nil {
+		return err
+	}
 
 	c.shake.Reset()
 	_, _ = c.shake.Write(G2)
@@ -340,11 +381,17 @@ func (c *MultiKEM) Decapsulate(secret []byte, prv *sidh.PrivateKey, pub *sidh.Pu
 
 	// Ensure bitlength is not bigger then to 2^e2-1
 	skA.Scalar[len(skA.Scalar)-1] &= (1 << (c.params.A.SecretBitLen % 8)) - 1
-	skA.GeneratePublicKey(pkA)
+	if err = skA.GeneratePublicKey(pkA); err != nil {
+		return err
+	}
 	// ct0' = r
-	pkA.Export(r[:c.params.PublicKeySize])
+	if err = pkA.Export(r[:c.params.PublicKeySize]); err != nil {
+		return err
+	}
 
-	skA.DeriveSecret(c.j[:], pub)
+	if err = skA.DeriveSecret(c.j[:], pub); err != nil {
+		return err
+	}
 	c.shake.Reset()
 	// H(j)
 	_, _ = c.shake.Write(G2)
@@ -399,12 +446,14 @@ func (c *KEM) KemSize() int {
 	return c.params.KemSize
 }
 
-func (c *KEM) generateCiphertext(ctext []byte, skA *sidh.PrivateKey, pkA, pkB *sidh.PublicKey, ptext []byte) {
+func (c *KEM) generateCiphertext(ctext []byte, skA *sidh.PrivateKey, pkA, pkB *sidh.PublicKey, ptext []byte) error {
 	var n [common.MaxMsgBsz]byte
 	var j [common.MaxSharedSecretBsz]byte
 	var ptextLen = skA.Params.MsgLen
 
-	skA.DeriveSecret(j[:], pkB)
+	if err := skA.DeriveSecret(j[:], pkB); err != nil {
+		return err
+	}
 	c.shake.Reset()
 	_, _ = c.shake.Write(j[:skA.Params.SharedSecretSize])
 	_, _ = c.shake.Read(n[:ptextLen])
@@ -412,8 +461,11 @@ func (c *KEM) generateCiphertext(ctext []byte, skA *sidh.PrivateKey, pkA, pkB *s
 		n[i] ^= ptext[i]
 	}
 
-	pkA.Export(ctext)
+	if err := pkA.Export(ctext); err != nil {
+		return err
+	}
 	copy(ctext[pkA.Size():], n[:ptextLen])
+	return nil
 }
 
 // encrypt uses SIKE public key to encrypt plaintext. Requires cryptographically secure
@@ -423,7 +475,7 @@ func (c *KEM) encrypt(ctext []byte, rng io.Reader, pub *sidh.PublicKey, ptext []
 	var ptextLen = len(ptext)
 	// c1 must be security level + 64 bits (see [SIKE] 1.4 and 4.3.3)
 	if ptextLen != pub.Params.KemSize {
-		return errors.New("unsupported message length")
+		return ErrBufferSize
 	}
 
 	skA := sidh.NewPrivateKey(pub.Params.ID, sidh.KeyVariantSidhA)
@@ -433,9 +485,10 @@ func (c *KEM) encrypt(ctext []byte, rng io.Reader, pub *sidh.PublicKey, ptext []
 		return err
 	}
 
-	skA.GeneratePublicKey(pkA)
-	c.generateCiphertext(ctext, skA, pkA, pub, ptext)
-	return nil
+	if err = skA.GeneratePublicKey(pkA); err != nil {
+		return err
+	}
+	return c.generateCiphertext(ctext, skA, pkA, pub, ptext)
 }
 
 // decrypt uses SIKE private key to decrypt ciphertext. Returns plaintext in case
@@ -452,7 +505,9 @@ func (c *KEM) decrypt(n []byte, prv *sidh.PrivateKey, ctext []byte) (int, error)
 	c1Len = len(ctext) - pkLen
 	c0 := sidh.NewPublicKey(prv.Params.ID, sidh.KeyVariantSidhA)
 	err := c0.Import(ctext[:pkLen])
-	prv.DeriveSecret(j[:], c0)
+	if e := prv.DeriveSecret(j[:], c0); err == nil {
+		err = e
+	}
 	c.shake.Reset()
 	_, _ = c.shake.Write(j[:prv.Params.SharedSecretSize])
 	_, _ = c.shake.Read(n[:c1Len])
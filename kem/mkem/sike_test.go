@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"crypto/rand"
 	"encoding/hex"
-	"errors"
 	"fmt"
 	"testing"
 
@@ -44,18 +43,6 @@ func init() {
 	}
 }
 
-// testPanic returns true if call to function 'f' caused panic.
-func testPanic(f func()) error {
-	var hasPanicked = errors.New("no panic detected")
-	defer func() {
-		if r := recover(); r != nil {
-			hasPanicked = nil
-		}
-	}()
-	f()
-	return hasPanicked
-}
-
 // helper
 func IsOk(t testing.TB, f error, msg string) {
 	t.Helper()
@@ -276,18 +263,16 @@ func testNegativeKEM(t *testing.T, v sikeVec) {
 
 	// Try decapsulate too small ciphertext
 	v.kem.Reset()
-	IsOk(
-		t,
-		testPanic(func() { _ = v.kem.Decapsulate(ssTmp[:ssBsz], sk, pk, ct[:len(ct)-2]) }),
-		"Decapsulation must panic if ciphertext is too small")
+	if v.kem.Decapsulate(ssTmp[:ssBsz], sk, pk, ct[:len(ct)-2]) != ErrBufferSize {
+		t.Error("Decapsulation must fail if ciphertext is too small")
+	}
 
 	ctTmp := make([]byte, len(ct)+1)
 	// Try decapsulate too big ciphertext
 	v.kem.Reset()
-	IsOk(
-		t,
-		testPanic(func() { _ = v.kem.Decapsulate(ssTmp[:ssBsz], sk, pk, ctTmp) }),
-		"Decapsulation must panic if ciphertext is too big")
+	if v.kem.Decapsulate(ssTmp[:ssBsz], sk, pk, ctTmp) != ErrBufferSize {
+		t.Error("Decapsulation must fail if ciphertext is too big")
+	}
 
 	// Change ciphertext
 	ct[0] = ct[0] - 1
@@ -304,17 +289,21 @@ func testNegativeKEM(t *testing.T, v sikeVec) {
 	pkSidh := sidh.NewPublicKey(v.id, sidh.KeyVariantSidhB)
 	prSidh := sidh.NewPrivateKey(v.id, sidh.KeyVariantSidhB)
 	v.kem.Reset()
-	IsOk(
-		t,
-		testPanic(func() { _ = v.kem.Encapsulate(ct, ssE[:], pkSidh) }),
-		"encapsulation accepts SIDH public key")
+	if v.kem.Encapsulate(ct, ssE[:], pkSidh) != ErrWrongVariant {
+		t.Error("encapsulation accepts SIDH public key")
+	}
 
 	// Try decapsulating with SIDH key
 	v.kem.Reset()
-	IsOk(
-		t,
-		testPanic(func() { _ = v.kem.Decapsulate(ssD[:ssBsz], prSidh, pk, ct) }),
-		"encapsulation accepts SIDH public key")
+	if v.kem.Decapsulate(ssD[:ssBsz], prSidh, pk, ct) != ErrWrongVariant {
+		t.Error("decapsulation accepts SIDH private key")
+	}
+
+	// Try unallocated KEM
+	var kem KEM
+	if kem.Encapsulate(ct, ssE[:], pk) != ErrUnallocated {
+		t.Error("unallocated KEM must fail")
+	}
 }
 
 // In case invalid ciphertext is provided, SIKE's decapsulation must
@@ -0,0 +1,24 @@
+package kem
+
+import (
+	"encoding/asn1"
+)
+
+// None of the schemes has a standardized object identifier. Identifiers
+// below belong to the experimental arc 1.3.9999, used by post-quantum
+// prototypes, and may change once standard ones are assigned.
+var (
+	oidSIKEp434 = asn1.ObjectIdentifier{1, 3, 9999, 10, 1, 1}
+	oidSIKEp503 = asn1.ObjectIdentifier{1, 3, 9999, 10, 1, 2}
+	oidSIKEp610 = asn1.ObjectIdentifier{1, 3, 9999, 10, 1, 3}
+	oidSIKEp751 = asn1.ObjectIdentifier{1, 3, 9999, 10, 1, 4}
+	oidCSIDH512 = asn1.ObjectIdentifier{1, 3, 9999, 10, 2, 1}
+)
+
+func init() {
+	Register(SIKEp434, oidSIKEp434)
+	Register(SIKEp503, oidSIKEp503)
+	Register(SIKEp610, oidSIKEp610)
+	Register(SIKEp751, oidSIKEp751)
+	Register(CSIDH512, oidCSIDH512)
+}
@@ -0,0 +1,158 @@
+package kem
+
+import (
+	"io"
+
+	"github.com/henrydcase/nobs/dh/sidh"
+	"github.com/henrydcase/nobs/dh/sidh/common"
+)
+
+// SIKE schemes
+var (
+	SIKEp434 Scheme = &sikeScheme{name: "SIKEp434", id: sidh.Fp434}
+	SIKEp503 Scheme = &sikeScheme{name: "SIKEp503", id: sidh.Fp503}
+	SIKEp610 Scheme = &sikeScheme{name: "SIKEp610", id: sidh.Fp610}
+	SIKEp751 Scheme = &sikeScheme{name: "SIKEp751", id: sidh.Fp751}
+)
+
+// Size of a seed used by DeriveKeyPair
+const sikeSeedSize = sidh.MinSeedSize
+
+// Adapter of sidh.KEM. Private key is encoded as in the SIKE
+// specification, that is, as s || sk || pk.
+type sikeScheme struct {
+	name string
+	id   uint8
+}
+
+type sikePublicKey struct {
+	scheme *sikeScheme
+	pk     *sidh.PublicKey
+}
+
+type sikePrivateKey struct {
+	scheme *sikeScheme
+	sk     *sidh.PrivateKey
+	pk     *sidh.PublicKey
+}
+
+func (s *sikeScheme) params() *common.SidhParams { return common.Params(s.id) }
+func (s *sikeScheme) Name() string               { return s.name }
+func (s *sikeScheme) PublicKeySize() int         { return s.params().PublicKeySize }
+func (s *sikeScheme) CiphertextSize() int        { return s.params().CiphertextSize }
+func (s *sikeScheme) SharedKeySize() int         { return s.params().KemSize }
+func (s *sikeScheme) SeedSize() int              { return sikeSeedSize }
+
+func (s *sikeScheme) PrivateKeySize() int {
+	p := s.params()
+	return p.MsgLen + int(p.B.SecretByteLen) + p.PublicKeySize
+}
+
+// newKEM returns KEM for a single operation.
+func (s *sikeScheme) newKEM(rng io.Reader) *sidh.KEM {
+	var k sidh.KEM
+	k.Allocate(s.id, rng)
+	return &k
+}
+
+func (s *sikeScheme) GenerateKeyPair(rng io.Reader) (PublicKey, PrivateKey, error) {
+	sk := sidh.NewPrivateKey(s.id, sidh.KeyVariantSike)
+	pk := sidh.NewPublicKey(s.id, sidh.KeyVariantSike)
+	if err := sk.Generate(rng); err != nil {
+		return nil, nil, err
+	}
+	if err := sk.GeneratePublicKey(pk); err != nil {
+		return nil, nil, err
+	}
+	return &sikePublicKey{s, pk}, &sikePrivateKey{s, sk, pk}, nil
+}
+
+func (s *sikeScheme) DeriveKeyPair(seed []byte) (PublicKey, PrivateKey, error) {
+	if len(seed) != s.SeedSize() {
+		return nil, nil, ErrSeedSize
+	}
+	sk := sidh.NewPrivateKey(s.id, sidh.KeyVariantSike)
+	pk := sidh.NewPublicKey(s.id, sidh.KeyVariantSike)
+	if err := sk.DeriveKeyPair(pk, seed); err != nil {
+		return nil, nil, err
+	}
+	return &sikePublicKey{s, pk}, &sikePrivateKey{s, sk, pk}, nil
+}
+
+func (s *sikeScheme) Encapsulate(pk PublicKey, rng io.Reader) (ct, ss []byte, err error) {
+	pub, ok := pk.(*sikePublicKey)
+	if !ok || pub.scheme != s {
+		return nil, nil, ErrTypeMismatch
+	}
+	ct = make([]byte, s.CiphertextSize())
+	ss = make([]byte, s.SharedKeySize())
+	if err = s.newKEM(rng).Encapsulate(ct, ss, pub.pk); err != nil {
+		return nil, nil, err
+	}
+	return ct, ss, nil
+}
+
+func (s *sikeScheme) Decapsulate(sk PrivateKey, ct []byte) ([]byte, error) {
+	prv, ok := sk.(*sikePrivateKey)
+	if !ok || prv.scheme != s {
+		return nil, ErrTypeMismatch
+	}
+	if len(ct) != s.CiphertextSize() {
+		return nil, ErrCiphertextSize
+	}
+	ss := make([]byte, s.SharedKeySize())
+	if err := s.newKEM(nil).Decapsulate(ss, prv.sk, prv.pk, ct); err != nil {
+		return nil, err
+	}
+	return ss, nil
+}
+
+func (s *sikeScheme) UnmarshalBinaryPublicKey(buf []byte) (PublicKey, error) {
+	if len(buf) != s.PublicKeySize() {
+		return nil, ErrPubKeySize
+	}
+	pk := sidh.NewPublicKey(s.id, sidh.KeyVariantSike)
+	if err := pk.Import(buf); err != nil {
+		return nil, err
+	}
+	return &sikePublicKey{s, pk}, nil
+}
+
+func (s *sikeScheme) UnmarshalBinaryPrivateKey(buf []byte) (PrivateKey, error) {
+	if len(buf) != s.PrivateKeySize() {
+		return nil, ErrPrivKeySize
+	}
+	sk := sidh.NewPrivateKey(s.id, sidh.KeyVariantSike)
+	pk := sidh.NewPublicKey(s.id, sidh.KeyVariantSike)
+	if err := sk.Import(buf[:sk.Size()]); err != nil {
+		return nil, err
+	}
+	if err := pk.Import(buf[sk.Size():]); err != nil {
+		return nil, err
+	}
+	return &sikePrivateKey{s, sk, pk}, nil
+}
+
+func (k *sikePublicKey) Scheme() Scheme { return k.scheme }
+
+func (k *sikePublicKey) MarshalBinary() ([]byte, error) {
+	out := make([]byte, k.pk.Size())
+	if err := k.pk.Export(out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (k *sikePrivateKey) Scheme() Scheme    { return k.scheme }
+func (k *sikePrivateKey) Public() PublicKey { return &sikePublicKey{k.scheme, k.pk} }
+
+func (k *sikePrivateKey) MarshalBinary() ([]byte, error) {
+	out := make([]byte, k.scheme.PrivateKeySize())
+	if err := k.sk.Export(out); err != nil {
+		return nil, err
+	}
+	if err := k.pk.Export(out[k.sk.Size():]); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -1,5 +1,17 @@
 package utils
 
+import (
+	"os"
+	"strings"
+)
+
+// Name of the environment variable which can be used to mask CPU
+// features at startup. It contains comma separated list of feature
+// names, as listed in 'options' (for example "adx,aes"). Value "all"
+// masks all features. Unknown names are ignored. It is meant for
+// testing fallback implementations on a single machine.
+const disableEnv = "NOBS_DISABLE"
+
 type x86 struct {
 	// Signals support for MULX which is in BMI2
 	HasBMI2 bool
@@ -12,6 +24,100 @@ type x86 struct {
 
 	// Signals support for RDSEED
 	HasRDSEED bool
+
+	// Signals support for AVX2 (with OS support for YMM state)
+	HasAVX2 bool
+
+	// Signals support for AVX-512 Foundation (with OS support for ZMM state)
+	HasAVX512F bool
+
+	// Signals support for AVX-512 Integer Fused Multiply-Add
+	HasAVX512IFMA bool
+
+	// Signals support for AVX-512 Vector Length extensions
+	HasAVX512VL bool
+
+	// Signals support for SHA extensions (SHA-NI)
+	HasSHA bool
+
+	// Signals support for carry-less multiplication (PCLMULQDQ)
+	HasPCLMULQDQ bool
+
+	// Signals support for vector AES (with OS support for YMM state)
+	HasVAES bool
+}
+
+type arm64 struct {
+	// Signals support for Advanced SIMD
+	HasASIMD bool
+
+	// Signals support for AES instructions
+	HasAES bool
+
+	// Signals support for polynomial multiplication (PMULL)
+	HasPMULL bool
+
+	// Signals support for SHA-1 instructions
+	HasSHA1 bool
+
+	// Signals support for SHA-256 instructions
+	HasSHA2 bool
+
+	// Signals support for SHA3 instructions
+	HasSHA3 bool
+
+	// Signals support for SHA-512 instructions
+	HasSHA512 bool
+
+	// Signals support for large system extensions atomics
+	HasATOMICS bool
 }
 
 var X86 x86
+var ARM64 arm64
+
+// option maps name of the feature used in NOBS_DISABLE
+// to the flag.
+type option struct {
+	name    string
+	feature *bool
+}
+
+var options = []option{
+	{"bmi2", &X86.HasBMI2},
+	{"adx", &X86.HasADX},
+	{"aes", &X86.HasAES},
+	{"rdseed", &X86.HasRDSEED},
+	{"avx2", &X86.HasAVX2},
+	{"avx512f", &X86.HasAVX512F},
+	{"avx512ifma", &X86.HasAVX512IFMA},
+	{"avx512vl", &X86.HasAVX512VL},
+	{"sha", &X86.HasSHA},
+	{"pclmulqdq", &X86.HasPCLMULQDQ},
+	{"vaes", &X86.HasVAES},
+	{"asimd", &ARM64.HasASIMD},
+	{"aes", &ARM64.HasAES},
+	{"pmull", &ARM64.HasPMULL},
+	{"sha1", &ARM64.HasSHA1},
+	{"sha2", &ARM64.HasSHA2},
+	{"sha3", &ARM64.HasSHA3},
+	{"sha512", &ARM64.HasSHA512},
+	{"atomics", &ARM64.HasATOMICS},
+}
+
+// disable clears flags of features listed in 's'.
+func disable(s string) {
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		for _, o := range options {
+			if name == "all" || name == o.name {
+				*o.feature = false
+			}
+		}
+	}
+}
+
+func init() {
+	detect()
+	disable(os.Getenv(disableEnv))
+}
@@ -0,0 +1,55 @@
+// +build arm64,linux
+
+// Sets capabilities flags for ARMv8 according to HWCAP bits provided
+// by the Linux kernel in auxiliary vector.
+// https://www.kernel.org/doc/html/latest/arm64/elf_hwcaps.html
+
+package utils
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+)
+
+const (
+	// Auxiliary vector entry type of HWCAP
+	atHWCAP = 16
+
+	hwcapASIMD   = 1 << 1
+	hwcapAES     = 1 << 3
+	hwcapPMULL   = 1 << 4
+	hwcapSHA1    = 1 << 5
+	hwcapSHA2    = 1 << 6
+	hwcapATOMICS = 1 << 8
+	hwcapSHA3    = 1 << 17
+	hwcapSHA512  = 1 << 21
+)
+
+// Returns value of HWCAP read from /proc/self/auxv or 0
+// in case it can't be read.
+func hwcap() uint64 {
+	buf, err := ioutil.ReadFile("/proc/self/auxv")
+	if err != nil {
+		return 0
+	}
+
+	// auxv is a list of (type, value) pairs of 64-bit words
+	for ; len(buf) >= 16; buf = buf[16:] {
+		if binary.LittleEndian.Uint64(buf) == atHWCAP {
+			return binary.LittleEndian.Uint64(buf[8:])
+		}
+	}
+	return 0
+}
+
+func detect() {
+	h := hwcap()
+	ARM64.HasASIMD = h&hwcapASIMD != 0
+	ARM64.HasAES = h&hwcapAES != 0
+	ARM64.HasPMULL = h&hwcapPMULL != 0
+	ARM64.HasSHA1 = h&hwcapSHA1 != 0
+	ARM64.HasSHA2 = h&hwcapSHA2 != 0
+	ARM64.HasATOMICS = h&hwcapATOMICS != 0
+	ARM64.HasSHA3 = h&hwcapSHA3 != 0
+	ARM64.HasSHA512 = h&hwcapSHA512 != 0
+}
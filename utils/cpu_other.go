@@ -0,0 +1,7 @@
+// +build !amd64 noasm
+// +build !arm64 !linux
+
+package utils
+
+// No feature detection on this platform, all flags stay unset.
+func detect() {}
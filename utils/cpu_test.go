@@ -0,0 +1,24 @@
+package utils
+
+import "testing"
+
+func TestDisable(t *testing.T) {
+	x, a := X86, ARM64
+	defer func() { X86, ARM64 = x, a }()
+
+	X86.HasADX, X86.HasAES, X86.HasBMI2 = true, true, true
+	ARM64.HasAES = true
+	disable("ADX, aes,unknown")
+	if X86.HasADX || X86.HasAES || ARM64.HasAES {
+		t.Error("feature not disabled")
+	}
+	if !X86.HasBMI2 {
+		t.Error("wrong feature disabled")
+	}
+
+	X86.HasBMI2, ARM64.HasSHA2 = true, true
+	disable("all")
+	if X86.HasBMI2 || ARM64.HasSHA2 {
+		t.Error("feature not disabled")
+	}
+}
@@ -11,23 +11,49 @@ package utils
 // go:nosplit
 func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
 
+// Returns value of XCR0 register. Must be called only
+// if OSXSAVE is set.
+// go:nosplit
+func xgetbv() (eax, edx uint32)
+
 // Returns true in case bit 'n' in 'bits' is set, otherwise false
 func bitn(bits uint32, n uint8) bool {
 	return (bits>>n)&1 == 1
 }
 
-func init() {
+func detect() {
 	// CPUID returns max possible input that can be requested
 	max, _, _, _ := cpuid(0, 0)
-	if max < 7 {
+	if max < 1 {
 		return
 	}
 
-	_, ecx, _, _ := cpuid(1, 0)
-	X86.HasAES = bitn(ecx, 25)
+	_, _, ecx1, _ := cpuid(1, 0)
+	X86.HasAES = bitn(ecx1, 25)
+	X86.HasPCLMULQDQ = bitn(ecx1, 1)
+
+	// Check if CPU supports AVX (ECX bit 28) and OS saves YMM and
+	// ZMM registers on context switch (XCR0 bits 1,2 and 5,6,7
+	// respectively).
+	var osAVX, osAVX512 bool
+	if bitn(ecx1, 27) {
+		xcr0, _ := xgetbv()
+		osAVX = bitn(ecx1, 28) && xcr0&0x6 == 0x6
+		osAVX512 = osAVX && xcr0&0xE0 == 0xE0
+	}
+
+	if max < 7 {
+		return
+	}
 
-	_, ecx, _, _ = cpuid(7, 0)
-	X86.HasBMI2 = bitn(ecx, 8)
-	X86.HasADX = bitn(ecx, 19)
-	X86.HasRDSEED = bitn(ecx, 18)
+	_, ebx7, ecx7, _ := cpuid(7, 0)
+	X86.HasBMI2 = bitn(ebx7, 8)
+	X86.HasADX = bitn(ebx7, 19)
+	X86.HasRDSEED = bitn(ebx7, 18)
+	X86.HasSHA = bitn(ebx7, 29)
+	X86.HasAVX2 = osAVX && bitn(ebx7, 5)
+	X86.HasVAES = osAVX && bitn(ecx7, 9)
+	X86.HasAVX512F = osAVX512 && bitn(ebx7, 16)
+	X86.HasAVX512IFMA = X86.HasAVX512F && bitn(ebx7, 21)
+	X86.HasAVX512VL = X86.HasAVX512F && bitn(ebx7, 31)
 }
@@ -2,7 +2,7 @@
 
 #include "textflag.h"
 
-TEXT ·cpuid(SB), NOSPLIT, $0-4
+TEXT ·cpuid(SB), NOSPLIT, $0-24
     MOVL eaxArg+0(FP), AX
     MOVL ecxArg+4(FP), CX
     CPUID
@@ -11,3 +11,10 @@ TEXT ·cpuid(SB), NOSPLIT, $0-4
     MOVL CX, ecx+16(FP)
     MOVL DX, edx+20(FP)
     RET
+
+TEXT ·xgetbv(SB), NOSPLIT, $0-8
+    MOVL $0, CX
+    XGETBV
+    MOVL AX, eax+0(FP)
+    MOVL DX, edx+4(FP)
+    RET
@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"os"
+	"runtime"
+	"unsafe"
+)
+
+// SecureBuffer is a byte buffer for storing secret data, like private keys.
+// Memory is allocated on Go heap (which doesn't move objects) and aligned
+// to pages, so that no other object shares pages with it. Where supported,
+// those pages are locked in RAM with mlock(2), so that they are never
+// written to swap, and excluded from core dumps with madvise(MADV_DONTDUMP).
+// Memory is zeroized on Destroy.
+//
+// Locking is best-effort: it may fail if RLIMIT_MEMLOCK is too low, in which
+// case buffer is still usable and Locked() returns false. On platforms
+// without support only zeroization is provided.
+//
+// Slices returned by Bytes keep the memory alive, so they can be safely used
+// even if SecureBuffer itself is not referenced anymore. Memory is zeroized
+// and unlocked by the garbage collector only once it is not referenced by
+// any slice.
+type SecureBuffer struct {
+	// Usable part of the buffer
+	buf []byte
+	// Beginning of the allocation, finalizer is attached to it
+	base *byte
+	// Page aligned part of the allocation, which contains buf
+	pages []byte
+	// Indicates if pages are locked in RAM
+	locked bool
+}
+
+// NewSecureBuffer returns buffer which is able to store 'size' bytes.
+// Buffer is zeroized when garbage collected, nevertheless caller should
+// call Destroy as soon as buffer isn't needed.
+func NewSecureBuffer(size int) *SecureBuffer {
+	b := &SecureBuffer{}
+	if size <= 0 {
+		b.buf = make([]byte, 0)
+		return b
+	}
+
+	pg := os.Getpagesize()
+	n := (size + pg - 1) / pg * pg
+	mem := make([]byte, n+pg)
+	off := pg - int(uintptr(unsafe.Pointer(&mem[0])))%pg
+	b.base = &mem[0]
+	b.pages = mem[off : off+n : off+n]
+	b.buf = b.pages[:size:size]
+	b.locked = lockMem(b.pages)
+
+	// Finalizer must not keep reference to the memory, hence the
+	// region is recomputed from the base address.
+	locked := b.locked
+	runtime.SetFinalizer(b.base, func(p *byte) {
+		pages := (*[1 << 30]byte)(unsafe.Pointer(uintptr(unsafe.Pointer(p)) + uintptr(off)))[:n:n]
+		Zeroize(pages)
+		unlockMem(pages, locked)
+	})
+	return b
+}
+
+// Bytes returns the buffer. Slices returned before Destroy are zeroized
+// by it, but their memory stays valid.
+func (b *SecureBuffer) Bytes() []byte {
+	return b.buf
+}
+
+// Locked returns true if buffer is locked in RAM.
+func (b *SecureBuffer) Locked() bool {
+	return b.locked
+}
+
+// Destroy zeroizes the buffer and unlocks its memory. It is safe to call
+// Destroy multiple times.
+func (b *SecureBuffer) Destroy() {
+	if b.base != nil {
+		runtime.SetFinalizer(b.base, nil)
+		Zeroize(b.pages)
+		unlockMem(b.pages, b.locked)
+	}
+	b.buf, b.base, b.pages, b.locked = nil, nil, nil, false
+}
+
+// Zeroize sets all bytes of 'b' to zero.
+func Zeroize(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+	runtime.KeepAlive(b)
+}
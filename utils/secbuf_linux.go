@@ -0,0 +1,28 @@
+// +build linux
+
+package utils
+
+import "syscall"
+
+// Values of MADV_DONTDUMP and MADV_DODUMP, not exported by syscall package
+const (
+	madvDontDump = 0x10
+	madvDoDump   = 0x11
+)
+
+// lockMem excludes page aligned memory 'mem' from core dumps and tries
+// to lock it in RAM. Returns true if memory has been locked.
+func lockMem(mem []byte) bool {
+	// Fails only on kernels older than 3.4, nothing to do about it.
+	_ = syscall.Madvise(mem, madvDontDump)
+	return syscall.Mlock(mem) == nil
+}
+
+// unlockMem reverts lockMem, as memory is given back to Go heap and
+// may be used by other objects.
+func unlockMem(mem []byte, locked bool) {
+	if locked {
+		_ = syscall.Munlock(mem)
+	}
+	_ = syscall.Madvise(mem, madvDoDump)
+}
@@ -0,0 +1,8 @@
+// +build !linux
+
+package utils
+
+// Memory locking not supported, only zeroization is provided.
+func lockMem(mem []byte) bool { return false }
+
+func unlockMem(mem []byte, locked bool) {}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"bytes"
+	"runtime"
+	"testing"
+)
+
+func TestSecureBuffer(t *testing.T) {
+	for _, sz := range []int{0, 1, 32, 4097} {
+		b := NewSecureBuffer(sz)
+		buf := b.Bytes()
+		if len(buf) != sz || cap(buf) != sz {
+			t.Fatalf("wrong size of the buffer %d", len(buf))
+		}
+		if !bytes.Equal(buf, make([]byte, sz)) {
+			t.Error("buffer not zero initialized")
+		}
+		for i := range buf {
+			buf[i] = 0xAA
+		}
+		b.Destroy()
+		if b.Bytes() != nil || b.Locked() {
+			t.Error("buffer not released")
+		}
+		// Must be safe
+		b.Destroy()
+	}
+}
+
+// Memory must stay valid as long as slice returned by Bytes is used,
+// even if SecureBuffer is garbage collected.
+func TestSecureBufferGC(t *testing.T) {
+	var bufs [][]byte
+	for i := 0; i < 16; i++ {
+		buf := NewSecureBuffer(64).Bytes()
+		for j := range buf {
+			buf[j] = 0xAA
+		}
+		bufs = append(bufs, buf)
+	}
+	runtime.GC()
+	runtime.GC()
+	for _, buf := range bufs {
+		for j := range buf {
+			if buf[j] != 0xAA {
+				t.Fatal("buffer modified by garbage collector")
+			}
+			buf[j] = 0x55
+		}
+	}
+	// Runs finalizers
+	bufs = nil
+	runtime.GC()
+	runtime.GC()
+
+	// Slice taken before Destroy is zeroized, but usable
+	b := NewSecureBuffer(32)
+	buf := b.Bytes()
+	buf[0] = 1
+	b.Destroy()
+	runtime.GC()
+	if !bytes.Equal(buf, make([]byte, 32)) {
+		t.Error("buffer not zeroized")
+	}
+	buf[0] = 1
+}
+
+func TestZeroize(t *testing.T) {
+	b := []byte{1, 2, 3, 4}
+	Zeroize(b)
+	if !bytes.Equal(b, make([]byte, 4)) {
+		t.Error("buffer not zeroized")
+	}
+}